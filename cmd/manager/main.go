@@ -12,6 +12,8 @@ import (
 
 	"github.com/redhat-developer/service-binding-operator/pkg/apis"
 	"github.com/redhat-developer/service-binding-operator/pkg/controller"
+	"github.com/redhat-developer/service-binding-operator/pkg/controller/servicebindingrequest"
+	"github.com/redhat-developer/service-binding-operator/pkg/webhook"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/operator-framework/operator-sdk/pkg/leader"
@@ -76,6 +78,16 @@ func main() {
 	}
 
 	ctx := context.TODO()
+	stop := signals.SetupSignalHandler()
+
+	// Serve the health probes on every replica, the ones on standby while waiting to become the
+	// leader included
+	go func() {
+		if err := servicebindingrequest.ServeHealthProbes(stop); err != nil {
+			log.Error(err, "Failed to serve the health probes")
+			os.Exit(1)
+		}
+	}()
 
 	// Become the leader before proceeding
 	err = leader.Become(ctx, "service-binding-operator-lock")
@@ -109,6 +121,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup the admission webhooks when enabled, the webhook server needing the cluster
+	// permissions to install the webhook configurations
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Create Service object to expose the metrics port.
 	_, err = metrics.ExposeMetricsPort(ctx, metricsPort)
 	if err != nil {
@@ -118,7 +139,7 @@ func main() {
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(stop); err != nil {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
@@ -0,0 +1,44 @@
+#!/bin/bash
+# Prints the Role, along with its RoleBinding, granting the operator read access to the resources
+# of the API groups given, comma separated, e.g. "postgresql.example.org,redis.example.org": the
+# backing resources read through backingSelector.resourceRef, and watched for changes, and the
+# owners followed through applicationSelector.ownedRef. Their groups are only known once the
+# backing service operators are installed, deploy/role.yaml can't list them.
+set -e
+
+if [ -z "$1" ]; then
+    echo "usage: $0 <group>[,<group>...]" >&2
+    exit 1
+fi
+
+cat <<YAML
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: service-binding-operator-backing
+rules:
+- apiGroups:
+YAML
+for group in ${1//,/ }; do
+    echo "  - ${group}"
+done
+cat <<YAML
+  resources:
+  - '*'
+  verbs:
+  - get
+  - list
+  - watch
+---
+kind: RoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: service-binding-operator-backing
+subjects:
+- kind: ServiceAccount
+  name: service-binding-operator
+roleRef:
+  kind: Role
+  name: service-binding-operator-backing
+  apiGroup: rbac.authorization.k8s.io
+YAML
@@ -1,6 +1,7 @@
 package apis
 
 import (
+	osappsv1 "github.com/openshift/api/apps/v1"
 	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
 )
@@ -9,4 +10,5 @@ func init() {
 	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
 	AddToSchemes = append(AddToSchemes, v1alpha1.SchemeBuilder.AddToScheme)
 	AddToSchemes = append(AddToSchemes, olmv1alpha1.SchemeBuilder.AddToScheme)
+	AddToSchemes = append(AddToSchemes, osappsv1.SchemeBuilder.AddToScheme)
 }
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,8 +29,22 @@ type ServiceBindingRequestSpec struct {
 	//	backingSelector:
 	//		resourceName: database.example.org
 	//		resourceVersion: v1alpha1
+	// Example 3, a bare Kind resolved against the CRDs owned by the operators:
+	//	backingSelector:
+	//		resourceName: Database
 	BackingSelector BackingSelector `json:"backingSelector"`
 
+	// BackingServices are additional backing services, bound along with the one of the
+	// BackingSelector. Their keys are qualified by the service, the first label of its resource
+	// name, so a key found in several services doesn't collide: the "password" key of
+	// "redis.example.org" is bound as SBRNAME_REDIS_PASSWORD, or as the "redis/password" file, and
+	// is named "redis/password" in the env map annotation. Names still colliding, of services
+	// sharing the first label, are bound from the service listed last.
+	// Example:
+	//	backingServices:
+	//	- resourceName: redis.example.org
+	BackingServices []BackingSelector `json:"backingServices,omitempty"`
+
 	// ApplicationSelector is used to identify the application connecting to the
 	// backing service operator.
 	// Example 1:
@@ -41,22 +56,304 @@ type ServiceBindingRequestSpec struct {
 	// Example 2:
 	//	applicationSelector:
 	//		resourceKind: Deployment
-	//		resourceName: my-app
+	//		resourceRef: my-app
+	// Example 3, applications in all namespaces labeled with "team: payments":
+	//	applicationSelector:
+	//		matchLabels:
+	//			connects-to: postgres
+	//		namespaceSelector:
+	//			team: payments
+	//		resourceKind: Deployment
+	// Example 4, applications referenced one by one, in any namespace:
+	//	applicationSelector:
+	//		matchLabels: {}
+	//		refs:
+	//		- namespace: payments
+	//		  name: checkout
+	//		- namespace: shipping
+	//		  name: tracker
+	//		  kind: DeploymentConfig
+	// Example 5, a generic workload:
+	//	applicationSelector:
+	//		matchLabels:
+	//			connects-to: postgres
+	//		group: example.org
+	//		version: v1
+	//		resourceKind: Workload
+	//		containersPath: spec.containers
 	ApplicationSelector ApplicationSelector `json:"applicationSelector"`
+
+	// ContainerIndex is used to bind only the container at the given position
+	// in the application pod template. When absent, all containers are bound.
+	// Example:
+	//	containerIndex: 1
+	ContainerIndex *int `json:"containerIndex,omitempty"`
+
+	// BindInitContainers binds the init containers of the application pod template as well, e.g.
+	// running the database migrations, all of them as ContainerIndex and Containers select among
+	// the containers only.
+	BindInitContainers bool `json:"bindInitContainers,omitempty"`
+
+	// InlineNonSensitive sets the value of non-sensitive binding data, the keys collected from
+	// config maps, directly in the environment variable. Sensitive data, the keys collected from
+	// secrets, is always referenced with secretKeyRef.
+	InlineNonSensitive bool `json:"inlineNonSensitive,omitempty"`
+
+	// EnvVarPrefix replaces the default prefix of the environment variable names, the name of the
+	// ServiceBindingRequest in upper case followed by an underscore. The variables keep referencing
+	// the keys, only their names change.
+	// Example:
+	//	envVarPrefix: DATABASE_
+	EnvVarPrefix string `json:"envVarPrefix,omitempty"`
+
+	// Mappings rename the binding keys read from the backing services, before the environment
+	// variable names are built from them, or compose new keys from their values. The referenced
+	// secret and config map keys don't change.
+	// Example, binding the "user" key as SBRNAME_USERNAME, and a JDBC URL as SBRNAME_JDBC_URL:
+	//	mappings:
+	//	- from: user
+	//	  to: username
+	//	- to: jdbc-url
+	//	  value: "jdbc:postgresql://{{ .host }}:{{ .port }}/{{ .database }}"
+	Mappings []Mapping `json:"mappings,omitempty"`
+
+	// SecretName names the secret holding the keys flattened from JSON values by the mappings of
+	// type json, the type and provider files of BindAsFiles, the ApplicationValues, and the values
+	// composed by the mappings setting secret, "<name>-flattened" by default. The secret is owned
+	// by the ServiceBindingRequest, a secret of the name owned by anything else, e.g. another
+	// ServiceBindingRequest, is not overwritten.
+	// Example:
+	//	secretName: orders-binding
+	SecretName string `json:"secretName,omitempty"`
+
+	// SecretAnnotations are set on the secret named by SecretName, e.g. for a controller
+	// encrypting secrets at rest, along with the annotations set on it by others, the ones removed
+	// from the list being removed from the secret. The secret is only written when it holds keys,
+	// and deleted once it holds none.
+	// Example:
+	//	secretAnnotations:
+	//		kms.example.org/encrypt: "true"
+	SecretAnnotations map[string]string `json:"secretAnnotations,omitempty"`
+
+	// ApplicationValues copy values of the status of objects of the application side, e.g. the
+	// host of the Route exposing the application, into the secret named by SecretName, for the
+	// consumers of the secret. The values are not bound into the applications.
+	// Example, copying the host of the Route of the application as "host":
+	//	applicationValues:
+	//	- key: host
+	//	  apiVersion: route.openshift.io/v1
+	//	  kind: Route
+	//	  name: my-app
+	//	  path: ingress[0].host
+	ApplicationValues []ApplicationValue `json:"applicationValues,omitempty"`
+
+	// BindAsFiles projects the binding data as files, named after the keys, in a volume mounted
+	// at MountPath, instead of setting environment variables. The containers get the parent
+	// directory of MountPath as SERVICE_BINDING_ROOT, unless already set. The files include type
+	// and provider, the kind of the backing service in lower case and its group, unless collected
+	// from the backing service.
+	BindAsFiles bool `json:"bindAsFiles,omitempty"`
+
+	// MountPath is the directory the binding data is mounted at when BindAsFiles is set, defaults
+	// to "/bindings/<name>".
+	// Example:
+	//	mountPath: /var/run/postgres
+	MountPath string `json:"mountPath,omitempty"`
+
+	// DryRun computes the binding without applying it, to preview it: the environment variables,
+	// the applications that would change and the secrets and config maps that would be written are
+	// reported in the DryRun status, and none of them, nor the ServiceBindingRequest, are modified.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
+// Mapping renames a binding key, or composes one.
+// +k8s:openapi-gen=true
+type Mapping struct {
+	// From is the key as read from the backing service descriptors.
+	From string `json:"from,omitempty"`
+	// To is the key it is bound as.
+	To string `json:"to"`
+	// Value is a Go template composing the value of To, instead of renaming From, from the values
+	// of the other binding keys. Only the attributes and config map keys can be used, secrets
+	// being referenced and never read, and the keys of additional backing services are qualified,
+	// e.g. {{ index . "redis/host" }}.
+	Value string `json:"value,omitempty"`
+	// Type "json" flattens the JSON object held by the From secret key, base64 encoded or not,
+	// into a key per field, named after To and the field path, e.g. "db-host" and "db-auth-user"
+	// for {"host": ..., "auth": {"user": ...}} mapped to "db". The flattened keys are bound from a
+	// "<name>-flattened" secret the ServiceBindingRequest owns, not written on dry runs.
+	// Example:
+	//	mappings:
+	//	- from: connection
+	//	  to: db
+	//	  type: json
+	Type MappingType `json:"type,omitempty"`
+	// Secret writes the value composed by Value into the secret named by SecretName, the
+	// variable referencing it, along with the values of the keys it can be composed of, the
+	// attributes and config map keys, as they are. Consumers of the secret find both the
+	// composed value and its parts, the value being composed again as any of them changes.
+	// Example:
+	//	mappings:
+	//	- to: dsn
+	//	  value: "postgres://{{ .host }}:{{ .port }}/{{ .database }}"
+	//	  secret: true
+	Secret bool `json:"secret,omitempty"`
+}
+
+// MappingType is how a Mapping binds its From key.
+type MappingType string
+
+// MappingTypeJSON flattens the JSON object of a secret key into a key per field.
+const MappingTypeJSON MappingType = "json"
+
+// LatestResourceVersion selects the newest version of the CRD owned, like an empty ResourceVersion.
+const LatestResourceVersion = "*"
+
 // BackingSelector defines the selector based on resource name, version, and resource kind
 // +k8s:openapi-gen=true
 type BackingSelector struct {
-	ResourceName    string `json:"resourceName"`
-	ResourceVersion string `json:"resourceVersion"`
+	ResourceName string `json:"resourceName"`
+	// ResourceVersion is the version of the CRD to bind, the newest one owned when empty or "*",
+	// so the binding follows the upgrades of the operator.
+	// Example:
+	//	backingSelector:
+	//		resourceName: database.example.org
+	//		resourceVersion: "*"
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// ResourceRef is the name of the backing resource to bind, in the namespace of the
+	// ServiceBindingRequest, or in Namespace when set. The values of the spec and status
+	// descriptors, the names of secrets and config maps or attributes, are then read from its
+	// spec and status fields, instead of the spec descriptor paths being the names. Its
+	// "servicebinding.io/<key>" annotations, paths of its fields, e.g. "servicebinding.io/host:
+	// .status.address", are bound as attributes. When no descriptor names a secret, all the keys
+	// of the secrets labeled "service.binding/provider: <resourceRef>" are bound. A secret
+	// descriptor of a list field, e.g. "secrets" for "status: {secrets: [admin, app]}", binds the
+	// secrets listed, their keys prefixed by the secret name, e.g. "app-password".
+	// Example:
+	//	backingSelector:
+	//		resourceName: database.example.org
+	//		resourceRef: orders-db
+	ResourceRef string `json:"resourceRef,omitempty"`
+	// SecretRef names the secret of the binding data of the backing resource referenced by
+	// ResourceRef, for operators naming it after the resource rather than in its status, as a
+	// template evaluated over the fields of the resource. All the keys of the secret are bound.
+	// Example:
+	//	backingSelector:
+	//		resourceName: database.example.org
+	//		resourceRef: orders-db
+	//		secretRef: "{{ .metadata.name }}-credentials"
+	SecretRef string `json:"secretRef,omitempty"`
+	// Sources lists, in order, the sources to collect the binding data from, the first
+	// collecting any being the only one used, for backing services whose operators expose it
+	// differently across versions: "descriptors", the spec and status descriptors of the CSV,
+	// "annotations", the "servicebinding.io/<key>" annotations of the resource, "secretRef", the
+	// secret named by SecretRef, and "labels", the secrets labeled as provided by the resource.
+	// A source failing to collect, e.g. a status field not populated yet, falls back to the next
+	// as well. When empty, all the sources are collected from together.
+	// Example:
+	//	backingSelector:
+	//		resourceName: database.example.org
+	//		resourceRef: orders-db
+	//		secretRef: "{{ .metadata.name }}-credentials"
+	//		sources: [descriptors, secretRef]
+	Sources []string `json:"sources,omitempty"`
+	// Namespace is the namespace of the backing service, its CSV, backing resource, secrets and
+	// config maps, when not the namespace of the ServiceBindingRequest. The secrets and config
+	// maps are copied into the ServiceBindingRequest namespace. The namespace must be allowed by
+	// the operator, listed in its ALLOWED_BACKING_NAMESPACES environment variable, and the
+	// operator must watch the whole cluster, with a role in the namespace to read CSVs, backing
+	// resources, secrets and config maps.
+	// Example:
+	//	backingSelector:
+	//		resourceName: database.example.org
+	//		resourceRef: orders-db
+	//		namespace: data
+	Namespace string `json:"namespace,omitempty"`
+	// Include lists the secret keys to bind, leaving out the others, all when empty.
+	Include []string `json:"include,omitempty"`
+	// Exclude lists the secret keys not to bind, e.g. internal tokens, taking precedence over
+	// Include.
+	// Example:
+	//	backingSelector:
+	//		resourceName: database.example.org
+	//		exclude:
+	//		- admin-token
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 // ApplicationSelector defines the selector based on labels and resource kind
 // +k8s:openapi-gen=true
 type ApplicationSelector struct {
-	MatchLabels  map[string]string `json:"matchLabels"`
-	ResourceKind string            `json:"resourceKind"`
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// MatchExpressions are label selector requirements, e.g. the "tier" label being "web" or
+	// "api", or the "connects-to" label existing, selecting along with MatchLabels the
+	// applications bearing labels that meet them all.
+	// Example:
+	//	applicationSelector:
+	//		matchExpressions:
+	//		- key: connects-to
+	//		  operator: Exists
+	//		resourceKind: Deployment
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+	ResourceKind     string                            `json:"resourceKind"`
+	// ResourceRef is the name of the application to bind, in the namespace of the
+	// ServiceBindingRequest, taking the place of the label search when set.
+	ResourceRef string `json:"resourceRef,omitempty"`
+	// NamespaceSelector selects, by labels, the namespaces to search applications in. When
+	// empty, applications are searched in the ServiceBindingRequest namespace. Selecting other
+	// namespaces requires the operator to watch the whole cluster.
+	NamespaceSelector map[string]string `json:"namespaceSelector,omitempty"`
+	// Refs names the applications to bind, taking the place of the label search when set.
+	// Secrets and config maps are mirrored in the referenced namespaces.
+	Refs []NamespacedRef `json:"refs,omitempty"`
+	// OwnedRef names an object of the ServiceBindingRequest namespace, e.g. a custom resource of
+	// an operator managed application or one of its pods, whose owner of ResourceKind is the
+	// application to bind, found following the controller ownerReferences from the object. It
+	// takes the place of the label search when set.
+	OwnedRef *OwnedRef `json:"ownedRef,omitempty"`
+	// Group and Version of the application, taken with ResourceKind as a generic workload when
+	// Version is set, instead of one of the supported kinds.
+	Group   string `json:"group,omitempty"`
+	Version string `json:"version,omitempty"`
+	// ContainersPath is the dot separated path of the containers of generic workloads. When empty,
+	// "spec.template.spec.containers" is used, or "spec.containers" when only that one exists.
+	ContainersPath string `json:"containersPath,omitempty"`
+	// Containers names the containers to bind, leaving out the others, e.g. sidecars. When empty,
+	// all containers are bound. It can't be set along with ContainerIndex.
+	Containers []string `json:"containers,omitempty"`
+}
+
+// NamespacedRef references an application object in a namespace.
+// +k8s:openapi-gen=true
+type NamespacedRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Kind of the application, ResourceKind is used when empty.
+	Kind string `json:"kind,omitempty"`
+}
+
+// ApplicationValue is a value of the status of an object of the ServiceBindingRequest namespace.
+// +k8s:openapi-gen=true
+type ApplicationValue struct {
+	// Key is the key of the value in the secret.
+	Key string `json:"key"`
+	// APIVersion of the object, e.g. "route.openshift.io/v1".
+	APIVersion string `json:"apiVersion"`
+	// Kind of the object, e.g. "Route".
+	Kind string `json:"kind"`
+	// Name of the object.
+	Name string `json:"name"`
+	// Path of the value, relative to the status of the object as the paths of the status
+	// descriptors, e.g. "ingress[0].host".
+	Path string `json:"path"`
+}
+
+// OwnedRef references an object owned by an application, directly or through its owners.
+// +k8s:openapi-gen=true
+type OwnedRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
 }
 
 // ServiceBindingRequestStatus defines the observed state of ServiceBindingRequest
@@ -64,6 +361,132 @@ type ApplicationSelector struct {
 type ServiceBindingRequestStatus struct {
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book.kubebuilder.io/beyond_basics/generating_crd.html
+
+	// Phase summarizes the conditions in a single value, meant for simple
+	// polling, e.g. "kubectl get sbr -o jsonpath='{.status.phase}'".
+	Phase ServiceBindingRequestPhase `json:"phase,omitempty"`
+
+	// Conditions describes the detailed state of the binding steps.
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// ConsumedKeys lists the binding keys each application reported as consumed, through the
+	// "servicebinding.dev/consumed-keys" annotation on the application object.
+	ConsumedKeys []ConsumedKeys `json:"consumedKeys,omitempty"`
+
+	// ApplicationObjects lists the application objects bound, as "namespace/name".
+	ApplicationObjects []string `json:"applicationObjects,omitempty"`
+
+	// BoundApplications is the number of application objects bound.
+	BoundApplications int `json:"boundApplications,omitempty"`
+
+	// FailedApplications lists the application objects that failed to be bound, the others
+	// being bound still.
+	FailedApplications []ApplicationFailure `json:"failedApplications,omitempty"`
+
+	// Secrets lists the names of the secrets the binding data is read from, in the
+	// ServiceBindingRequest namespace.
+	Secrets []string `json:"secrets,omitempty"`
+
+	// DryRun is the binding that would be applied, set when the DryRun spec field is.
+	DryRun *DryRunStatus `json:"dryRun,omitempty"`
+
+	// CollectedSources lists the source the binding data of each backing service listing
+	// Sources was collected from.
+	CollectedSources []CollectedSource `json:"collectedSources,omitempty"`
+}
+
+// CollectedSource is the source the binding data of a backing service was collected from.
+// +k8s:openapi-gen=true
+type CollectedSource struct {
+	// ResourceName is the resource name of the backing service, as in its BackingSelector.
+	ResourceName string `json:"resourceName"`
+	// Source is the first of its Sources that collected binding data, e.g. "secretRef".
+	Source string `json:"source"`
+}
+
+// DryRunStatus is the binding a ServiceBindingRequest would apply, see DryRun.
+// +k8s:openapi-gen=true
+type DryRunStatus struct {
+	// EnvVars are the names of the environment variables that would be injected, before the
+	// applications rename them.
+	EnvVars []string `json:"envVars,omitempty"`
+	// ApplicationObjects lists the application objects that would change, as "namespace/name".
+	ApplicationObjects []string `json:"applicationObjects,omitempty"`
+	// Secrets lists the secrets that would be written, the copies of the secrets of another
+	// namespace and the secret of the flattened keys, as "namespace/name".
+	Secrets []string `json:"secrets,omitempty"`
+	// ConfigMaps lists the copies of the config maps of another namespace that would be written,
+	// as "namespace/name".
+	ConfigMaps []string `json:"configMaps,omitempty"`
+}
+
+// ConsumedKeys are the binding keys an application reported as consumed.
+// +k8s:openapi-gen=true
+type ConsumedKeys struct {
+	// Application is the name of the application object.
+	Application string `json:"application"`
+	// Keys are the names of the binding environment variables in use.
+	Keys []string `json:"keys"`
+}
+
+// ApplicationFailure is the failure binding an application.
+// +k8s:openapi-gen=true
+type ApplicationFailure struct {
+	// Application is the application object, as "namespace/name".
+	Application string `json:"application"`
+	// Message is the error binding it.
+	Message string `json:"message"`
+}
+
+// ServiceBindingRequestPhase is a coarse summary of the binding state.
+type ServiceBindingRequestPhase string
+
+const (
+	// PhasePending means the binding has not started or is waiting on the backing service, or on
+	// applications to match the application selector.
+	PhasePending ServiceBindingRequestPhase = "Pending"
+	// PhaseCollecting means the binding data is being collected from the backing services, for
+	// the first time.
+	PhaseCollecting ServiceBindingRequestPhase = "Collecting"
+	// PhaseBinding means the binding data was collected and is being injected.
+	PhaseBinding ServiceBindingRequestPhase = "Binding"
+	// PhaseReady means the binding data was injected in the applications.
+	PhaseReady ServiceBindingRequestPhase = "Ready"
+	// PhaseError means at least one of the binding steps failed.
+	PhaseError ServiceBindingRequestPhase = "Error"
+)
+
+// ConditionType names a step of the binding process.
+type ConditionType string
+
+const (
+	// CollectionReady indicates the binding data was collected from the backing service.
+	CollectionReady ConditionType = "CollectionReady"
+	// SourceSecretFound indicates the secrets of the backing service exist.
+	SourceSecretFound ConditionType = "SourceSecretFound"
+	// InjectionReady indicates the binding data was injected in the applications.
+	InjectionReady ConditionType = "InjectionReady"
+	// ApplicationsMatched indicates applications match the application selector, its message
+	// giving their count, or echoing the selector when none does.
+	ApplicationsMatched ConditionType = "ApplicationsMatched"
+	// Ready summarizes the other conditions as the phase does, being True once the binding data
+	// was injected in the applications and False when a binding step failed.
+	Ready ConditionType = "Ready"
+)
+
+// Condition describes the state of a binding step at a certain point.
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type of the condition.
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is the last time the condition changed its status.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a brief machine readable reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the condition.
+	Message string `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -71,6 +494,10 @@ type ServiceBindingRequestStatus struct {
 // ServiceBindingRequest is the Schema for the servicebindings API
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the binding is ready"
+// +kubebuilder:printcolumn:name="Secret",type="string",JSONPath=".status.secrets[0]",description="The first secret the binding data is read from"
+// +kubebuilder:printcolumn:name="Applications",type="integer",JSONPath=".status.boundApplications",description="The number of applications bound"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type ServiceBindingRequest struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -13,24 +13,434 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationFailure":          schema_pkg_apis_apps_v1alpha1_ApplicationFailure(ref),
 		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationSelector":         schema_pkg_apis_apps_v1alpha1_ApplicationSelector(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationValue":            schema_pkg_apis_apps_v1alpha1_ApplicationValue(ref),
 		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.BackingSelector":             schema_pkg_apis_apps_v1alpha1_BackingSelector(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.CollectedSource":             schema_pkg_apis_apps_v1alpha1_CollectedSource(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.Condition":                   schema_pkg_apis_apps_v1alpha1_Condition(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ConsumedKeys":                schema_pkg_apis_apps_v1alpha1_ConsumedKeys(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.DryRunStatus":                schema_pkg_apis_apps_v1alpha1_DryRunStatus(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.Mapping":                     schema_pkg_apis_apps_v1alpha1_Mapping(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.NamespacedRef":               schema_pkg_apis_apps_v1alpha1_NamespacedRef(ref),
+		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.OwnedRef":                    schema_pkg_apis_apps_v1alpha1_OwnedRef(ref),
 		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ServiceBindingRequest":       schema_pkg_apis_apps_v1alpha1_ServiceBindingRequest(ref),
 		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ServiceBindingRequestSpec":   schema_pkg_apis_apps_v1alpha1_ServiceBindingRequestSpec(ref),
 		"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ServiceBindingRequestStatus": schema_pkg_apis_apps_v1alpha1_ServiceBindingRequestStatus(ref),
 	}
 }
 
+func schema_pkg_apis_apps_v1alpha1_ApplicationFailure(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApplicationFailure is the failure binding an application.",
+				Properties: map[string]spec.Schema{
+					"application": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Application is the application object, as \"namespace/name\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is the error binding it.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"application", "message"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_apps_v1alpha1_ApplicationSelector(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "ApplicationSelector defines the selector based on labels and resource kind",
+				Description: "ApplicationSelector defines the selector based on labels and resource kind",
+				Properties: map[string]spec.Schema{
+					"matchLabels": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"matchExpressions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MatchExpressions are label selector requirements, e.g. the \"tier\" label being \"web\" or \"api\", or the \"connects-to\" label existing, selecting along with MatchLabels the applications bearing labels that meet them all. Example:\n\tapplicationSelector:\n\t\tmatchExpressions:\n\t\t- key: connects-to\n\t\t  operator: Exists\n\t\tresourceKind: Deployment",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelectorRequirement"),
+									},
+								},
+							},
+						},
+					},
+					"resourceKind": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"resourceRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceRef is the name of the application to bind, in the namespace of the ServiceBindingRequest, taking the place of the label search when set.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"namespaceSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NamespaceSelector selects, by labels, the namespaces to search applications in. When empty, applications are searched in the ServiceBindingRequest namespace. Selecting other namespaces requires the operator to watch the whole cluster.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"refs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Refs names the applications to bind, taking the place of the label search when set. Secrets and config maps are mirrored in the referenced namespaces.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.NamespacedRef"),
+									},
+								},
+							},
+						},
+					},
+					"ownedRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "OwnedRef names an object of the ServiceBindingRequest namespace, e.g. a custom resource of an operator managed application or one of its pods, whose owner of ResourceKind is the application to bind, found following the controller ownerReferences from the object. It takes the place of the label search when set.",
+							Ref:         ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.OwnedRef"),
+						},
+					},
+					"group": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Group and Version of the application, taken with ResourceKind as a generic workload when Version is set, instead of one of the supported kinds.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"containersPath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContainersPath is the dot separated path of the containers of generic workloads. When empty, \"spec.template.spec.containers\" is used, or \"spec.containers\" when only that one exists.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"containers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Containers names the containers to bind, leaving out the others, e.g. sidecars. When empty, all containers are bound. It can't be set along with ContainerIndex.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"resourceKind"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.NamespacedRef", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.OwnedRef", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelectorRequirement"},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_ApplicationValue(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApplicationValue is a value of the status of an object of the ServiceBindingRequest namespace.",
+				Properties: map[string]spec.Schema{
+					"key": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key is the key of the value in the secret.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion of the object, e.g. \"route.openshift.io/v1\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind of the object, e.g. \"Route\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the object.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path of the value, relative to the status of the object as the paths of the status descriptors, e.g. \"ingress[0].host\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"key", "apiVersion", "kind", "name", "path"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_BackingSelector(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "BackingSelector defines the selector based on resource name, version, and resource kind",
+				Properties: map[string]spec.Schema{
+					"resourceName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"resourceVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceVersion is the version of the CRD to bind, the newest one owned when empty or \"*\", so the binding follows the upgrades of the operator. Example:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\tresourceVersion: \"*\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resourceRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceRef is the name of the backing resource to bind, in the namespace of the ServiceBindingRequest, or in Namespace when set. The values of the spec and status descriptors, the names of secrets and config maps or attributes, are then read from its spec and status fields, instead of the spec descriptor paths being the names. Its \"servicebinding.io/<key>\" annotations, paths of its fields, e.g. \"servicebinding.io/host: .status.address\", are bound as attributes. When no descriptor names a secret, all the keys of the secrets labeled \"service.binding/provider: <resourceRef>\" are bound. A secret descriptor of a list field, e.g. \"secrets\" for \"status: {secrets: [admin, app]}\", binds the secrets listed, their keys prefixed by the secret name, e.g. \"app-password\". Example:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\tresourceRef: orders-db",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"secretRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretRef names the secret of the binding data of the backing resource referenced by ResourceRef, for operators naming it after the resource rather than in its status, as a template evaluated over the fields of the resource. All the keys of the secret are bound. Example:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\tresourceRef: orders-db\n\t\tsecretRef: \"{{ .metadata.name }}-credentials\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"sources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Sources lists, in order, the sources to collect the binding data from, the first collecting any being the only one used, for backing services whose operators expose it differently across versions: \"descriptors\", the spec and status descriptors of the CSV, \"annotations\", the \"servicebinding.io/<key>\" annotations of the resource, \"secretRef\", the secret named by SecretRef, and \"labels\", the secrets labeled as provided by the resource. A source failing to collect, e.g. a status field not populated yet, falls back to the next as well. When empty, all the sources are collected from together. Example:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\tresourceRef: orders-db\n\t\tsecretRef: \"{{ .metadata.name }}-credentials\"\n\t\tsources: [descriptors, secretRef]",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace is the namespace of the backing service, its CSV, backing resource, secrets and config maps, when not the namespace of the ServiceBindingRequest. The secrets and config maps are copied into the ServiceBindingRequest namespace. The namespace must be allowed by the operator, listed in its ALLOWED_BACKING_NAMESPACES environment variable, and the operator must watch the whole cluster, with a role in the namespace to read CSVs, backing resources, secrets and config maps. Example:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\tresourceRef: orders-db\n\t\tnamespace: data",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"include": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Include lists the secret keys to bind, leaving out the others, all when empty.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"exclude": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Exclude lists the secret keys not to bind, e.g. internal tokens, taking precedence over Include. Example:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\texclude:\n\t\t- admin-token",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"resourceName"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_CollectedSource(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CollectedSource is the source the binding data of a backing service was collected from.",
+				Properties: map[string]spec.Schema{
+					"resourceName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceName is the resource name of the backing service, as in its BackingSelector.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"source": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Source is the first of its Sources that collected binding data, e.g. \"secretRef\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"resourceName", "source"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_Condition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Condition describes the state of a binding step at a certain point.",
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the condition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the condition, one of True, False or Unknown.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastTransitionTime is the last time the condition changed its status.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason is a brief machine readable reason for the condition's last transition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is a human readable description of the condition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "status"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_ConsumedKeys(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ConsumedKeys are the binding keys an application reported as consumed.",
+				Properties: map[string]spec.Schema{
+					"application": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Application is the name of the application object.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"keys": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Keys are the names of the binding environment variables in use.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"application", "keys"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_DryRunStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DryRunStatus is the binding a ServiceBindingRequest would apply, see DryRun.",
 				Properties: map[string]spec.Schema{
-					"matchLabels": {
+					"envVars": {
 						SchemaProps: spec.SchemaProps{
-							Type: []string{"object"},
-							AdditionalProperties: &spec.SchemaOrBool{
+							Description: "EnvVars are the names of the environment variables that would be injected, before the applications rename them.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
 										Type:   []string{"string"},
@@ -40,40 +450,163 @@ func schema_pkg_apis_apps_v1alpha1_ApplicationSelector(ref common.ReferenceCallb
 							},
 						},
 					},
-					"resourceKind": {
+					"applicationObjects": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ApplicationObjects lists the application objects that would change, as \"namespace/name\".",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"secrets": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Secrets lists the secrets that would be written, the copies of the secrets of another namespace and the secret of the flattened keys, as \"namespace/name\".",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"configMaps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ConfigMaps lists the copies of the config maps of another namespace that would be written, as \"namespace/name\".",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_Mapping(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Mapping renames a binding key, or composes one.",
+				Properties: map[string]spec.Schema{
+					"from": {
+						SchemaProps: spec.SchemaProps{
+							Description: "From is the key as read from the backing service descriptors.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"to": {
+						SchemaProps: spec.SchemaProps{
+							Description: "To is the key it is bound as.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"value": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value is a Go template composing the value of To, instead of renaming From, from the values of the other binding keys. Only the attributes and config map keys can be used, secrets being referenced and never read, and the keys of additional backing services are qualified, e.g. {{ index . \"redis/host\" }}.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type \"json\" flattens the JSON object held by the From secret key, base64 encoded or not, into a key per field, named after To and the field path, e.g. \"db-host\" and \"db-auth-user\" for {\"host\": ..., \"auth\": {\"user\": ...}} mapped to \"db\". The flattened keys are bound from a \"<name>-flattened\" secret the ServiceBindingRequest owns, not written on dry runs. Example:\n\tmappings:\n\t- from: connection\n\t  to: db\n\t  type: json",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"secret": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Secret writes the value composed by Value into the secret named by SecretName, the variable referencing it, along with the values of the keys it can be composed of, the attributes and config map keys, as they are. Consumers of the secret find both the composed value and its parts, the value being composed again as any of them changes. Example:\n\tmappings:\n\t- to: dsn\n\t  value: \"postgres://{{ .host }}:{{ .port }}/{{ .database }}\"\n\t  secret: true",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"to"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_apps_v1alpha1_NamespacedRef(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "NamespacedRef references an application object in a namespace.",
+				Properties: map[string]spec.Schema{
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"name": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
 							Format: "",
 						},
 					},
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind of the application, ResourceKind is used when empty.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
-				Required: []string{"matchLabels", "resourceKind"},
+				Required: []string{"namespace", "name"},
 			},
 		},
 		Dependencies: []string{},
 	}
 }
 
-func schema_pkg_apis_apps_v1alpha1_BackingSelector(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_apps_v1alpha1_OwnedRef(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "BackingSelector defines the selector based on resource name, version, and resource kind",
+				Description: "OwnedRef references an object owned by an application, directly or through its owners.",
 				Properties: map[string]spec.Schema{
-					"resourceName": {
+					"apiVersion": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
 							Format: "",
 						},
 					},
-					"resourceVersion": {
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"name": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
 							Format: "",
 						},
 					},
 				},
-				Required: []string{"resourceName", "resourceVersion"},
+				Required: []string{"apiVersion", "kind", "name"},
 			},
 		},
 		Dependencies: []string{},
@@ -131,22 +664,131 @@ func schema_pkg_apis_apps_v1alpha1_ServiceBindingRequestSpec(ref common.Referenc
 				Properties: map[string]spec.Schema{
 					"backingSelector": {
 						SchemaProps: spec.SchemaProps{
-							Description: "BackingSelector is used to identify the backing service operator.\n\nRefer: https://12factor.net/backing-services A backing service is any service the app consumes over the network as part of its normal operation. Examples include datastores (such as MySQL or CouchDB), messaging/queueing systems (such as RabbitMQ or Beanstalkd), SMTP services for outbound email (such as Postfix), and caching systems (such as Memcached).\n\nExample 1:\n\tbackingSelector:\n\t\tresourceName: database.example.org\nExample 2:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\tresourceVersion: v1alpha1",
+							Description: "BackingSelector is used to identify the backing service operator.\n\nRefer: https://12factor.net/backing-services A backing service is any service the app consumes over the network as part of its normal operation. Examples include datastores (such as MySQL or CouchDB), messaging/queueing systems (such as RabbitMQ or Beanstalkd), SMTP services for outbound email (such as Postfix), and caching systems (such as Memcached).\n\nExample 1:\n\tbackingSelector:\n\t\tresourceName: database.example.org\nExample 2:\n\tbackingSelector:\n\t\tresourceName: database.example.org\n\t\tresourceVersion: v1alpha1\nExample 3, a bare Kind resolved against the CRDs owned by the operators:\n\tbackingSelector:\n\t\tresourceName: Database",
 							Ref:         ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.BackingSelector"),
 						},
 					},
+					"backingServices": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BackingServices are additional backing services, bound along with the one of the BackingSelector. Their keys are qualified by the service, the first label of its resource name, so a key found in several services doesn't collide: the \"password\" key of \"redis.example.org\" is bound as SBRNAME_REDIS_PASSWORD, or as the \"redis/password\" file, and is named \"redis/password\" in the env map annotation. Names still colliding, of services sharing the first label, are bound from the service listed last. Example:\n\tbackingServices:\n\t- resourceName: redis.example.org",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.BackingSelector"),
+									},
+								},
+							},
+						},
+					},
 					"applicationSelector": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ApplicationSelector is used to identify the application connecting to the backing service operator. Example 1:\n\tapplicationSelector:\n\t\tmatchLabels:\n\t\t\tconnects-to: postgres\n\t\t\tenvironment: stage\n\t\tresourceKind: Deployment\nExample 2:\n\tapplicationSelector:\n\t\tresourceKind: Deployment\n\t\tresourceName: my-app",
+							Description: "ApplicationSelector is used to identify the application connecting to the backing service operator. Example 1:\n\tapplicationSelector:\n\t\tmatchLabels:\n\t\t\tconnects-to: postgres\n\t\t\tenvironment: stage\n\t\tresourceKind: Deployment\nExample 2:\n\tapplicationSelector:\n\t\tresourceKind: Deployment\n\t\tresourceRef: my-app\nExample 3, applications in all namespaces labeled with \"team: payments\":\n\tapplicationSelector:\n\t\tmatchLabels:\n\t\t\tconnects-to: postgres\n\t\tnamespaceSelector:\n\t\t\tteam: payments\n\t\tresourceKind: Deployment\nExample 4, applications referenced one by one, in any namespace:\n\tapplicationSelector:\n\t\tmatchLabels: {}\n\t\trefs:\n\t\t- namespace: payments\n\t\t  name: checkout\n\t\t- namespace: shipping\n\t\t  name: tracker\n\t\t  kind: DeploymentConfig\nExample 5, a generic workload:\n\tapplicationSelector:\n\t\tmatchLabels:\n\t\t\tconnects-to: postgres\n\t\tgroup: example.org\n\t\tversion: v1\n\t\tresourceKind: Workload\n\t\tcontainersPath: spec.containers",
 							Ref:         ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationSelector"),
 						},
 					},
+					"containerIndex": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContainerIndex is used to bind only the container at the given position in the application pod template. When absent, all containers are bound. Example:\n\tcontainerIndex: 1",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"bindInitContainers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BindInitContainers binds the init containers of the application pod template as well, e.g. running the database migrations, all of them as ContainerIndex and Containers select among the containers only.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"inlineNonSensitive": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InlineNonSensitive sets the value of non-sensitive binding data, the keys collected from config maps, directly in the environment variable. Sensitive data, the keys collected from secrets, is always referenced with secretKeyRef.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"envVarPrefix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EnvVarPrefix replaces the default prefix of the environment variable names, the name of the ServiceBindingRequest in upper case followed by an underscore. The variables keep referencing the keys, only their names change. Example:\n\tenvVarPrefix: DATABASE_",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"mappings": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Mappings rename the binding keys read from the backing services, before the environment variable names are built from them, or compose new keys from their values. The referenced secret and config map keys don't change. Example, binding the \"user\" key as SBRNAME_USERNAME, and a JDBC URL as SBRNAME_JDBC_URL:\n\tmappings:\n\t- from: user\n\t  to: username\n\t- to: jdbc-url\n\t  value: \"jdbc:postgresql://{{ .host }}:{{ .port }}/{{ .database }}\"",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.Mapping"),
+									},
+								},
+							},
+						},
+					},
+					"secretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretName names the secret holding the keys flattened from JSON values by the mappings of type json, the type and provider files of BindAsFiles, the ApplicationValues, and the values composed by the mappings setting secret, \"<name>-flattened\" by default. The secret is owned by the ServiceBindingRequest, a secret of the name owned by anything else, e.g. another ServiceBindingRequest, is not overwritten. Example:\n\tsecretName: orders-binding",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"secretAnnotations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretAnnotations are set on the secret named by SecretName, e.g. for a controller encrypting secrets at rest, along with the annotations set on it by others, the ones removed from the list being removed from the secret. The secret is only written when it holds keys, and deleted once it holds none. Example:\n\tsecretAnnotations:\n\t\tkms.example.org/encrypt: \"true\"",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"applicationValues": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ApplicationValues copy values of the status of objects of the application side, e.g. the host of the Route exposing the application, into the secret named by SecretName, for the consumers of the secret. The values are not bound into the applications. Example, copying the host of the Route of the application as \"host\":\n\tapplicationValues:\n\t- key: host\n\t  apiVersion: route.openshift.io/v1\n\t  kind: Route\n\t  name: my-app\n\t  path: ingress[0].host",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationValue"),
+									},
+								},
+							},
+						},
+					},
+					"bindAsFiles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BindAsFiles projects the binding data as files, named after the keys, in a volume mounted at MountPath, instead of setting environment variables. The containers get the parent directory of MountPath as SERVICE_BINDING_ROOT, unless already set. The files include type and provider, the kind of the backing service in lower case and its group, unless collected from the backing service.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"mountPath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MountPath is the directory the binding data is mounted at when BindAsFiles is set, defaults to \"/bindings/<name>\". Example:\n\tmountPath: /var/run/postgres",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"dryRun": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DryRun computes the binding without applying it, to preview it: the environment variables, the applications that would change and the secrets and config maps that would be written are reported in the DryRun status, and none of them, nor the ServiceBindingRequest, are modified.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"backingSelector", "applicationSelector"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationSelector", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.BackingSelector"},
+			"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationSelector", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationValue", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.BackingSelector", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.Mapping"},
 	}
 }
 
@@ -155,9 +797,111 @@ func schema_pkg_apis_apps_v1alpha1_ServiceBindingRequestStatus(ref common.Refere
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
 				Description: "ServiceBindingRequestStatus defines the observed state of ServiceBindingRequest",
-				Properties:  map[string]spec.Schema{},
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase summarizes the conditions in a single value, meant for simple polling, e.g. \"kubectl get sbr -o jsonpath='{.status.phase}'\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions describes the detailed state of the binding steps.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"consumedKeys": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ConsumedKeys lists the binding keys each application reported as consumed, through the \"servicebinding.dev/consumed-keys\" annotation on the application object.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ConsumedKeys"),
+									},
+								},
+							},
+						},
+					},
+					"applicationObjects": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ApplicationObjects lists the application objects bound, as \"namespace/name\".",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"boundApplications": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BoundApplications is the number of application objects bound.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"failedApplications": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailedApplications lists the application objects that failed to be bound, the others being bound still.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationFailure"),
+									},
+								},
+							},
+						},
+					},
+					"secrets": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Secrets lists the names of the secrets the binding data is read from, in the ServiceBindingRequest namespace.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"dryRun": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DryRun is the binding that would be applied, set when the DryRun spec field is.",
+							Ref:         ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.DryRunStatus"),
+						},
+					},
+					"collectedSources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CollectedSources lists the source the binding data of each backing service listing Sources was collected from.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.CollectedSource"),
+									},
+								},
+							},
+						},
+					},
+				},
 			},
 		},
-		Dependencies: []string{},
+		Dependencies: []string{
+			"github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ApplicationFailure", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.CollectedSource", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.Condition", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.ConsumedKeys", "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1.DryRunStatus"},
 	}
 }
@@ -0,0 +1,1941 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"encoding/json"
+	errs "errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	osappsv1 "github.com/openshift/api/apps/v1"
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// envMapAnnotation is set on the pod template of an application to name the environment variables
+// of binding keys, e.g. "user=DB_USERNAME,password=DB_PASSWORD". Keys not in the map get the
+// default SBRNAME_KEY name.
+const envMapAnnotation = "servicebinding.dev/map"
+
+// boundEnvsAnnotation is set on the pod template of an application, or on a generic workload, with
+// the environment variables each ServiceBindingRequest injected, as a JSON object of their names by
+// "namespace/name" of the ServiceBindingRequest, e.g. {"default/postgres": ["POSTGRES_PASSWORD"]}.
+// Unbinding removes the variables recorded only, the ones of the application being left alone.
+// Every ServiceBindingRequest binding the application has an entry, none injected when bound as
+// files, which marks the applications left bound by a deleted one, see UnbindOrphans.
+const boundEnvsAnnotation = "servicebinding.dev/bound-envs"
+
+// secretAnnotationsAnnotation is set on the secret of the flattened keys with the sorted, comma
+// separated keys of the SecretAnnotations written on it, e.g. "kms.example.org/encrypt", so the
+// ones removed from the spec are removed from the secret, the ones set by others being kept.
+const secretAnnotationsAnnotation = "servicebinding.dev/secret-annotations"
+
+// serviceBindingRootEnv tells applications the directory the bindings mounted as files are found in.
+const serviceBindingRootEnv = "SERVICE_BINDING_ROOT"
+
+// defaultContainersPaths are where the containers of generic workloads are looked for, in order,
+// unless the ApplicationSelector sets ContainersPath or the kind is in podSpecPaths: in a pod
+// template, as workload controllers have, or else directly in the spec, as pod-like kinds have.
+var defaultContainersPaths = []string{"spec.template.spec.containers", "spec.containers"}
+
+// podSpecPaths are the paths of the pod spec of the known kinds, by group and kind, the containers,
+// init containers and volumes being kept in it. Objects of Go types find their pod template at the
+// path without the last field, see changeTyped, and the others, generic workloads, their
+// containers at it; binding a new kind only takes an entry here.
+var podSpecPaths = map[schema.GroupKind]string{
+	{Group: "apps", Kind: "Deployment"}:                    "spec.template.spec",
+	{Group: "apps", Kind: "StatefulSet"}:                   "spec.template.spec",
+	{Group: "apps", Kind: "DaemonSet"}:                     "spec.template.spec",
+	{Group: "apps", Kind: "ReplicaSet"}:                    "spec.template.spec",
+	{Group: "apps.openshift.io", Kind: "DeploymentConfig"}: "spec.template.spec",
+	{Group: "batch", Kind: "Job"}:                          "spec.template.spec",
+	{Group: "batch", Kind: "CronJob"}:                      "spec.jobTemplate.spec.template.spec",
+	{Group: "", Kind: "ReplicationController"}:             "spec.template.spec",
+	{Group: "serving.knative.dev", Kind: "Service"}:        "spec.template.spec",
+}
+
+// knativeServingGroupVersion is the group of Knative Services, bound as generic workloads since
+// their types are not vendored.
+var knativeServingGroupVersion = schema.GroupVersion{Group: "serving.knative.dev", Version: "v1"}
+
+// mirroredFromLabel is set on the secrets and config maps mirrored into application namespaces,
+// or copied from the namespace of a backing service, with the namespace they were copied from.
+const mirroredFromLabel = "servicebinding.dev/mirrored-from"
+
+// mirroredForAnnotation is set on the copies mirrored into application namespaces with the sorted,
+// comma separated names of the ServiceBindingRequests they're kept for, e.g. "orders,payments",
+// from the namespace of their mirroredFromLabel. Owner references don't cross namespaces, the
+// copies are deleted by releaseMirrored instead.
+const mirroredForAnnotation = "servicebinding.dev/mirrored-for"
+
+// ErrEmptyApplicationSelector is returned when the ApplicationSelector sets neither labels nor
+// references, which would select every application of the kind.
+var ErrEmptyApplicationSelector = errs.New("application selector has neither matchLabels, matchExpressions nor a resourceRef")
+
+// InvalidApplicationSelectorError is returned when the label selector of the ApplicationSelector
+// is not valid, e.g. an expression with an unknown operator.
+type InvalidApplicationSelectorError struct {
+	Err error
+}
+
+func (e *InvalidApplicationSelectorError) Error() string {
+	return fmt.Sprintf("invalid application selector: %v", e.Err)
+}
+
+// KindNotAllowedError is returned when the application kind is not allowed by the operator.
+type KindNotAllowedError struct {
+	Kind string
+}
+
+func (e *KindNotAllowedError) Error() string {
+	return fmt.Sprintf("binding into kind '%s' is not allowed by the operator", e.Kind)
+}
+
+// ApplicationOwnerNotFoundError is returned when following the controller ownerReferences from the
+// object named by OwnedRef leads to no owner of the application kind.
+type ApplicationOwnerNotFoundError struct {
+	Kind         string
+	Name         string
+	ResourceKind string
+}
+
+func (e *ApplicationOwnerNotFoundError) Error() string {
+	return fmt.Sprintf("no %s found owning %s '%s'", e.ResourceKind, e.Kind, e.Name)
+}
+
+// SecretConflictError is returned when the secret the ServiceBindingRequest writes exists and is
+// not controlled by it, e.g. a secret of the application named by SecretName.
+type SecretConflictError struct {
+	Name string
+}
+
+func (e *SecretConflictError) Error() string {
+	return fmt.Sprintf("secret '%s' exists and is not owned by the ServiceBindingRequest", e.Name)
+}
+
+// SecretTooLargeError is returned when the data of the secret the ServiceBindingRequest writes
+// exceeds the size of secrets the API server accepts, corev1.MaxSecretSize.
+type SecretTooLargeError struct {
+	Name string
+	Size int
+}
+
+func (e *SecretTooLargeError) Error() string {
+	return fmt.Sprintf("secret '%s' would hold %d bytes, over the limit of %d bytes; bind fewer keys, e.g. listing them in include or exclude",
+		e.Name, e.Size, corev1.MaxSecretSize)
+}
+
+// ApplicationError is the error binding an application object, see BindError.
+type ApplicationError struct {
+	Ref corev1.ObjectReference
+	Err error
+}
+
+// BindError is returned by Bind when application objects failed to be bound, the others being
+// bound still.
+type BindError struct {
+	Failures []ApplicationError
+}
+
+func (e *BindError) Error() string {
+	msgs := []string{}
+	for _, f := range e.Failures {
+		msgs = append(msgs, fmt.Sprintf("%s '%s/%s': %v", f.Ref.Kind, f.Ref.Namespace, f.Ref.Name, f.Err))
+	}
+	return fmt.Sprintf("failed binding %d application(s): %s", len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// Binder searches the applications selected by a ServiceBindingRequest and injects the binding
+// environment variables in their containers.
+type Binder struct {
+	client client.Client
+	scheme *runtime.Scheme
+	sbr    *v1alpha1.ServiceBindingRequest
+	// allowedKinds are the lower case application kinds that can be bound, all when empty.
+	allowedKinds []string
+	// ctx is the context of the API calls, bounding them when it has a deadline.
+	ctx context.Context
+}
+
+// NewBinder returns a Binder for the ServiceBindingRequest.
+func NewBinder(
+	client client.Client,
+	scheme *runtime.Scheme,
+	sbr *v1alpha1.ServiceBindingRequest,
+	allowedKinds []string,
+) *Binder {
+	return &Binder{client: client, scheme: scheme, sbr: sbr, allowedKinds: allowedKinds, ctx: context.Background()}
+}
+
+// WithContext makes the API calls with the context, e.g. the one bounding a reconcile; the calls,
+// and their retries, stop once it's done.
+func (b *Binder) WithContext(ctx context.Context) *Binder {
+	b.ctx = ctx
+	return b
+}
+
+// applicationKinds are the supported application kinds along with their list types, Deployment,
+// the default kind, first.
+var applicationKinds = []struct {
+	kind string
+	list schema.GroupVersionKind
+}{
+	{"Deployment", appsv1.SchemeGroupVersion.WithKind("DeploymentList")},
+	{"DeploymentConfig", osappsv1.SchemeGroupVersion.WithKind("DeploymentConfigList")},
+	{"StatefulSet", appsv1.SchemeGroupVersion.WithKind("StatefulSetList")},
+	{"DaemonSet", appsv1.SchemeGroupVersion.WithKind("DaemonSetList")},
+	{"KnativeService", knativeServingGroupVersion.WithKind("ServiceList")},
+	{"Job", batchv1.SchemeGroupVersion.WithKind("JobList")},
+	{"CronJob", batchv1beta1.SchemeGroupVersion.WithKind("CronJobList")},
+	{"ReplicaSet", appsv1.SchemeGroupVersion.WithKind("ReplicaSetList")},
+	{"ReplicationController", corev1.SchemeGroupVersion.WithKind("ReplicationControllerList")},
+}
+
+// SupportedKinds returns the supported application resource kinds.
+func SupportedKinds() []string {
+	kinds := []string{}
+	for _, k := range applicationKinds {
+		kinds = append(kinds, k.kind)
+	}
+	return kinds
+}
+
+// IsSupportedKind returns whether the application resource kind is one of the supported kinds,
+// ignoring case. An empty kind is, as Deployment.
+func IsSupportedKind(kind string) bool {
+	for _, k := range applicationKinds {
+		if strings.EqualFold(kind, k.kind) {
+			return true
+		}
+	}
+	return kind == ""
+}
+
+// ValidateApplicationSelector returns the errors of the ApplicationSelector fields, at the path,
+// the controller can't bind with: no labels nor references, invalid match expressions, kinds not
+// supported, and the containers selected both by index and name. Both the validating webhook and
+// the controller check them, the webhook being optional.
+func ValidateApplicationSelector(sbr *v1alpha1.ServiceBindingRequest, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	selector := sbr.Spec.ApplicationSelector
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 && selector.ResourceRef == "" && len(selector.Refs) == 0 && selector.OwnedRef == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("matchLabels"), "either matchLabels, matchExpressions, resourceRef, refs or ownedRef is required"))
+	}
+	if ref := selector.OwnedRef; ref != nil {
+		if selector.ResourceRef != "" || len(selector.Refs) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("ownedRef"), "may not be set along with resourceRef or refs"))
+		}
+		if ref.APIVersion == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ownedRef", "apiVersion"), ""))
+		}
+		if ref.Kind == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ownedRef", "kind"), ""))
+		}
+		if ref.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ownedRef", "name"), ""))
+		}
+	}
+	for i, expr := range selector.MatchExpressions {
+		ls := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{expr}}
+		if _, err := metav1.LabelSelectorAsSelector(ls); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("matchExpressions").Index(i), expr, err.Error()))
+		}
+	}
+	if len(selector.Containers) > 0 && sbr.Spec.ContainerIndex != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("containers"), "may not be set along with containerIndex"))
+	}
+	// the group alone would be ignored, binding one of the supported kinds instead
+	if selector.Group != "" && selector.Version == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("version"), "the version of the group is required"))
+	}
+	// the containers of running pods can't be changed, the API server rejects the update; their
+	// workload is bound instead
+	if selector.Version != "" && selector.Group == "" {
+		if isPod(selector.ResourceKind) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("resourceKind"), "pods can't be bound, the workload creating them can"))
+		}
+		for i, ref := range selector.Refs {
+			if isPod(ref.Kind) {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("refs").Index(i).Child("kind"), "pods can't be bound, the workload creating them can"))
+			}
+		}
+	}
+	// generic workloads, of a group and version, are of any kind
+	if selector.Version == "" {
+		if !IsSupportedKind(selector.ResourceKind) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("resourceKind"), selector.ResourceKind, SupportedKinds()))
+		}
+		for i, ref := range selector.Refs {
+			if !IsSupportedKind(ref.Kind) {
+				allErrs = append(allErrs, field.NotSupported(fldPath.Child("refs").Index(i).Child("kind"), ref.Kind, SupportedKinds()))
+			}
+		}
+	}
+	return allErrs
+}
+
+// isPod returns whether the kind, of the core group, is Pod.
+func isPod(kind string) bool {
+	return strings.EqualFold(strings.TrimSuffix(kind, "List"), "Pod")
+}
+
+// getListGVK returns the list type of the application resource kind. Deployment is assumed
+// when the kind is not set or not known.
+func getListGVK(kind string) schema.GroupVersionKind {
+	for _, k := range applicationKinds {
+		if strings.EqualFold(kind, k.kind) {
+			return k.list
+		}
+	}
+	return applicationKinds[0].list
+}
+
+// searchNamespaces returns the namespaces where applications are searched: the ones matching the
+// NamespaceSelector when set, or else the request namespace.
+func (b *Binder) searchNamespaces() ([]string, error) {
+	selector := b.sbr.Spec.ApplicationSelector.NamespaceSelector
+	if len(selector) == 0 {
+		return []string{b.sbr.GetNamespace()}, nil
+	}
+
+	nsl := &corev1.NamespaceList{}
+	lo := &client.ListOptions{LabelSelector: labels.SelectorFromSet(selector)}
+	if err := b.client.List(b.ctx, lo, nsl); err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{}
+	for _, ns := range nsl.Items {
+		namespaces = append(namespaces, ns.GetName())
+	}
+	return namespaces, nil
+}
+
+// getGVK returns the type of the application resource kind, see getListGVK.
+func getGVK(kind string) schema.GroupVersionKind {
+	gvk := getListGVK(kind)
+	return gvk.GroupVersion().WithKind(strings.TrimSuffix(gvk.Kind, "List"))
+}
+
+// applicationGVK returns the type of the application kind. When the ApplicationSelector sets the
+// Group and Version, the kind is taken as is, as a generic workload; otherwise it's one of the
+// supported kinds, see getGVK.
+func applicationGVK(sbr *v1alpha1.ServiceBindingRequest, kind string) schema.GroupVersionKind {
+	selector := sbr.Spec.ApplicationSelector
+	if selector.Version == "" {
+		return getGVK(kind)
+	}
+	return schema.GroupVersionKind{Group: selector.Group, Version: selector.Version, Kind: kind}
+}
+
+// newObject returns a new object of the type, unstructured when there's no Go type registered in
+// the scheme or it's not one of the application kinds, as for generic workloads.
+func (b *Binder) newObject(gvk schema.GroupVersionKind) (runtime.Object, error) {
+	if obj, err := b.scheme.New(gvk); err == nil && hasPodTemplate(gvk) {
+		if _, ok := obj.(runtime.Unstructured); !ok {
+			return obj, nil
+		}
+	}
+	if strings.HasSuffix(gvk.Kind, "List") {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		return list, nil
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj, nil
+}
+
+// kindAllowed returns a KindNotAllowedError when the application type is not one of the allowed
+// kinds.
+func (b *Binder) kindAllowed(gvk schema.GroupVersionKind) error {
+	if len(b.allowedKinds) == 0 {
+		return nil
+	}
+	kind := strings.TrimSuffix(gvk.Kind, "List")
+	for _, allowed := range b.allowedKinds {
+		if allowed == strings.ToLower(kind) {
+			return nil
+		}
+	}
+	return &KindNotAllowedError{Kind: kind}
+}
+
+// applicationRefs returns the applications referenced by the ApplicationSelector, the one named by
+// ResourceRef in the ServiceBindingRequest namespace first.
+func applicationRefs(sbr *v1alpha1.ServiceBindingRequest) []v1alpha1.NamespacedRef {
+	refs := []v1alpha1.NamespacedRef{}
+	if name := sbr.Spec.ApplicationSelector.ResourceRef; name != "" {
+		refs = append(refs, v1alpha1.NamespacedRef{Namespace: sbr.GetNamespace(), Name: name})
+	}
+	return append(refs, sbr.Spec.ApplicationSelector.Refs...)
+}
+
+// searchRefs fetches the application objects referenced by the ApplicationSelector.
+func (b *Binder) searchRefs() ([]runtime.Object, error) {
+	objs := []runtime.Object{}
+	for _, ref := range applicationRefs(b.sbr) {
+		kind := ref.Kind
+		if kind == "" {
+			kind = b.sbr.Spec.ApplicationSelector.ResourceKind
+		}
+		gvk := applicationGVK(b.sbr, kind)
+		if err := b.kindAllowed(gvk); err != nil {
+			return nil, err
+		}
+		obj, err := b.newObject(gvk)
+		if err != nil {
+			return nil, err
+		}
+		nn := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		if err = b.client.Get(b.ctx, nn, obj); err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// maxOwnerDepth bounds the ownerReferences followed from the object named by OwnedRef, guarding
+// against cycles.
+const maxOwnerDepth = 10
+
+// searchOwner fetches the application owning the object named by OwnedRef, following the
+// controller ownerReferences from the object up to an owner of the ResourceKind, e.g. from a pod to
+// its ReplicaSet and then to its Deployment. The object is the application when of the ResourceKind
+// itself.
+func (b *Binder) searchOwner() ([]runtime.Object, error) {
+	selector := b.sbr.Spec.ApplicationSelector
+	gvk := applicationGVK(b.sbr, selector.ResourceKind)
+	if err := b.kindAllowed(gvk); err != nil {
+		return nil, err
+	}
+
+	owned := schema.FromAPIVersionAndKind(selector.OwnedRef.APIVersion, selector.OwnedRef.Kind)
+	name := selector.OwnedRef.Name
+	for depth := 0; depth <= maxOwnerDepth; depth++ {
+		nn := types.NamespacedName{Namespace: b.sbr.GetNamespace(), Name: name}
+		if owned == gvk {
+			obj, err := b.newObject(gvk)
+			if err != nil {
+				return nil, err
+			}
+			if err = b.client.Get(b.ctx, nn, obj); err != nil {
+				return nil, err
+			}
+			return []runtime.Object{obj}, nil
+		}
+
+		// the objects in between, of any kind, are only read for their ownerReferences
+		obj, err := b.scheme.New(owned)
+		if err != nil {
+			obj = &unstructured.Unstructured{}
+		}
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			u.SetGroupVersionKind(owned)
+		}
+		if err = b.client.Get(b.ctx, nn, obj); err != nil {
+			return nil, err
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		owner := metav1.GetControllerOf(accessor)
+		if owner == nil {
+			break
+		}
+		owned = schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind)
+		name = owner.Name
+	}
+	return nil, &ApplicationOwnerNotFoundError{
+		Kind:         selector.OwnedRef.Kind,
+		Name:         selector.OwnedRef.Name,
+		ResourceKind: gvk.Kind,
+	}
+}
+
+// search lists the application objects matching the ApplicationSelector, or fetches the ones it
+// references, retrying on transient errors.
+func (b *Binder) search() ([]runtime.Object, error) {
+	var objs []runtime.Object
+	err := retryTransient(b.ctx, retry.DefaultBackoff, func() (err error) {
+		objs, err = b.searchOnce()
+		return err
+	})
+	return objs, err
+}
+
+// searchOnce is a single attempt of search.
+func (b *Binder) searchOnce() ([]runtime.Object, error) {
+	if len(applicationRefs(b.sbr)) > 0 {
+		return b.searchRefs()
+	}
+	if b.sbr.Spec.ApplicationSelector.OwnedRef != nil {
+		return b.searchOwner()
+	}
+	if !hasLabelSelector(b.sbr.Spec.ApplicationSelector) {
+		return nil, ErrEmptyApplicationSelector
+	}
+	selector, err := applicationLabelSelector(b.sbr.Spec.ApplicationSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := b.searchNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	gvk := applicationGVK(b.sbr, b.sbr.Spec.ApplicationSelector.ResourceKind)
+	if err = b.kindAllowed(gvk); err != nil {
+		return nil, err
+	}
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	objs := []runtime.Object{}
+	for _, ns := range namespaces {
+		list, err := b.newObject(listGVK)
+		if err != nil {
+			return nil, err
+		}
+
+		lo := &client.ListOptions{
+			Namespace:     ns,
+			LabelSelector: selector,
+		}
+		if err = b.client.List(b.ctx, lo, list); err != nil {
+			return nil, err
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, items...)
+	}
+	return objs, nil
+}
+
+// selects returns whether the application object of the type is one of the objects search finds,
+// by reference or by labels, e.g. a Deployment recreated with the labels of a bound one.
+func (b *Binder) selects(gvk schema.GroupVersionKind, obj metav1.Object) (bool, error) {
+	if b.kindAllowed(gvk) != nil {
+		return false, nil
+	}
+	selector := b.sbr.Spec.ApplicationSelector
+	if refs := applicationRefs(b.sbr); len(refs) > 0 {
+		for _, ref := range refs {
+			kind := ref.Kind
+			if kind == "" {
+				kind = selector.ResourceKind
+			}
+			if applicationGVK(b.sbr, kind) == gvk && ref.Namespace == obj.GetNamespace() && ref.Name == obj.GetName() {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if selector.OwnedRef != nil {
+		objs, err := b.searchOwner()
+		if _, ok := err.(*ApplicationOwnerNotFoundError); ok || errors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		owner, err := meta.Accessor(objs[0])
+		if err != nil {
+			return false, err
+		}
+		return applicationGVK(b.sbr, selector.ResourceKind) == gvk &&
+			owner.GetNamespace() == obj.GetNamespace() && owner.GetName() == obj.GetName(), nil
+	}
+
+	if !hasLabelSelector(selector) || applicationGVK(b.sbr, selector.ResourceKind) != gvk {
+		return false, nil
+	}
+	// an invalid selector selects nothing, its reconcile reports it
+	ls, err := applicationLabelSelector(selector)
+	if err != nil {
+		return false, nil
+	}
+	if !ls.Matches(labels.Set(obj.GetLabels())) {
+		return false, nil
+	}
+	namespaces, err := b.searchNamespaces()
+	if err != nil {
+		return false, err
+	}
+	for _, ns := range namespaces {
+		if ns == obj.GetNamespace() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasLabelSelector returns whether the ApplicationSelector selects applications by labels.
+func hasLabelSelector(selector v1alpha1.ApplicationSelector) bool {
+	return len(selector.MatchLabels) > 0 || len(selector.MatchExpressions) > 0
+}
+
+// applicationLabelSelector returns the label selector of the ApplicationSelector, its labels and
+// expressions combined.
+func applicationLabelSelector(selector v1alpha1.ApplicationSelector) (labels.Selector, error) {
+	ls, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels:      selector.MatchLabels,
+		MatchExpressions: selector.MatchExpressions,
+	})
+	if err != nil {
+		return nil, &InvalidApplicationSelectorError{Err: err}
+	}
+	return ls, nil
+}
+
+// hasPodTemplate returns whether the objects of the type, or of its items for a list type, have a
+// typed pod template, the supported kinds but Knative Services, see changeTyped.
+func hasPodTemplate(gvk schema.GroupVersionKind) bool {
+	for _, k := range applicationKinds {
+		if k.list.GroupVersion() == gvk.GroupVersion() &&
+			(gvk.Kind == k.list.Kind || gvk.Kind+"List" == k.list.Kind) {
+			return k.list.GroupVersion() != knativeServingGroupVersion
+		}
+	}
+	return false
+}
+
+// rollsOut returns whether a change of the pod template of the application object rolls it out.
+// DeploymentConfigs hold their pod template at the same path as Deployments, but only roll out on
+// a template change with a ConfigChange trigger, otherwise waiting for "oc rollout latest".
+// ReplicaSets and ReplicationControllers never do, the template is only used for the pods created
+// from then on.
+func rollsOut(obj runtime.Object) bool {
+	switch o := obj.(type) {
+	case *appsv1.ReplicaSet, *corev1.ReplicationController:
+		return false
+	case *osappsv1.DeploymentConfig:
+		for _, trigger := range o.Spec.Triggers {
+			if trigger.Type == osappsv1.DeploymentTriggerOnConfigChange {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// parseEnvMap parses the value of the env map annotation, comma separated "key=NAME" pairs.
+func parseEnvMap(value string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid '%s' entry '%s', expected key=NAME", envMapAnnotation, pair)
+		}
+		mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return mapping, nil
+}
+
+// mapEnvs renames the environment variables of the binding keys found in the env map annotation,
+// read from the pod template annotations. The others keep their name.
+func mapEnvs(
+	annotations map[string]string,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+) ([]corev1.EnvVar, error) {
+	value, ok := annotations[envMapAnnotation]
+	if !ok {
+		return envs, nil
+	}
+	mapping, err := parseEnvMap(value)
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := []corev1.EnvVar{}
+	for _, env := range envs {
+		if name, ok := mapping[bindingKeys[env.Name]]; ok {
+			env.Name = name
+		}
+		mapped = append(mapped, env)
+	}
+	return mapped, nil
+}
+
+// podSpecChange changes the containers and volumes of an application pod spec. The annotations
+// are the ones of the object holding the pod spec, never nil, and the changes to them are written
+// back along with the pod spec.
+type podSpecChange func(annotations map[string]string, spec *corev1.PodSpec) error
+
+// BindOne binds a single application object, typed or unstructured: the secrets and config maps
+// the environment variables reference are mirrored into its namespace, see mirror, and the
+// variables injected, see bind. A single application, e.g. a recreated one, can be bound again
+// without searching all the applications of the ServiceBindingRequest.
+func (b *Binder) BindOne(
+	obj runtime.Object,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+) (bool, error) {
+	secretNames, configMapNames := referencedSources(envs)
+	if err := b.mirror(obj, secretNames, configMapNames); err != nil {
+		return false, err
+	}
+	return b.bind(obj, envs, bindingKeys)
+}
+
+// Bind binds the application objects one at a time, see BindOne, and returns the references of
+// the ones it modified, those already bound being left alone. An object failing to be bound
+// doesn't keep the others from being bound: the failures are returned together as a BindError,
+// along with the references of the objects modified.
+func (b *Binder) Bind(
+	objs []runtime.Object,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+) ([]corev1.ObjectReference, error) {
+	modified := []corev1.ObjectReference{}
+	failures := []ApplicationError{}
+	for _, obj := range objs {
+		ref, err := b.objectReference(obj)
+		if err != nil {
+			return modified, err
+		}
+		updated, err := b.BindOne(obj, envs, bindingKeys)
+		if err != nil {
+			failures = append(failures, ApplicationError{Ref: ref, Err: err})
+			continue
+		}
+		if updated {
+			modified = append(modified, ref)
+		}
+	}
+	if len(failures) > 0 {
+		return modified, &BindError{Failures: failures}
+	}
+	return modified, nil
+}
+
+// objectReference returns the reference of the application object, without its resourceVersion,
+// which changes as it's bound.
+func (b *Binder) objectReference(obj runtime.Object) (corev1.ObjectReference, error) {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return corev1.ObjectReference{}, err
+	}
+	gvk, err := apiutil.GVKForObject(obj, b.scheme)
+	if err != nil {
+		return corev1.ObjectReference{}, err
+	}
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return corev1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  objMeta.GetNamespace(),
+		Name:       objMeta.GetName(),
+		UID:        objMeta.GetUID(),
+	}, nil
+}
+
+// bind injects the environment variables in the application object and writes it back, returning
+// whether the object had to be updated, see apply. The bindingKeys, binding keys by environment
+// variable name, are used to rename the variables as the application asks, see mapEnvs.
+func (b *Binder) bind(
+	obj runtime.Object,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+) (bool, error) {
+	updated, err := b.apply(obj, func(annotations map[string]string, spec *corev1.PodSpec) error {
+		return injectPodSpec(b.sbr, annotations, spec, envs, bindingKeys)
+	})
+	if updated {
+		applicationsBoundTotal.Inc()
+	}
+	return updated, err
+}
+
+// unbind removes the binding from the application object and writes it back, returning whether
+// the object had to be updated, see eject.
+func (b *Binder) unbind(obj runtime.Object) (bool, error) {
+	return b.apply(obj, func(annotations map[string]string, spec *corev1.PodSpec) error {
+		return eject(b.sbr, annotations, spec)
+	})
+}
+
+// apply changes the pod spec of the application object and writes it back, returning whether the
+// object had to be updated. The update carries the resourceVersion read from the cluster, acting
+// as an optimistic lock: when the object was changed meanwhile the update is rejected with a
+// conflict, and the object is fetched again to re-apply the change on its latest version. The
+// change is re-applied as well after other transient errors, see isTransient.
+func (b *Binder) apply(obj runtime.Object, change podSpecChange) (bool, error) {
+	key, err := client.ObjectKeyFromObject(obj)
+	if err != nil {
+		return false, err
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, b.scheme)
+	if err != nil {
+		return false, err
+	}
+
+	attempt := 0
+	updated := false
+	err = retryTransient(b.ctx, retry.DefaultRetry, func() error {
+		if attempt > 0 {
+			// reading into a new object, the one of the failed attempt still carries its changes
+			latest, err := b.newObject(gvk)
+			if err != nil {
+				return err
+			}
+			if err = b.client.Get(b.ctx, key, latest); err != nil {
+				return err
+			}
+			obj = latest
+		}
+		attempt++
+
+		changed, err := changePodSpec(b.sbr, b.scheme, obj, change)
+		// skipping unchanged objects keeps repeated reconciles from causing rollouts
+		if err != nil || !changed {
+			return err
+		}
+		if err = b.client.Update(b.ctx, obj); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
+// inject injects the environment variables in the containers of the application object, or mounts
+// the data they reference as files when BindAsFiles is set, and returns whether the object changed.
+func inject(
+	sbr *v1alpha1.ServiceBindingRequest,
+	s *runtime.Scheme,
+	obj runtime.Object,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+) (bool, error) {
+	return changePodSpec(sbr, s, obj, func(annotations map[string]string, spec *corev1.PodSpec) error {
+		return injectPodSpec(sbr, annotations, spec, envs, bindingKeys)
+	})
+}
+
+// injectPodSpec injects the environment variables in the containers of the pod spec, and in its
+// init containers when BindInitContainers is set, or mounts the binding volume when BindAsFiles is
+// set. Variables with a value instead of a reference, the attributes read from the backing
+// resource, can't be projected and are always injected. The env map annotation is read from the
+// annotations, and the names of the variables injected are recorded in them, see
+// boundEnvsAnnotation.
+func injectPodSpec(
+	sbr *v1alpha1.ServiceBindingRequest,
+	annotations map[string]string,
+	spec *corev1.PodSpec,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+) error {
+	injected := envs
+	names := []string{}
+	if sbr.Spec.BindAsFiles {
+		rootAdded, err := mountVolume(sbr, spec, bindingVolume(sbr, envs, bindingKeys))
+		if err != nil {
+			return err
+		}
+		if rootAdded {
+			names = append(names, serviceBindingRootEnv)
+		}
+
+		injected = []corev1.EnvVar{}
+		for _, env := range envs {
+			if env.ValueFrom == nil {
+				injected = append(injected, env)
+			}
+		}
+	}
+
+	mapped, err := mapEnvs(annotations, injected, bindingKeys)
+	if err != nil {
+		return err
+	}
+	for _, env := range mapped {
+		names = append(names, env.Name)
+	}
+	if names, err = removeStaleEnvs(sbr, annotations, spec, names); err != nil {
+		return err
+	}
+	if len(mapped) > 0 {
+		if err = update(sbr, spec.Containers, mapped); err != nil {
+			return err
+		}
+		for _, c := range boundInitContainers(sbr, spec) {
+			c.Env = mergeEnvs(c.Env, mapped)
+		}
+	}
+	return recordBoundEnvs(sbr, annotations, names)
+}
+
+// removeStaleEnvs removes the environment variables recorded for the ServiceBindingRequest but not
+// among the names injected now, e.g. of a descriptor removed from the backing service, from the
+// containers and the init containers, as eject does. SERVICE_BINDING_ROOT, only added when not set,
+// stays injected while the bindings are mounted as files, and is otherwise only removed from the
+// containers left without any mount under it. It returns the names with the ones kept added.
+func removeStaleEnvs(sbr *v1alpha1.ServiceBindingRequest, annotations map[string]string, spec *corev1.PodSpec, names []string) ([]string, error) {
+	bound, err := boundEnvs(annotations)
+	if err != nil {
+		return nil, err
+	}
+	injected := map[string]bool{}
+	for _, name := range names {
+		injected[name] = true
+	}
+	stale := map[string]bool{}
+	for _, name := range bound[bindingRef(sbr)] {
+		if injected[name] {
+			continue
+		}
+		if name == serviceBindingRootEnv && sbr.Spec.BindAsFiles {
+			names = append(names, name)
+			continue
+		}
+		stale[name] = true
+	}
+	if len(stale) == 0 {
+		return names, nil
+	}
+
+	containers := []*corev1.Container{}
+	for i := range spec.Containers {
+		containers = append(containers, &spec.Containers[i])
+	}
+	for i := range spec.InitContainers {
+		containers = append(containers, &spec.InitContainers[i])
+	}
+	for _, c := range containers {
+		envs := c.Env[:0]
+		for _, env := range c.Env {
+			if !stale[env.Name] || (env.Name == serviceBindingRootEnv && mountedUnder(c.VolumeMounts, env.Value)) {
+				envs = append(envs, env)
+			}
+		}
+		c.Env = envs
+	}
+	return names, nil
+}
+
+// bindingRef returns the key of the ServiceBindingRequest in the bound envs annotation.
+func bindingRef(sbr *v1alpha1.ServiceBindingRequest) string {
+	return types.NamespacedName{Namespace: sbr.GetNamespace(), Name: sbr.GetName()}.String()
+}
+
+// boundEnvs returns the names of the environment variables injected by each ServiceBindingRequest,
+// read from the bound envs annotation.
+func boundEnvs(annotations map[string]string) (map[string][]string, error) {
+	bound := map[string][]string{}
+	value, ok := annotations[boundEnvsAnnotation]
+	if !ok {
+		return bound, nil
+	}
+	if err := json.Unmarshal([]byte(value), &bound); err != nil {
+		return nil, fmt.Errorf("invalid '%s' annotation: %v", boundEnvsAnnotation, err)
+	}
+	return bound, nil
+}
+
+// setBoundEnvs writes the names of the environment variables injected by each
+// ServiceBindingRequest in the bound envs annotation, removed when none is left.
+func setBoundEnvs(annotations map[string]string, bound map[string][]string) error {
+	if len(bound) == 0 {
+		delete(annotations, boundEnvsAnnotation)
+		return nil
+	}
+	value, err := json.Marshal(bound)
+	if err != nil {
+		return err
+	}
+	annotations[boundEnvsAnnotation] = string(value)
+	return nil
+}
+
+// recordBoundEnvs replaces the names recorded for the ServiceBindingRequest, the variables no
+// longer injected being removed from the containers, see removeStaleEnvs.
+func recordBoundEnvs(sbr *v1alpha1.ServiceBindingRequest, annotations map[string]string, names []string) error {
+	bound, err := boundEnvs(annotations)
+	if err != nil {
+		return err
+	}
+	ref := bindingRef(sbr)
+	bound[ref] = sortedNames(names)
+	return setBoundEnvs(annotations, bound)
+}
+
+// changePodSpec applies the change to the pod spec of the application object, and returns whether
+// the object changed. The scheme gives the kind of the objects of Go types.
+func changePodSpec(sbr *v1alpha1.ServiceBindingRequest, s *runtime.Scheme, obj runtime.Object, change podSpecChange) (bool, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return changeUnstructured(sbr, u, change)
+	}
+	gvk, err := apiutil.GVKForObject(obj, s)
+	if err != nil {
+		return false, err
+	}
+	return changeTyped(gvk.GroupKind(), obj, change)
+}
+
+// changeTyped applies the change to the pod template of an application object of a Go type, found
+// at the path of its pod spec without the last field, see podSpecPaths. The annotations are read
+// from the pod template.
+func changeTyped(gk schema.GroupKind, obj runtime.Object, change podSpecChange) (bool, error) {
+	path, ok := podSpecPaths[gk]
+	if !ok || !strings.HasSuffix(path, ".spec") {
+		return false, fmt.Errorf("unsupported application type %T", obj)
+	}
+	fields := strings.Split(strings.TrimSuffix(path, ".spec"), ".")
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, err
+	}
+	template, found, err := unstructured.NestedMap(content, fields...)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("%s '%s' has no pod template", strings.ToLower(gk.Kind), objMeta.GetName())
+	}
+	tmpl := &corev1.PodTemplateSpec{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(template, tmpl); err != nil {
+		return false, err
+	}
+
+	before := tmpl.Spec.DeepCopy()
+	annotations := copyAnnotations(tmpl.GetAnnotations())
+	if err = change(annotations, &tmpl.Spec); err != nil {
+		return false, err
+	}
+	annotationsChanged := annotationsChanged(tmpl.GetAnnotations(), annotations)
+	if reflect.DeepEqual(before, &tmpl.Spec) && !annotationsChanged {
+		return false, nil
+	}
+	if annotationsChanged {
+		tmpl.SetAnnotations(nilIfEmpty(annotations))
+	}
+	if template, err = runtime.DefaultUnstructuredConverter.ToUnstructured(tmpl); err != nil {
+		return false, err
+	}
+	if err = unstructured.SetNestedMap(content, template, fields...); err != nil {
+		return false, err
+	}
+	return true, runtime.DefaultUnstructuredConverter.FromUnstructured(content, obj)
+}
+
+// copyAnnotations returns a copy of the annotations, never nil, for a change to modify.
+func copyAnnotations(annotations map[string]string) map[string]string {
+	copied := map[string]string{}
+	for k, v := range annotations {
+		copied[k] = v
+	}
+	return copied
+}
+
+// nilIfEmpty returns the annotations, or nil when there are none, so setting them removes the
+// field.
+func nilIfEmpty(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
+
+// annotationsChanged returns whether the annotations changed, nil and empty annotations being the
+// same.
+func annotationsChanged(before, after map[string]string) bool {
+	if len(before) == 0 && len(after) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(before, after)
+}
+
+// containersPath returns the ContainersPath of the ApplicationSelector, or else the containers of
+// the pod spec of the kind, see podSpecPaths, or else the first of the default paths found in the
+// object.
+func containersPath(sbr *v1alpha1.ServiceBindingRequest, u *unstructured.Unstructured) string {
+	if path := sbr.Spec.ApplicationSelector.ContainersPath; path != "" {
+		return path
+	}
+	if path, ok := podSpecPaths[u.GroupVersionKind().GroupKind()]; ok {
+		return path + ".containers"
+	}
+	for _, path := range defaultContainersPaths {
+		if _, found, _ := unstructured.NestedSlice(u.Object, strings.Split(path, ".")...); found {
+			return path
+		}
+	}
+	return defaultContainersPaths[0]
+}
+
+// changeUnstructured applies the change to the containers found at ContainersPath of a generic
+// application object, and to the init containers and volumes kept next to them, as in a pod spec.
+// The annotations are read from the object itself.
+func changeUnstructured(
+	sbr *v1alpha1.ServiceBindingRequest,
+	u *unstructured.Unstructured,
+	change podSpecChange,
+) (bool, error) {
+	path := containersPath(sbr, u)
+	fields := strings.Split(path, ".")
+
+	containers, found, err := unstructuredContainers(u, fields)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, fmt.Errorf("%s '%s' has no containers at '%s'", u.GetKind(), u.GetName(), path)
+	}
+
+	initContainersFields := append(append([]string{}, fields[:len(fields)-1]...), "initContainers")
+	initContainers, _, err := unstructuredContainers(u, initContainersFields)
+	if err != nil {
+		return false, err
+	}
+
+	volumesFields := append(append([]string{}, fields[:len(fields)-1]...), "volumes")
+	volumes, err := unstructuredVolumes(u, volumesFields)
+	if err != nil {
+		return false, err
+	}
+
+	spec := &corev1.PodSpec{Containers: containers, InitContainers: initContainers, Volumes: volumes}
+	before := spec.DeepCopy()
+	annotations := copyAnnotations(u.GetAnnotations())
+	if err = change(annotations, spec); err != nil {
+		return false, err
+	}
+
+	changed := false
+	if annotationsChanged(u.GetAnnotations(), annotations) {
+		u.SetAnnotations(nilIfEmpty(annotations))
+		changed = true
+	}
+	if !reflect.DeepEqual(before.Volumes, spec.Volumes) {
+		volumeItems := make([]interface{}, len(spec.Volumes))
+		for i := range spec.Volumes {
+			if volumeItems[i], err = runtime.DefaultUnstructuredConverter.ToUnstructured(&spec.Volumes[i]); err != nil {
+				return false, err
+			}
+		}
+		if err = unstructured.SetNestedSlice(u.Object, volumeItems, volumesFields...); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	if !reflect.DeepEqual(before.Containers, spec.Containers) {
+		if err = setUnstructuredContainers(u, fields, spec.Containers); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	if !reflect.DeepEqual(before.InitContainers, spec.InitContainers) {
+		if err = setUnstructuredContainers(u, initContainersFields, spec.InitContainers); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	if changed && u.GroupVersionKind().GroupVersion() == knativeServingGroupVersion {
+		// the template change creates a new revision, which can't reuse the name of the current one
+		unstructured.RemoveNestedField(u.Object, "spec", "template", "metadata", "name")
+	}
+	return changed, nil
+}
+
+// unstructuredContainers returns the containers found at the given fields of a generic application
+// object, and whether they were found.
+func unstructuredContainers(u *unstructured.Unstructured, fields []string) ([]corev1.Container, bool, error) {
+	items, found, err := unstructured.NestedSlice(u.Object, fields...)
+	if err != nil {
+		return nil, false, err
+	}
+	containers := make([]corev1.Container, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("%s '%s' has an invalid container at '%s'", u.GetKind(), u.GetName(), strings.Join(fields, "."))
+		}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(m, &containers[i]); err != nil {
+			return nil, false, err
+		}
+	}
+	return containers, found, nil
+}
+
+// setUnstructuredContainers sets the containers at the given fields of a generic application
+// object.
+func setUnstructuredContainers(u *unstructured.Unstructured, fields []string, containers []corev1.Container) error {
+	items := make([]interface{}, len(containers))
+	for i := range containers {
+		item, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&containers[i])
+		if err != nil {
+			return err
+		}
+		items[i] = item
+	}
+	return unstructured.SetNestedSlice(u.Object, items, fields...)
+}
+
+// unstructuredVolumes returns the volumes found at the given fields of a generic application
+// object, none when absent.
+func unstructuredVolumes(u *unstructured.Unstructured, fields []string) ([]corev1.Volume, error) {
+	items, _, err := unstructured.NestedSlice(u.Object, fields...)
+	if err != nil {
+		return nil, err
+	}
+	volumes := make([]corev1.Volume, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s '%s' has an invalid volume at '%s'", u.GetKind(), u.GetName(), strings.Join(fields, "."))
+		}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(m, &volumes[i]); err != nil {
+			return nil, err
+		}
+	}
+	return volumes, nil
+}
+
+// boundContainers returns the containers selected by the ServiceBindingRequest. When
+// ContainerIndex is set, only the container at that position is bound, when Containers is set,
+// only the containers named, otherwise all containers are.
+func boundContainers(sbr *v1alpha1.ServiceBindingRequest, containers []corev1.Container) ([]*corev1.Container, error) {
+	if sbr.Spec.ContainerIndex != nil {
+		idx := *sbr.Spec.ContainerIndex
+		if idx < 0 || idx >= len(containers) {
+			return nil, fmt.Errorf("container index %d is out of range, found %d container(s)", idx, len(containers))
+		}
+		return []*corev1.Container{&containers[idx]}, nil
+	}
+
+	bound := []*corev1.Container{}
+	if names := sbr.Spec.ApplicationSelector.Containers; len(names) > 0 {
+		for _, name := range names {
+			found := false
+			for i := range containers {
+				if containers[i].Name == name {
+					bound = append(bound, &containers[i])
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("container '%s' not found", name)
+			}
+		}
+		return bound, nil
+	}
+
+	for i := range containers {
+		bound = append(bound, &containers[i])
+	}
+	return bound, nil
+}
+
+// boundInitContainers returns the init containers of the pod spec when BindInitContainers is set,
+// all of them as ContainerIndex and Containers select among the containers only, or else none.
+func boundInitContainers(sbr *v1alpha1.ServiceBindingRequest, spec *corev1.PodSpec) []*corev1.Container {
+	bound := []*corev1.Container{}
+	if !sbr.Spec.BindInitContainers {
+		return bound
+	}
+	for i := range spec.InitContainers {
+		bound = append(bound, &spec.InitContainers[i])
+	}
+	return bound
+}
+
+// update injects the environment variables into the containers selected by the
+// ServiceBindingRequest, see boundContainers. Environment variables already present in the
+// containers are kept, see mergeEnvs.
+func update(sbr *v1alpha1.ServiceBindingRequest, containers []corev1.Container, envs []corev1.EnvVar) error {
+	bound, err := boundContainers(sbr, containers)
+	if err != nil {
+		return err
+	}
+	for _, c := range bound {
+		c.Env = mergeEnvs(c.Env, envs)
+	}
+	return nil
+}
+
+// bindingVolume returns the volume projecting the keys referenced by the environment variables
+// as files named after the binding keys, found in bindingKeys by environment variable name, or
+// else after the referenced keys. As when setting the variables, the last one of a name wins. The
+// volume is named after the ServiceBindingRequest.
+func bindingVolume(
+	sbr *v1alpha1.ServiceBindingRequest,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+) corev1.Volume {
+	sources := []corev1.VolumeProjection{}
+	// position of the projection of each secret and config map in sources
+	secrets := map[string]int{}
+	configMaps := map[string]int{}
+	// position of the last variable of each name in envs
+	last := map[string]int{}
+	for i, env := range envs {
+		last[env.Name] = i
+	}
+
+	for i, env := range envs {
+		if env.ValueFrom == nil || last[env.Name] != i {
+			continue
+		}
+		if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+			j, ok := secrets[ref.Name]
+			if !ok {
+				j = len(sources)
+				secrets[ref.Name] = j
+				sources = append(sources, corev1.VolumeProjection{
+					Secret: &corev1.SecretProjection{LocalObjectReference: ref.LocalObjectReference},
+				})
+			}
+			item := corev1.KeyToPath{Key: ref.Key, Path: filePath(env.Name, ref.Key, bindingKeys)}
+			sources[j].Secret.Items = append(sources[j].Secret.Items, item)
+		}
+		if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+			j, ok := configMaps[ref.Name]
+			if !ok {
+				j = len(sources)
+				configMaps[ref.Name] = j
+				sources = append(sources, corev1.VolumeProjection{
+					ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: ref.LocalObjectReference},
+				})
+			}
+			item := corev1.KeyToPath{Key: ref.Key, Path: filePath(env.Name, ref.Key, bindingKeys)}
+			sources[j].ConfigMap.Items = append(sources[j].ConfigMap.Items, item)
+		}
+	}
+
+	return corev1.Volume{
+		Name: sbr.GetName(),
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{Sources: sources},
+		},
+	}
+}
+
+// filePath returns the path of the file of a binding key, the binding key of the environment
+// variable when known, or else the referenced key.
+func filePath(name string, key string, bindingKeys map[string]string) string {
+	if bindingKey, ok := bindingKeys[name]; ok {
+		return bindingKey
+	}
+	return key
+}
+
+// MountPath returns the directory the binding data of the ServiceBindingRequest is mounted at
+// when bound as files, MountPath or else "/bindings/<name>".
+func MountPath(sbr *v1alpha1.ServiceBindingRequest) string {
+	if sbr.Spec.MountPath != "" {
+		return sbr.Spec.MountPath
+	}
+	return "/bindings/" + sbr.GetName()
+}
+
+// mountVolume adds the volume to the pod spec, replacing the one of the same name, and mounts it
+// in the containers selected by the ServiceBindingRequest, and the init containers when
+// BindInitContainers is set, at MountPath, "/bindings/<name>" by default. Mounts of the same name
+// are replaced as well, so binding again doesn't add duplicates. The containers get
+// SERVICE_BINDING_ROOT, the parent directory of the mount, unless already set, as the root of a
+// binding done before is kept. It returns whether SERVICE_BINDING_ROOT was added.
+func mountVolume(sbr *v1alpha1.ServiceBindingRequest, spec *corev1.PodSpec, volume corev1.Volume) (bool, error) {
+	bound, err := boundContainers(sbr, spec.Containers)
+	if err != nil {
+		return false, err
+	}
+	bound = append(bound, boundInitContainers(sbr, spec)...)
+
+	mountPath := MountPath(sbr)
+	mount := corev1.VolumeMount{Name: volume.Name, MountPath: mountPath, ReadOnly: true}
+	root := corev1.EnvVar{Name: serviceBindingRootEnv, Value: path.Dir(mountPath)}
+	rootAdded := false
+	for _, c := range bound {
+		c.VolumeMounts = replaceVolumeMount(c.VolumeMounts, mount)
+		if !hasEnv(c.Env, serviceBindingRootEnv) {
+			c.Env = append(c.Env, root)
+			rootAdded = true
+		}
+	}
+
+	mounted := []corev1.Volume{}
+	replaced := false
+	for _, v := range spec.Volumes {
+		if v.Name == volume.Name {
+			v, replaced = volume, true
+		}
+		mounted = append(mounted, v)
+	}
+	if !replaced {
+		mounted = append(mounted, volume)
+	}
+	spec.Volumes = mounted
+	return rootAdded, nil
+}
+
+// hasEnv returns whether the environment variable is set.
+func hasEnv(envs []corev1.EnvVar, name string) bool {
+	for _, env := range envs {
+		if env.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceVolumeMount returns the mounts with the one of the same name replaced, or appended when
+// not present.
+func replaceVolumeMount(mounts []corev1.VolumeMount, mount corev1.VolumeMount) []corev1.VolumeMount {
+	for i := range mounts {
+		if mounts[i].Name == mount.Name {
+			mounts[i] = mount
+			return mounts
+		}
+	}
+	return append(mounts, mount)
+}
+
+// mergeEnvs returns the existing environment variables, in their order, with the ones also found
+// in envs replaced in place. The remaining envs are appended sorted by name, so merging the same
+// variables again gives the same result.
+func mergeEnvs(existing []corev1.EnvVar, envs []corev1.EnvVar) []corev1.EnvVar {
+	byName := map[string]corev1.EnvVar{}
+	for _, env := range envs {
+		byName[env.Name] = env
+	}
+
+	merged := []corev1.EnvVar{}
+	for _, env := range existing {
+		if replacement, ok := byName[env.Name]; ok {
+			env = replacement
+			delete(byName, env.Name)
+		}
+		merged = append(merged, env)
+	}
+
+	added := []corev1.EnvVar{}
+	for _, env := range byName {
+		added = append(added, env)
+	}
+	sort.Slice(added, func(i, j int) bool {
+		return added[i].Name < added[j].Name
+	})
+	return append(merged, added...)
+}
+
+// eject removes the binding from the pod spec, the inverse of injectPodSpec: the environment
+// variables recorded in the bound envs annotation for the ServiceBindingRequest, and the binding
+// volume with its mounts, from the containers and the init containers. SERVICE_BINDING_ROOT is only
+// removed when recorded, as added by the binding, and from the containers left without any mount
+// under it. The record of the ServiceBindingRequest is removed from the annotations.
+func eject(sbr *v1alpha1.ServiceBindingRequest, annotations map[string]string, spec *corev1.PodSpec) error {
+	bound, err := boundEnvs(annotations)
+	if err != nil {
+		return err
+	}
+	ref := bindingRef(sbr)
+	injected := map[string]bool{}
+	for _, name := range bound[ref] {
+		injected[name] = true
+	}
+
+	// init containers are ejected even when BindInitContainers is not set, as it may have been
+	// unset after binding
+	containers := []*corev1.Container{}
+	for i := range spec.Containers {
+		containers = append(containers, &spec.Containers[i])
+	}
+	for i := range spec.InitContainers {
+		containers = append(containers, &spec.InitContainers[i])
+	}
+
+	// filtering in place keeps nil slices nil, so an unbound pod spec compares as unchanged
+	for _, c := range containers {
+		mounts := c.VolumeMounts[:0]
+		for _, mount := range c.VolumeMounts {
+			if mount.Name != sbr.GetName() {
+				mounts = append(mounts, mount)
+			}
+		}
+		c.VolumeMounts = mounts
+
+		envs := c.Env[:0]
+		for _, env := range c.Env {
+			if env.Name == serviceBindingRootEnv {
+				if injected[env.Name] && !mountedUnder(c.VolumeMounts, env.Value) {
+					continue
+				}
+			} else if injected[env.Name] {
+				continue
+			}
+			envs = append(envs, env)
+		}
+		c.Env = envs
+	}
+
+	volumes := spec.Volumes[:0]
+	for _, volume := range spec.Volumes {
+		if volume.Name != sbr.GetName() {
+			volumes = append(volumes, volume)
+		}
+	}
+	spec.Volumes = volumes
+
+	if _, ok := bound[ref]; !ok {
+		return nil
+	}
+	delete(bound, ref)
+	return setBoundEnvs(annotations, bound)
+}
+
+// mountedUnder returns whether any of the mounts is in the directory.
+func mountedUnder(mounts []corev1.VolumeMount, dir string) bool {
+	for _, mount := range mounts {
+		if strings.HasPrefix(mount.MountPath, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mirror copies the secrets and config maps to the namespace of the application object, when it's
+// not the ServiceBindingRequest namespace, since environment variables can only reference objects
+// in the pod namespace. Existing copies are updated, when their content differs.
+func (b *Binder) mirror(
+	obj runtime.Object,
+	secretNames []string,
+	configMapNames []string,
+) error {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	ns := objMeta.GetNamespace()
+	if ns == b.sbr.GetNamespace() {
+		return nil
+	}
+	return b.copySources(b.sbr.GetNamespace(), ns, secretNames, configMapNames)
+}
+
+// referencedSources returns the names of the secrets and config maps the environment variables
+// reference, sorted.
+func referencedSources(envs []corev1.EnvVar) ([]string, []string) {
+	secretNames, configMapNames := []string{}, []string{}
+	for _, env := range envs {
+		switch {
+		case env.ValueFrom == nil:
+		case env.ValueFrom.SecretKeyRef != nil:
+			secretNames = append(secretNames, env.ValueFrom.SecretKeyRef.Name)
+		case env.ValueFrom.ConfigMapKeyRef != nil:
+			configMapNames = append(configMapNames, env.ValueFrom.ConfigMapKeyRef.Name)
+		}
+	}
+	return sortedNames(secretNames), sortedNames(configMapNames)
+}
+
+// copySources copies the secrets and config maps from a namespace to another, labeled with the
+// namespace they were copied from. The copies of secrets keep their type, e.g.
+// kubernetes.io/basic-auth, for consumers relying on typed secrets. Existing copies are updated,
+// when their content differs, or recreated when the type of the secret changed.
+func (b *Binder) copySources(from, to string, secretNames, configMapNames []string) error {
+	for _, name := range secretNames {
+		secret := &corev1.Secret{}
+		err := b.client.Get(b.ctx, types.NamespacedName{Namespace: from, Name: name}, secret)
+		if err != nil {
+			return err
+		}
+		mirrored := &corev1.Secret{
+			ObjectMeta: b.mirroredObjectMeta(from, name, to),
+			Type:       secret.Type,
+			Data:       secret.Data,
+		}
+		current := &corev1.Secret{}
+		retyped := false
+		err = b.createOrUpdate(mirrored, current, func() bool {
+			metaUnchanged := mergeMirrored(current, mirrored)
+			// the type of a secret can't be updated, the copy is recreated instead
+			retyped = current.Type != mirrored.Type
+			if retyped {
+				return true
+			}
+			return metaUnchanged && reflect.DeepEqual(current.Data, mirrored.Data) &&
+				reflect.DeepEqual(current.GetLabels(), mirrored.GetLabels())
+		})
+		if err == nil && retyped {
+			err = retryTransient(b.ctx, retry.DefaultBackoff, func() error {
+				if err := b.client.Delete(b.ctx, current); err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+				return b.client.Create(b.ctx, mirrored)
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range configMapNames {
+		cm := &corev1.ConfigMap{}
+		err := b.client.Get(b.ctx, types.NamespacedName{Namespace: from, Name: name}, cm)
+		if err != nil {
+			return err
+		}
+		mirrored := &corev1.ConfigMap{
+			ObjectMeta: b.mirroredObjectMeta(from, name, to),
+			Data:       cm.Data,
+		}
+		current := &corev1.ConfigMap{}
+		err = b.createOrUpdate(mirrored, current, func() bool {
+			return mergeMirrored(current, mirrored) && reflect.DeepEqual(current.Data, mirrored.Data) &&
+				reflect.DeepEqual(current.GetLabels(), mirrored.GetLabels())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFlattened writes the secret of the keys flattened from the JSON values of secret keys, see
+// v1alpha1.MappingTypeJSON. It's controlled by the ServiceBindingRequest, in its namespace, and
+// garbage collected along with it. It carries the SecretAnnotations, the ones removed from the
+// spec being dropped, see secretAnnotationsAnnotation, and the annotations and labels set on it by
+// others being kept. A SecretConflictError is returned when a secret of the name exists that it
+// doesn't control, the secret being left alone, and a SecretTooLargeError when the data is over
+// the size the API server accepts, nothing being written.
+func (b *Binder) writeFlattened(name string, data map[string][]byte) error {
+	if size := secretSize(data); size > corev1.MaxSecretSize {
+		return &SecretTooLargeError{Name: name, Size: size}
+	}
+	annotations := map[string]string{}
+	keys := []string{}
+	for k, v := range b.sbr.Spec.SecretAnnotations {
+		annotations[k] = v
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		annotations[secretAnnotationsAnnotation] = strings.Join(sortedNames(keys), ",")
+	}
+	flattened := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   b.sbr.GetNamespace(),
+			Annotations: nilIfEmpty(annotations),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(b.sbr, v1alpha1.SchemeGroupVersion.WithKind("ServiceBindingRequest")),
+			},
+		},
+		Data: data,
+	}
+	current := &corev1.Secret{}
+	conflict := false
+	err := b.createOrUpdate(flattened, current, func() bool {
+		// taken as unchanged, not to be updated
+		if !b.controls(current) {
+			conflict = true
+			return true
+		}
+		conflict = false
+		// the annotations set by others, e.g. the encryption controller, are kept, the ones written
+		// before replaced
+		merged := copyAnnotations(current.GetAnnotations())
+		for _, k := range parseList(merged[secretAnnotationsAnnotation]) {
+			delete(merged, k)
+		}
+		delete(merged, secretAnnotationsAnnotation)
+		for k, v := range annotations {
+			merged[k] = v
+		}
+		unchanged := !annotationsChanged(current.GetAnnotations(), merged)
+		flattened.SetAnnotations(nilIfEmpty(merged))
+		// the operator sets no labels, the ones of others are kept
+		flattened.SetLabels(current.GetLabels())
+		return unchanged && reflect.DeepEqual(current.Data, flattened.Data)
+	})
+	if err == nil && conflict {
+		return &SecretConflictError{Name: name}
+	}
+	return err
+}
+
+// deleteFlattened deletes the secret of the flattened keys once the ServiceBindingRequest has none,
+// the secret being left alone unless it controls it, see writeFlattened.
+func (b *Binder) deleteFlattened(name string) error {
+	return retryTransient(b.ctx, retry.DefaultBackoff, func() error {
+		current := &corev1.Secret{}
+		err := b.client.Get(b.ctx, types.NamespacedName{Namespace: b.sbr.GetNamespace(), Name: name}, current)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !b.controls(current) {
+			return nil
+		}
+		if err = b.client.Delete(b.ctx, current); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// controls returns whether the ServiceBindingRequest is the controller of the object.
+func (b *Binder) controls(obj metav1.Object) bool {
+	owner := metav1.GetControllerOf(obj)
+	return owner != nil && owner.UID == b.sbr.GetUID() && owner.Kind == "ServiceBindingRequest" &&
+		owner.Name == b.sbr.GetName()
+}
+
+// secretSize returns the size of the secret data as the API server limits it, the bytes of its
+// values.
+func secretSize(data map[string][]byte) int {
+	size := 0
+	for _, value := range data {
+		size += len(value)
+	}
+	return size
+}
+
+// mirroredObjectMeta returns the metadata of an object copied from a namespace for the
+// ServiceBindingRequest. A copy in the namespace of the ServiceBindingRequest is owned by it, and
+// garbage collected along with the last of the ServiceBindingRequests sharing it, see
+// mergeMirrored. Owners must be in the namespace of their dependents though, the garbage collector
+// deleting a copy owned from another namespace: a copy mirrored into an application namespace
+// names the ServiceBindingRequest in its mirroredForAnnotation instead.
+func (b *Binder) mirroredObjectMeta(from, name, ns string) metav1.ObjectMeta {
+	objMeta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: ns,
+		Labels: map[string]string{
+			mirroredFromLabel: from,
+		},
+	}
+	if ns == b.sbr.GetNamespace() {
+		objMeta.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(b.sbr, v1alpha1.SchemeGroupVersion.WithKind("ServiceBindingRequest")),
+		}
+	} else {
+		objMeta.Annotations = map[string]string{mirroredForAnnotation: b.sbr.GetName()}
+	}
+	return objMeta
+}
+
+// mergeMirrored merges the owners and the ServiceBindingRequests of the current copy into the
+// metadata of the mirrored one, so a copy shared by several ServiceBindingRequests is kept for
+// each of them, and returns whether the metadata is unchanged. The first owner stays the
+// controller, an object having at most one. The annotations set by others are kept.
+func mergeMirrored(current, mirrored metav1.Object) bool {
+	owners := current.GetOwnerReferences()
+	for _, ref := range mirrored.GetOwnerReferences() {
+		if hasOwner(owners, ref) {
+			continue
+		}
+		if metav1.GetControllerOf(current) != nil {
+			ref.Controller = nil
+		}
+		owners = append(owners, ref)
+	}
+	ownersUnchanged := len(owners) == len(current.GetOwnerReferences())
+	mirrored.SetOwnerReferences(owners)
+
+	annotations := copyAnnotations(current.GetAnnotations())
+	if value, ok := mirrored.GetAnnotations()[mirroredForAnnotation]; ok {
+		names := append(parseList(annotations[mirroredForAnnotation]), parseList(value)...)
+		annotations[mirroredForAnnotation] = strings.Join(sortedNames(names), ",")
+	}
+	annotationsUnchanged := !annotationsChanged(current.GetAnnotations(), annotations)
+	mirrored.SetAnnotations(nilIfEmpty(annotations))
+	return ownersUnchanged && annotationsUnchanged
+}
+
+// hasOwner returns whether the owner is one of the owner references.
+func hasOwner(owners []metav1.OwnerReference, owner metav1.OwnerReference) bool {
+	for _, ref := range owners {
+		if ref.UID == owner.UID && ref.Kind == owner.Kind && ref.Name == owner.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseMirrored releases the copies mirrored into application namespaces for the
+// ServiceBindingRequest, when it's deleted: it's dropped from their mirroredForAnnotation, and the
+// copies kept for no other ServiceBindingRequest are deleted. The copies in its own namespace are
+// garbage collected.
+func (b *Binder) releaseMirrored() error {
+	lo := &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{mirroredFromLabel: b.sbr.GetNamespace()}),
+	}
+	copies := []runtime.Object{}
+	sl := &corev1.SecretList{}
+	if err := b.client.List(b.ctx, lo, sl); err != nil {
+		return err
+	}
+	for i := range sl.Items {
+		copies = append(copies, &sl.Items[i])
+	}
+	cml := &corev1.ConfigMapList{}
+	if err := b.client.List(b.ctx, lo, cml); err != nil {
+		return err
+	}
+	for i := range cml.Items {
+		copies = append(copies, &cml.Items[i])
+	}
+
+	for _, obj := range copies {
+		key, err := client.ObjectKeyFromObject(obj)
+		if err != nil {
+			return err
+		}
+		if key.Namespace == b.sbr.GetNamespace() {
+			continue
+		}
+		err = retryTransient(b.ctx, retry.DefaultBackoff, func() error {
+			// read again on retries, a conflict telling the copy changed
+			if err := b.client.Get(b.ctx, key, obj); err != nil {
+				if errors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			objMeta, err := meta.Accessor(obj)
+			if err != nil {
+				return err
+			}
+			names := parseList(objMeta.GetAnnotations()[mirroredForAnnotation])
+			kept := []string{}
+			for _, name := range names {
+				if name != b.sbr.GetName() {
+					kept = append(kept, name)
+				}
+			}
+			if len(kept) == len(names) {
+				return nil
+			}
+			if len(kept) == 0 {
+				if err = b.client.Delete(b.ctx, obj); err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+				return nil
+			}
+			annotations := copyAnnotations(objMeta.GetAnnotations())
+			annotations[mirroredForAnnotation] = strings.Join(kept, ",")
+			objMeta.SetAnnotations(annotations)
+			return b.client.Update(b.ctx, obj)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createOrUpdate creates the object, or updates it when it already exists, retrying on transient
+// errors. The existing object is read into current, of the same type, to carry its
+// resourceVersion, and is left alone when unchanged tells it matches the object already, so
+// repeated reconciles don't write it again.
+func (b *Binder) createOrUpdate(obj runtime.Object, current runtime.Object, unchanged func() bool) error {
+	return retryTransient(b.ctx, retry.DefaultBackoff, func() error {
+		return b.createOrUpdateOnce(obj, current, unchanged)
+	})
+}
+
+// createOrUpdateOnce is a single attempt of createOrUpdate.
+func (b *Binder) createOrUpdateOnce(obj runtime.Object, current runtime.Object, unchanged func() bool) error {
+	err := b.client.Create(b.ctx, obj)
+	if err == nil || !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	key, err := client.ObjectKeyFromObject(obj)
+	if err != nil {
+		return err
+	}
+	if err = b.client.Get(b.ctx, key, current); err != nil {
+		return err
+	}
+	if unchanged() {
+		return nil
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	currentMeta, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+	objMeta.SetResourceVersion(currentMeta.GetResourceVersion())
+	return b.client.Update(b.ctx, obj)
+}
+
+// isTransient returns whether the API error is likely to go away when the call is retried: a
+// conflict with a concurrent change, a timeout, or an API server unavailable or throttling the
+// operator. Other errors, like a kind not allowed, fail the same way again.
+func isTransient(err error) bool {
+	return errors.IsConflict(err) ||
+		errors.IsServerTimeout(err) ||
+		errors.IsTimeout(err) ||
+		errors.IsServiceUnavailable(err) ||
+		errors.IsTooManyRequests(err) ||
+		errors.IsInternalError(err)
+}
+
+// retryTransient calls fn until it succeeds, fails with an error that isn't transient or the
+// backoff steps are exhausted, returning the last error, or the error of the context once done.
+// Like retry.RetryOnConflict, for all the transient errors.
+func retryTransient(ctx context.Context, backoff wait.Backoff, fn func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		lastErr = fn()
+		switch {
+		case lastErr == nil:
+			return true, nil
+		case isTransient(lastErr):
+			return false, nil
+		default:
+			return false, lastErr
+		}
+	})
+	if err == wait.ErrWaitTimeout {
+		err = lastErr
+	}
+	return err
+}
@@ -0,0 +1,1871 @@
+package servicebindingrequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	errs "errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	osappsv1 "github.com/openshift/api/apps/v1"
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetListGVK(t *testing.T) {
+	tests := []struct {
+		kind string
+		want schema.GroupVersionKind
+	}{
+		{kind: "", want: appsv1.SchemeGroupVersion.WithKind("DeploymentList")},
+		{kind: "Deployment", want: appsv1.SchemeGroupVersion.WithKind("DeploymentList")},
+		{kind: "deploymentconfig", want: osappsv1.SchemeGroupVersion.WithKind("DeploymentConfigList")},
+		{kind: "StatefulSet", want: appsv1.SchemeGroupVersion.WithKind("StatefulSetList")},
+		{kind: "statefulset", want: appsv1.SchemeGroupVersion.WithKind("StatefulSetList")},
+		{kind: "DaemonSet", want: appsv1.SchemeGroupVersion.WithKind("DaemonSetList")},
+		{kind: "KnativeService", want: knativeServingGroupVersion.WithKind("ServiceList")},
+		{kind: "Job", want: batchv1.SchemeGroupVersion.WithKind("JobList")},
+		{kind: "cronjob", want: batchv1beta1.SchemeGroupVersion.WithKind("CronJobList")},
+		{kind: "replicaset", want: appsv1.SchemeGroupVersion.WithKind("ReplicaSetList")},
+		{kind: "ReplicationController", want: corev1.SchemeGroupVersion.WithKind("ReplicationControllerList")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if got := getListGVK(tt.kind); got != tt.want {
+				t.Errorf("List GVK not matching: %s", got)
+			}
+			if got := getGVK(tt.kind).Kind + "List"; got != tt.want.Kind {
+				t.Errorf("GVK not matching the list kind: %s", got)
+			}
+		})
+	}
+}
+
+func TestRollsOut(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  runtime.Object
+		want bool
+	}{
+		{"deployment", &appsv1.Deployment{}, true},
+		{"replicaset", &appsv1.ReplicaSet{}, false},
+		{"replicationcontroller", &corev1.ReplicationController{}, false},
+		{"deploymentconfig without trigger", &osappsv1.DeploymentConfig{}, false},
+		{"deploymentconfig with config change trigger", &osappsv1.DeploymentConfig{
+			Spec: osappsv1.DeploymentConfigSpec{
+				Triggers: osappsv1.DeploymentTriggerPolicies{{Type: osappsv1.DeploymentTriggerOnConfigChange}},
+			},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollsOut(tt.obj); got != tt.want {
+				t.Errorf("rollsOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeEnvs(t *testing.T) {
+	existing := []corev1.EnvVar{
+		{Name: "Z_APP", Value: "z"},
+		{Name: "POSTGRES_PASSWORD", Value: "stale"},
+		{Name: "A_APP", Value: "a"},
+	}
+	envs := []corev1.EnvVar{
+		{Name: "POSTGRES_USERNAME", Value: "postgres"},
+		{Name: "POSTGRES_PASSWORD", Value: "secret"},
+		{Name: "POSTGRES_DATABASE", Value: "db"},
+	}
+
+	merged := mergeEnvs(existing, envs)
+	expected := []string{"Z_APP", "POSTGRES_PASSWORD", "A_APP", "POSTGRES_DATABASE", "POSTGRES_USERNAME"}
+	if len(merged) != len(expected) {
+		t.Fatalf("Environment not matching: %v", merged)
+	}
+	for i, name := range expected {
+		if merged[i].Name != name {
+			t.Errorf("Environment at %d not matching: %s", i, merged[i].Name)
+		}
+	}
+	if merged[1].Value != "secret" {
+		t.Errorf("Existing environment not replaced: %v", merged[1])
+	}
+
+	again := mergeEnvs(merged, envs)
+	first, _ := json.Marshal(merged)
+	second, _ := json.Marshal(again)
+	if !bytes.Equal(first, second) {
+		t.Errorf("Merging again is not stable:\n%s\n%s", first, second)
+	}
+}
+
+func TestParseEnvMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "pairs", value: "user=DB_USERNAME,password=DB_PASSWORD", want: map[string]string{"user": "DB_USERNAME", "password": "DB_PASSWORD"}},
+		{name: "spaces and empty entries", value: " user = DB_USERNAME, ,", want: map[string]string{"user": "DB_USERNAME"}},
+		{name: "empty", value: "", want: map[string]string{}},
+		{name: "missing name", value: "user=", wantErr: true},
+		{name: "missing separator", value: "user", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvMap(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected error parsing '%s', found '%v'", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: (%v)", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mapping not matching: %v", got)
+			}
+		})
+	}
+}
+
+func TestBindKnativeService(t *testing.T) {
+	namespace := "default"
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "postgres",
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+				ResourceKind: "KnativeService",
+			},
+		},
+	}
+
+	// the fake client needs the Knative types to be known
+	s := scheme.Scheme
+	s.AddKnownTypeWithName(knativeServingGroupVersion.WithKind("Service"), &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(knativeServingGroupVersion.WithKind("ServiceList"), &knativeServiceList{})
+
+	ksvc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "serving.knative.dev/v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      "my-app",
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					"connects-to": "postgres",
+				},
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "my-app-00001",
+					},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"image": "quay.io/example/app:latest",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cl := fake.NewFakeClient(ksvc)
+	binder := NewBinder(cl, s, sbr, nil)
+
+	objs, err := binder.search()
+	if err != nil {
+		t.Fatalf("search: (%v)", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("Expected a single Knative Service, found %d", len(objs))
+	}
+
+	envs := []corev1.EnvVar{{Name: "POSTGRES_PASSWORD", Value: "secret"}}
+	if _, err = binder.bind(objs[0], envs, map[string]string{}); err != nil {
+		t.Fatalf("bind: (%v)", err)
+	}
+
+	out := &unstructured.Unstructured{}
+	out.SetGroupVersionKind(knativeServingGroupVersion.WithKind("Service"))
+	if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "my-app"}, out); err != nil {
+		t.Fatalf("get knative service: (%v)", err)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(out.Object, "spec", "template", "spec", "containers")
+	if len(containers) != 1 {
+		t.Fatalf("Containers not matching: %v", containers)
+	}
+	container := &corev1.Container{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(containers[0].(map[string]interface{}), container)
+	if err != nil {
+		t.Fatalf("convert container: (%v)", err)
+	}
+	if len(container.Env) != 1 || container.Env[0].Name != "POSTGRES_PASSWORD" {
+		t.Errorf("Knative Service not bound: %v", container.Env)
+	}
+
+	// the revision name is cleared, so the change creates a new revision
+	if _, found, _ := unstructured.NestedString(out.Object, "spec", "template", "metadata", "name"); found {
+		t.Errorf("Revision name should be cleared: %v", out.Object["spec"])
+	}
+}
+
+func TestBindUnchanged(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}},
+				},
+			},
+		},
+	}
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+
+	cl := &conflictingClient{Client: fake.NewFakeClient(dp)}
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+	for i, expected := range []bool{true, false} {
+		obj := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-app"}, obj); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		updated, err := binder.bind(obj, envs, map[string]string{})
+		if err != nil {
+			t.Fatalf("bind: (%v)", err)
+		}
+		if updated != expected {
+			t.Errorf("Bind %d: expected updated to be %v", i+1, expected)
+		}
+	}
+	if cl.updates != 1 {
+		t.Errorf("Expected binding again to issue no update, found %d updates", cl.updates)
+	}
+}
+
+func TestBindOne(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	deployment := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": name, "namespace": "apps"},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": []interface{}{map[string]interface{}{"name": "app"}},
+						},
+					},
+				},
+			},
+		}
+	}
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+
+	cl := fake.NewFakeClient(secret, deployment("my-app"), deployment("other-app"))
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+	updated, err := binder.BindOne(deployment("my-app"), envs, map[string]string{})
+	if err != nil {
+		t.Fatalf("bind: (%v)", err)
+	}
+	if !updated {
+		t.Error("Expected the application to be updated")
+	}
+
+	for name, expected := range map[string]int{"my-app": 1, "other-app": 0} {
+		out := &appsv1.Deployment{}
+		if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "apps", Name: name}, out); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		if env := out.Spec.Template.Spec.Containers[0].Env; len(env) != expected {
+			t.Errorf("Expected %d variables bound in '%s', found %v", expected, name, env)
+		}
+	}
+
+	// the referenced secret is mirrored into the application namespace
+	mirrored := &corev1.Secret{}
+	if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "apps", Name: "db-credentials"}, mirrored); err != nil {
+		t.Fatalf("get mirrored secret: (%v)", err)
+	}
+	if from := mirrored.GetLabels()[mirroredFromLabel]; from != "default" {
+		t.Errorf("Expected the secret mirrored from 'default', found '%s'", from)
+	}
+}
+
+func TestCopySourcesType(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "default"},
+		Type:       corev1.SecretTypeBasicAuth,
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("secret")},
+	}
+	// copied before the secret was recreated with its type
+	stale := &corev1.Secret{
+		ObjectMeta: NewBinder(nil, scheme.Scheme, sbr, nil).mirroredObjectMeta("default", "db-credentials", "apps"),
+		Type:       corev1.SecretTypeOpaque,
+		Data:       secret.Data,
+	}
+	cl := &recordingClient{Client: fake.NewFakeClient(secret, stale)}
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+
+	for _, ns := range []string{"apps", "jobs"} {
+		if err := binder.copySources("default", ns, []string{"db-credentials"}, nil); err != nil {
+			t.Fatalf("copy sources: (%v)", err)
+		}
+		copied := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: "db-credentials"}, copied); err != nil {
+			t.Fatalf("get copied secret: (%v)", err)
+		}
+		if copied.Type != corev1.SecretTypeBasicAuth || !reflect.DeepEqual(copied.Data, secret.Data) {
+			t.Errorf("Expected a %s copy in '%s', found %s %v", corev1.SecretTypeBasicAuth, ns, copied.Type, copied.Data)
+		}
+	}
+	// the type can't be updated, the stale copy is recreated
+	deleted := "delete *v1.Secret apps/db-credentials"
+	if len(cl.mutations) < 2 || cl.mutations[1] != deleted {
+		t.Errorf("Expected '%s' after the first create, found %v", deleted, cl.mutations)
+	}
+
+	// copies of the type are left alone
+	cl.mutations = nil
+	if err := binder.copySources("default", "apps", []string{"db-credentials"}, nil); err != nil {
+		t.Fatalf("copy sources: (%v)", err)
+	}
+	if !reflect.DeepEqual(cl.mutations, []string{"create *v1.Secret apps/db-credentials"}) {
+		t.Errorf("Expected the copy to only be attempted, found %v", cl.mutations)
+	}
+}
+
+func TestCopySourcesShared(t *testing.T) {
+	sbr := func(name string) *v1alpha1.ServiceBindingRequest {
+		return &v1alpha1.ServiceBindingRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid")},
+		}
+	}
+	orders, payments := sbr("orders"), sbr("payments")
+	secret := func(ns string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: ns},
+			Data:       map[string][]byte{"password": []byte("secret")},
+		}
+	}
+	cl := &selectingClient{Client: fake.NewFakeClient(secret("data"))}
+	get := func(ns string) (*corev1.Secret, error) {
+		copied := &corev1.Secret{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: "db-credentials"}, copied)
+		return copied, err
+	}
+
+	// copied from the backing service namespace into the one of the ServiceBindingRequests, and
+	// mirrored from there into an application namespace, by both
+	for _, s := range []*v1alpha1.ServiceBindingRequest{orders, payments} {
+		binder := NewBinder(cl, scheme.Scheme, s, nil)
+		if err := binder.copySources("data", "default", []string{"db-credentials"}, nil); err != nil {
+			t.Fatalf("copy sources: (%v)", err)
+		}
+		if err := binder.copySources("default", "apps", []string{"db-credentials"}, nil); err != nil {
+			t.Fatalf("mirror sources: (%v)", err)
+		}
+	}
+
+	copied, err := get("default")
+	if err != nil {
+		t.Fatalf("get copied secret: (%v)", err)
+	}
+	owners := copied.GetOwnerReferences()
+	if len(owners) != 2 || owners[0].UID != orders.GetUID() || owners[1].UID != payments.GetUID() {
+		t.Fatalf("Expected the copy owned by both, found %+v", owners)
+	}
+	if controller := metav1.GetControllerOf(copied); controller == nil || controller.UID != orders.GetUID() {
+		t.Errorf("Expected the first owner to control the copy, found %+v", controller)
+	}
+	mirrored, err := get("apps")
+	if err != nil {
+		t.Fatalf("get mirrored secret: (%v)", err)
+	}
+	if len(mirrored.GetOwnerReferences()) != 0 {
+		t.Errorf("Expected no owner across namespaces, found %+v", mirrored.GetOwnerReferences())
+	}
+	if names := mirrored.GetAnnotations()[mirroredForAnnotation]; names != "orders,payments" {
+		t.Errorf("Expected the copy mirrored for both, found '%s'", names)
+	}
+
+	// the copy is kept for the remaining ServiceBindingRequest, then deleted
+	if err = NewBinder(cl, scheme.Scheme, orders, nil).releaseMirrored(); err != nil {
+		t.Fatalf("release: (%v)", err)
+	}
+	if mirrored, err = get("apps"); err != nil {
+		t.Fatalf("get mirrored secret: (%v)", err)
+	}
+	if names := mirrored.GetAnnotations()[mirroredForAnnotation]; names != "payments" {
+		t.Errorf("Expected the copy kept for 'payments', found '%s'", names)
+	}
+	if err = NewBinder(cl, scheme.Scheme, payments, nil).releaseMirrored(); err != nil {
+		t.Fatalf("release: (%v)", err)
+	}
+	if _, err = get("apps"); !errors.IsNotFound(err) {
+		t.Errorf("Expected the mirrored copy deleted, got (%v)", err)
+	}
+	// the copy in the namespace of the ServiceBindingRequests is left to the garbage collector
+	if _, err = get("default"); err != nil {
+		t.Errorf("Expected the owned copy kept, got (%v)", err)
+	}
+}
+
+func TestWriteFlattenedTooLarge(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default", UID: "sbr-uid"},
+	}
+	cl := &recordingClient{Client: fake.NewFakeClient()}
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+
+	data := map[string][]byte{
+		"db-ca":   bytes.Repeat([]byte("a"), corev1.MaxSecretSize/2),
+		"db-cert": bytes.Repeat([]byte("b"), corev1.MaxSecretSize/2+1),
+	}
+	err := binder.writeFlattened("postgres-flattened", data)
+	tooLarge, ok := err.(*SecretTooLargeError)
+	if !ok {
+		t.Fatalf("Expected a SecretTooLargeError, got (%v)", err)
+	}
+	if tooLarge.Size != corev1.MaxSecretSize+1 {
+		t.Errorf("Expected the size to be %d, found %d", corev1.MaxSecretSize+1, tooLarge.Size)
+	}
+	if len(cl.mutations) != 0 {
+		t.Errorf("Expected nothing written, found %v", cl.mutations)
+	}
+
+	// at the limit, the secret is written
+	data["db-cert"] = data["db-cert"][1:]
+	if err = binder.writeFlattened("postgres-flattened", data); err != nil {
+		t.Fatalf("write flattened: (%v)", err)
+	}
+	if !reflect.DeepEqual(cl.mutations, []string{"create *v1.Secret default/postgres-flattened"}) {
+		t.Errorf("Expected the secret to be created, found %v", cl.mutations)
+	}
+}
+
+func TestWriteFlattenedAnnotations(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default", UID: "sbr-uid"},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			SecretAnnotations: map[string]string{"kms.example.org/encrypt": "true"},
+		},
+	}
+	cl := fake.NewFakeClient()
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+	data := map[string][]byte{"db-host": []byte("orders-db")}
+	if err := binder.writeFlattened("postgres-flattened", data); err != nil {
+		t.Fatalf("write flattened: (%v)", err)
+	}
+	out := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: "default", Name: "postgres-flattened"}
+	if err := cl.Get(context.TODO(), key, out); err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	expected := map[string]string{"kms.example.org/encrypt": "true", secretAnnotationsAnnotation: "kms.example.org/encrypt"}
+	if !reflect.DeepEqual(out.GetAnnotations(), expected) {
+		t.Errorf("Expected the annotations %v, found %v", expected, out.GetAnnotations())
+	}
+
+	// the annotations and labels of the encryption controller are kept as the secret is written
+	// again, the annotation removed from the spec is removed
+	out.Annotations["kms.example.org/key-id"] = "k1"
+	out.SetLabels(map[string]string{"kms.example.org/encrypted": "true"})
+	if err := cl.Update(context.TODO(), out); err != nil {
+		t.Fatalf("update secret: (%v)", err)
+	}
+	sbr.Spec.SecretAnnotations = map[string]string{"kms.example.org/key-ring": "orders"}
+	if err := binder.writeFlattened("postgres-flattened", data); err != nil {
+		t.Fatalf("write flattened: (%v)", err)
+	}
+	out = &corev1.Secret{}
+	if err := cl.Get(context.TODO(), key, out); err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	expected = map[string]string{
+		"kms.example.org/key-ring":  "orders",
+		"kms.example.org/key-id":    "k1",
+		secretAnnotationsAnnotation: "kms.example.org/key-ring",
+	}
+	if !reflect.DeepEqual(out.GetAnnotations(), expected) {
+		t.Errorf("Expected the annotations %v, found %v", expected, out.GetAnnotations())
+	}
+	if labels := out.GetLabels(); !reflect.DeepEqual(labels, map[string]string{"kms.example.org/encrypted": "true"}) {
+		t.Errorf("Expected the labels of the encryption controller kept, found %v", labels)
+	}
+}
+
+func TestDeleteFlattened(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default", UID: "sbr-uid"},
+	}
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "default"}}
+	cl := fake.NewFakeClient(other)
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+	if err := binder.writeFlattened("postgres-flattened", map[string][]byte{"db-host": []byte("orders-db")}); err != nil {
+		t.Fatalf("write flattened: (%v)", err)
+	}
+
+	for _, name := range []string{"postgres-flattened", "app-config", "missing"} {
+		if err := binder.deleteFlattened(name); err != nil {
+			t.Fatalf("delete flattened: (%v)", err)
+		}
+	}
+	key := types.NamespacedName{Namespace: "default", Name: "postgres-flattened"}
+	if err := cl.Get(context.TODO(), key, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected the secret of the flattened keys deleted, got (%v)", err)
+	}
+	// a secret the ServiceBindingRequest doesn't control is left alone
+	key.Name = "app-config"
+	if err := cl.Get(context.TODO(), key, &corev1.Secret{}); err != nil {
+		t.Errorf("Expected 'app-config' kept, got (%v)", err)
+	}
+}
+
+func TestBind(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+	}
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+	deployment := func(name string, uid types.UID, env []corev1.EnvVar) *appsv1.Deployment {
+		dp := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "app", Env: env}},
+					},
+				},
+			},
+		}
+		if env != nil {
+			dp.Spec.Template.SetAnnotations(map[string]string{boundEnvsAnnotation: `{"default/postgres":["POSTGRES_PASSWORD"]}`})
+		}
+		return dp
+	}
+
+	cl := fake.NewFakeClient(deployment("my-app", "uid-1", nil), deployment("bound-app", "uid-2", envs))
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+	objs := []runtime.Object{}
+	for _, name := range []string{"my-app", "bound-app"} {
+		obj := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, obj); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		objs = append(objs, obj)
+	}
+
+	modified, err := binder.Bind(objs, envs, map[string]string{})
+	if err != nil {
+		t.Fatalf("bind: (%v)", err)
+	}
+	// the application bound already is left alone
+	expected := []corev1.ObjectReference{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Name:       "my-app",
+		UID:        "uid-1",
+	}}
+	if !reflect.DeepEqual(modified, expected) {
+		t.Errorf("References not matching: expected %+v, got %+v", expected, modified)
+	}
+
+	t.Run("one failing", func(t *testing.T) {
+		cl := &rejectingClient{
+			Client: fake.NewFakeClient(deployment("my-app", "uid-1", nil), deployment("other-app", "uid-3", nil)),
+			name:   "my-app",
+		}
+		binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+		objs := []runtime.Object{}
+		for _, name := range []string{"my-app", "other-app"} {
+			obj := &appsv1.Deployment{}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, obj); err != nil {
+				t.Fatalf("get deployment: (%v)", err)
+			}
+			objs = append(objs, obj)
+		}
+
+		// the failure doesn't keep the application after it from being bound
+		modified, err := binder.Bind(objs, envs, map[string]string{})
+		if len(modified) != 1 || modified[0].Name != "other-app" {
+			t.Errorf("Expected 'other-app' modified, found %+v", modified)
+		}
+		bindErr, ok := err.(*BindError)
+		if !ok || len(bindErr.Failures) != 1 {
+			t.Fatalf("Expected a BindError with one failure, found '%v'", err)
+		}
+		if f := bindErr.Failures[0]; f.Ref.Name != "my-app" || !errors.IsForbidden(f.Err) {
+			t.Errorf("Expected 'my-app' to fail as forbidden, found %+v", f)
+		}
+	})
+}
+
+func TestBindWorkloads(t *testing.T) {
+	labels := map[string]string{"connects-to": "postgres"}
+	podTemplate := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers:    []corev1.Container{{Name: "migrate"}},
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+
+	tests := []struct {
+		kind string
+		obj  runtime.Object
+		// path is where the containers are found in the object
+		path []string
+	}{
+		{
+			kind: "Job",
+			obj: &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-migration", Namespace: "default", Labels: labels},
+				Spec:       batchv1.JobSpec{Template: podTemplate},
+			},
+			path: []string{"spec", "template", "spec", "containers"},
+		},
+		{
+			kind: "CronJob",
+			obj: &batchv1beta1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-backup", Namespace: "default", Labels: labels},
+				Spec: batchv1beta1.CronJobSpec{
+					Schedule: "0 2 * * *",
+					JobTemplate: batchv1beta1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{Template: podTemplate},
+					},
+				},
+			},
+			path: []string{"spec", "jobTemplate", "spec", "template", "spec", "containers"},
+		},
+		{
+			kind: "ReplicaSet",
+			obj: &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy-app", Namespace: "default", Labels: labels},
+				Spec:       appsv1.ReplicaSetSpec{Template: podTemplate},
+			},
+			path: []string{"spec", "template", "spec", "containers"},
+		},
+		{
+			kind: "ReplicationController",
+			obj: &corev1.ReplicationController{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy-app", Namespace: "default", Labels: labels},
+				Spec:       corev1.ReplicationControllerSpec{Template: &podTemplate},
+			},
+			path: []string{"spec", "template", "spec", "containers"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+				Spec: v1alpha1.ServiceBindingRequestSpec{
+					ApplicationSelector: v1alpha1.ApplicationSelector{ResourceKind: tt.kind, MatchLabels: labels},
+				},
+			}
+			// an object of the kind not labeled is left out by the label search
+			unlabeled := tt.obj.DeepCopyObject()
+			unlabeled.(metav1.Object).SetName("unlabeled")
+			unlabeled.(metav1.Object).SetLabels(nil)
+			cl := &selectingClient{Client: fake.NewFakeClient(tt.obj, unlabeled)}
+			binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+
+			objs, err := binder.search()
+			if err != nil {
+				t.Fatalf("search: (%v)", err)
+			}
+			if len(objs) != 1 {
+				t.Fatalf("Expected the %s to be found, found %d objects", tt.kind, len(objs))
+			}
+			if _, err = binder.bind(objs[0], envs, map[string]string{}); err != nil {
+				t.Fatalf("bind: (%v)", err)
+			}
+
+			out := tt.obj.DeepCopyObject()
+			key := types.NamespacedName{Namespace: "default", Name: tt.obj.(metav1.Object).GetName()}
+			if err = cl.Get(context.TODO(), key, out); err != nil {
+				t.Fatalf("get %s: (%v)", tt.kind, err)
+			}
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(out)
+			if err != nil {
+				t.Fatalf("convert: (%v)", err)
+			}
+			containers, found, err := unstructured.NestedSlice(u, tt.path...)
+			if err != nil || !found || len(containers) != 1 {
+				t.Fatalf("Containers not found at %v: %v (%v)", tt.path, containers, err)
+			}
+			env, _, _ := unstructured.NestedSlice(containers[0].(map[string]interface{}), "env")
+			if len(env) != 1 || env[0].(map[string]interface{})["name"] != "POSTGRES_PASSWORD" {
+				t.Errorf("Environment not bound: %v", env)
+			}
+		})
+	}
+}
+
+func TestBindConcurrentChange(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}},
+				},
+			},
+		},
+	}
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+
+	// another writer sets an environment variable meanwhile, the binding is re-applied on top
+	cl := &racingClient{Client: fake.NewFakeClient(dp), change: func(dp *appsv1.Deployment) {
+		container := &dp.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env, corev1.EnvVar{Name: "LOG_LEVEL", Value: "debug"})
+	}}
+	obj := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-app"}, obj); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if _, err := NewBinder(cl, scheme.Scheme, sbr, nil).bind(obj, envs, map[string]string{}); err != nil {
+		t.Fatalf("bind: (%v)", err)
+	}
+	if cl.updates != 2 {
+		t.Errorf("Expected 2 update attempts, found %d", cl.updates)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-app"}, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	names := []string{}
+	for _, ev := range dpOut.Spec.Template.Spec.Containers[0].Env {
+		names = append(names, ev.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"LOG_LEVEL", "POSTGRES_PASSWORD"}) {
+		t.Errorf("Expected the concurrent change kept and the binding applied once, found %v", names)
+	}
+}
+
+func TestContainersPath(t *testing.T) {
+	containers := []interface{}{
+		map[string]interface{}{
+			"image": "quay.io/example/app:latest",
+		},
+	}
+	templated := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": containers,
+				},
+			},
+		},
+	}
+	podLike := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": containers,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		gvk      schema.GroupVersionKind
+		object   map[string]interface{}
+		expected string
+	}{
+		{"pod template", "", schema.GroupVersionKind{}, templated, "spec.template.spec.containers"},
+		{"pod-like spec", "", schema.GroupVersionKind{}, podLike, "spec.containers"},
+		{"no containers", "", schema.GroupVersionKind{}, map[string]interface{}{}, "spec.template.spec.containers"},
+		{"explicit path", "spec.workers", schema.GroupVersionKind{}, podLike, "spec.workers"},
+		{"deployment", "", appsv1.SchemeGroupVersion.WithKind("Deployment"), map[string]interface{}{}, "spec.template.spec.containers"},
+		{"cronjob", "", batchv1beta1.SchemeGroupVersion.WithKind("CronJob"), templated, "spec.jobTemplate.spec.template.spec.containers"},
+		{"replication controller", "", corev1.SchemeGroupVersion.WithKind("ReplicationController"), podLike, "spec.template.spec.containers"},
+		{"explicit path of a known kind", "spec.workers", corev1.SchemeGroupVersion.WithKind("ReplicationController"), podLike, "spec.workers"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				Spec: v1alpha1.ServiceBindingRequestSpec{
+					ApplicationSelector: v1alpha1.ApplicationSelector{ContainersPath: test.path},
+				},
+			}
+			u := &unstructured.Unstructured{Object: test.object}
+			if !test.gvk.Empty() {
+				u.SetGroupVersionKind(test.gvk)
+			}
+			if path := containersPath(sbr, u); path != test.expected {
+				t.Errorf("Path not matching: expected '%s', got '%s'", test.expected, path)
+			}
+		})
+	}
+}
+
+func TestInjectPodLikeSpec(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{}
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.org/v1",
+			"kind":       "Workload",
+			"metadata": map[string]interface{}{
+				"name":      "my-app",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{
+						"image": "quay.io/example/app:latest",
+					},
+				},
+			},
+		},
+	}
+
+	envs := []corev1.EnvVar{{Name: "POSTGRES_PASSWORD", Value: "secret"}}
+	changed, err := inject(sbr, scheme.Scheme, u, envs, map[string]string{})
+	if err != nil {
+		t.Fatalf("inject: (%v)", err)
+	}
+	if !changed {
+		t.Fatal("Expected the containers to change")
+	}
+
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "containers")
+	if len(containers) != 1 {
+		t.Fatalf("Containers not matching: %v", containers)
+	}
+	container := &corev1.Container{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(containers[0].(map[string]interface{}), container)
+	if err != nil {
+		t.Fatalf("convert container: (%v)", err)
+	}
+	if !reflect.DeepEqual(container.Env, envs) {
+		t.Errorf("Env not matching: expected %v, got %v", envs, container.Env)
+	}
+}
+
+// TestBindRollout binds an Argo Rollout, a workload of a CRD named by its group, version and kind.
+func TestBindRollout(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				Group:        "argoproj.io",
+				Version:      "v1alpha1",
+				ResourceKind: "Rollout",
+				ResourceRef:  "orders",
+			},
+		},
+	}
+	rollout := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Rollout",
+			"metadata":   map[string]interface{}{"name": "orders", "namespace": "default"},
+			"spec": map[string]interface{}{
+				"strategy": map[string]interface{}{"canary": map[string]interface{}{}},
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{"name": "orders"}},
+					},
+				},
+			},
+		},
+	}
+	envs := []corev1.EnvVar{{Name: "POSTGRES_HOST", Value: "orders-db"}}
+
+	cl := fake.NewFakeClient(rollout)
+	binder := NewBinder(cl, scheme.Scheme, sbr, nil)
+	objs, err := binder.search()
+	if err != nil {
+		t.Fatalf("search: (%v)", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("Expected the rollout to be found, found %d objects", len(objs))
+	}
+	if gvk := objs[0].GetObjectKind().GroupVersionKind(); gvk.Group != "argoproj.io" || gvk.Kind != "Rollout" {
+		t.Fatalf("Expected a Rollout, found %v", gvk)
+	}
+	if _, err = binder.bind(objs[0], envs, map[string]string{}); err != nil {
+		t.Fatalf("bind: (%v)", err)
+	}
+
+	out := &unstructured.Unstructured{}
+	out.SetGroupVersionKind(rollout.GroupVersionKind())
+	if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "orders"}, out); err != nil {
+		t.Fatalf("get rollout: (%v)", err)
+	}
+	containers, _, err := unstructured.NestedSlice(out.Object, "spec", "template", "spec", "containers")
+	if err != nil || len(containers) != 1 {
+		t.Fatalf("Expected one container, found %v (%v)", containers, err)
+	}
+	container := &corev1.Container{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(containers[0].(map[string]interface{}), container); err != nil {
+		t.Fatalf("convert container: (%v)", err)
+	}
+	if !reflect.DeepEqual(container.Env, envs) {
+		t.Errorf("Env not matching: expected %v, got %v", envs, container.Env)
+	}
+	// the fields the operator doesn't know of are kept
+	if _, found, _ := unstructured.NestedMap(out.Object, "spec", "strategy", "canary"); !found {
+		t.Errorf("Rollout strategy not kept: %v", out.Object["spec"])
+	}
+
+	// the kind is of that group only, not one of the supported kinds
+	if selected, err := binder.selects(appsv1.SchemeGroupVersion.WithKind("Rollout"), out); err != nil || selected {
+		t.Errorf("Expected an apps Rollout not selected, found %v (%v)", selected, err)
+	}
+}
+
+func TestPodSpecPaths(t *testing.T) {
+	for _, k := range applicationKinds {
+		gk := schema.GroupKind{Group: k.list.Group, Kind: strings.TrimSuffix(k.list.Kind, "List")}
+		if _, ok := podSpecPaths[gk]; !ok {
+			t.Errorf("Expected a pod spec path for the %s kind, %v", k.kind, gk)
+		}
+	}
+}
+
+func TestChangeTypedWithoutPodTemplate(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"}}
+	rc := &corev1.ReplicationController{ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "default"}}
+	_, err := inject(sbr, scheme.Scheme, rc, []corev1.EnvVar{{Name: "POSTGRES_HOST", Value: "db"}}, map[string]string{})
+	if err == nil || err.Error() != "replicationcontroller 'my-app' has no pod template" {
+		t.Errorf("Expected a missing pod template error, found %v", err)
+	}
+}
+
+// TestValidatePodApplication rejects pods of the core group, the API server rejecting the update of
+// their containers, while a pod may still lead to its workload through an OwnedRef.
+func TestValidatePodApplication(t *testing.T) {
+	fldPath := field.NewPath("spec", "applicationSelector")
+	tests := []struct {
+		name     string
+		selector v1alpha1.ApplicationSelector
+		field    string
+	}{
+		{
+			name:     "pod",
+			selector: v1alpha1.ApplicationSelector{Version: "v1", ResourceKind: "Pod", ResourceRef: "migrate"},
+			field:    "spec.applicationSelector.resourceKind",
+		},
+		{
+			name: "referenced pod",
+			selector: v1alpha1.ApplicationSelector{
+				Version: "v1",
+				Refs:    []v1alpha1.NamespacedRef{{Namespace: "default", Name: "migrate", Kind: "Pod"}},
+			},
+			field: "spec.applicationSelector.refs[0].kind",
+		},
+		{
+			name: "owner of a pod",
+			selector: v1alpha1.ApplicationSelector{
+				OwnedRef: &v1alpha1.OwnedRef{APIVersion: "v1", Kind: "Pod", Name: "my-app-6d4cf56db6-x7k2p"},
+			},
+		},
+		{
+			name:     "pod-like kind of another group",
+			selector: v1alpha1.ApplicationSelector{Group: "example.org", Version: "v1", ResourceKind: "Pod", ResourceRef: "migrate"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				Spec: v1alpha1.ServiceBindingRequestSpec{ApplicationSelector: test.selector},
+			}
+			errs := ValidateApplicationSelector(sbr, fldPath)
+			if test.field == "" {
+				if len(errs) > 0 {
+					t.Errorf("Expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != test.field || errs[0].Type != field.ErrorTypeForbidden {
+				t.Errorf("Expected %s forbidden, got %v", test.field, errs)
+			}
+		})
+	}
+}
+
+func TestMountVolume(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres"},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BindAsFiles: true,
+			MountPath:   "/var/run/postgres",
+		},
+	}
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.org/v1",
+			"kind":       "Workload",
+			"metadata": map[string]interface{}{
+				"name":      "my-app",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{
+						"image": "quay.io/example/app:latest",
+					},
+				},
+				"volumes": []interface{}{
+					map[string]interface{}{
+						"name":     "cache",
+						"emptyDir": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}
+
+	changed, err := inject(sbr, scheme.Scheme, u, envs, map[string]string{})
+	if err != nil {
+		t.Fatalf("inject: (%v)", err)
+	}
+	if !changed {
+		t.Fatal("Expected the workload to change")
+	}
+	if changed, err = inject(sbr, scheme.Scheme, u, envs, map[string]string{}); err != nil || changed {
+		t.Fatalf("Expected binding again to change nothing, changed: %v, error: (%v)", changed, err)
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(u.Object, "spec", "volumes")
+	if len(volumes) != 2 {
+		t.Fatalf("Volumes not matching: %v", volumes)
+	}
+	volume := &corev1.Volume{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(volumes[1].(map[string]interface{}), volume)
+	if err != nil {
+		t.Fatalf("convert volume: (%v)", err)
+	}
+	if volume.Name != "postgres" || volume.Projected == nil || len(volume.Projected.Sources) != 1 {
+		t.Errorf("Volume not matching: %v", volume)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "containers")
+	container := &corev1.Container{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(containers[0].(map[string]interface{}), container)
+	if err != nil {
+		t.Fatalf("convert container: (%v)", err)
+	}
+	expected := []corev1.VolumeMount{{Name: "postgres", MountPath: "/var/run/postgres", ReadOnly: true}}
+	if !reflect.DeepEqual(container.VolumeMounts, expected) {
+		t.Errorf("Volume mounts not matching: expected %v, got %v", expected, container.VolumeMounts)
+	}
+	expectedEnv := []corev1.EnvVar{{Name: "SERVICE_BINDING_ROOT", Value: "/var/run"}}
+	if !reflect.DeepEqual(container.Env, expectedEnv) {
+		t.Errorf("Environment not matching: expected %v, got %v", expectedEnv, container.Env)
+	}
+}
+
+func TestServiceBindingRoot(t *testing.T) {
+	dp := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}},
+				},
+			},
+		},
+	}
+
+	// two bindings of the same deployment, the second one with its own mount path
+	for _, sbr := range []*v1alpha1.ServiceBindingRequest{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres"},
+			Spec:       v1alpha1.ServiceBindingRequestSpec{BindAsFiles: true},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis"},
+			Spec:       v1alpha1.ServiceBindingRequestSpec{BindAsFiles: true, MountPath: "/var/run/redis"},
+		},
+	} {
+		if _, err := inject(sbr, scheme.Scheme, dp, []corev1.EnvVar{}, map[string]string{}); err != nil {
+			t.Fatalf("inject %s: (%v)", sbr.Name, err)
+		}
+	}
+
+	podSpec := dp.Spec.Template.Spec
+	if len(podSpec.Volumes) != 2 || len(podSpec.Containers[0].VolumeMounts) != 2 {
+		t.Fatalf("Expected a volume and a mount per binding, found %v and %v", podSpec.Volumes, podSpec.Containers[0].VolumeMounts)
+	}
+	expected := []corev1.EnvVar{{Name: "SERVICE_BINDING_ROOT", Value: "/bindings"}}
+	if !reflect.DeepEqual(podSpec.Containers[0].Env, expected) {
+		t.Errorf("Environment not matching: expected %v, got %v", expected, podSpec.Containers[0].Env)
+	}
+}
+
+func TestBindInitContainers(t *testing.T) {
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+	dp := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{
+						Name:         "migrations",
+						Env:          []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+						VolumeMounts: []corev1.VolumeMount{{Name: "cache", MountPath: "/var/cache"}},
+					}},
+					Containers: []corev1.Container{{
+						Name:         "app",
+						Env:          []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+						VolumeMounts: []corev1.VolumeMount{{Name: "cache", MountPath: "/var/cache"}},
+					}},
+					Volumes: []corev1.Volume{{Name: "cache"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		spec       v1alpha1.ServiceBindingRequestSpec
+		initEnvs   int
+		initMounts int
+	}{
+		{name: "skipped by default", spec: v1alpha1.ServiceBindingRequestSpec{}, initEnvs: 1, initMounts: 1},
+		{name: "env", spec: v1alpha1.ServiceBindingRequestSpec{BindInitContainers: true}, initEnvs: 2, initMounts: 1},
+		{
+			name:       "files",
+			spec:       v1alpha1.ServiceBindingRequestSpec{BindInitContainers: true, BindAsFiles: true},
+			initEnvs:   2,
+			initMounts: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{ObjectMeta: metav1.ObjectMeta{Name: "postgres"}, Spec: test.spec}
+			bound := dp.DeepCopy()
+			if _, err := inject(sbr, scheme.Scheme, bound, envs, map[string]string{}); err != nil {
+				t.Fatalf("inject: (%v)", err)
+			}
+
+			podSpec := &bound.Spec.Template.Spec
+			if len(podSpec.Containers[0].Env) != 2 {
+				t.Errorf("Container not bound: %v", podSpec.Containers[0].Env)
+			}
+			initContainer := podSpec.InitContainers[0]
+			if len(initContainer.Env) != test.initEnvs || len(initContainer.VolumeMounts) != test.initMounts {
+				t.Errorf("Init container not matching: env %v, mounts %v", initContainer.Env, initContainer.VolumeMounts)
+			}
+
+			// ejecting with the flag unset still removes the binding from the init containers
+			unset := &v1alpha1.ServiceBindingRequest{ObjectMeta: sbr.ObjectMeta}
+			_, err := changePodSpec(unset, scheme.Scheme, bound, func(annotations map[string]string, spec *corev1.PodSpec) error {
+				return eject(unset, annotations, spec)
+			})
+			if err != nil {
+				t.Fatalf("eject: (%v)", err)
+			}
+			if !reflect.DeepEqual(&bound.Spec.Template, &dp.Spec.Template) {
+				t.Errorf("Pod template not ejected: %v", bound.Spec.Template)
+			}
+		})
+	}
+}
+
+func TestEject(t *testing.T) {
+	envs := []corev1.EnvVar{{
+		Name: "POSTGRES_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+	bindingKeys := map[string]string{"POSTGRES_PASSWORD": "password"}
+	annotations := map[string]string{envMapAnnotation: "password=DB_PASSWORD"}
+
+	newSpec := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:         "app",
+				Env:          []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+				VolumeMounts: []corev1.VolumeMount{{Name: "cache", MountPath: "/var/cache"}},
+			}},
+			Volumes: []corev1.Volume{{Name: "cache"}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		bindAsFiles bool
+		annotations map[string]string
+	}{
+		{name: "env"},
+		{name: "mapped env", annotations: annotations},
+		{name: "files", bindAsFiles: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "postgres"},
+				Spec:       v1alpha1.ServiceBindingRequestSpec{BindAsFiles: test.bindAsFiles},
+			}
+			spec := newSpec()
+			annotations := copyAnnotations(test.annotations)
+			if err := injectPodSpec(sbr, annotations, spec, envs, bindingKeys); err != nil {
+				t.Fatalf("inject: (%v)", err)
+			}
+			if reflect.DeepEqual(spec, newSpec()) {
+				t.Fatal("Expected the pod spec to be bound")
+			}
+			if err := eject(sbr, annotations, spec); err != nil {
+				t.Fatalf("eject: (%v)", err)
+			}
+			if !reflect.DeepEqual(spec, newSpec()) {
+				t.Errorf("Pod spec not matching: expected %+v, got %+v", newSpec(), spec)
+			}
+			if !reflect.DeepEqual(annotations, copyAnnotations(test.annotations)) {
+				t.Errorf("Annotations not matching: expected %v, got %v", test.annotations, annotations)
+			}
+		})
+	}
+}
+
+func TestEjectKeepsApplicationEnvs(t *testing.T) {
+	envs := []corev1.EnvVar{{
+		Name: "APP_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+				Key:                  "password",
+			},
+		},
+	}}
+	bindingKeys := map[string]string{"APP_PASSWORD": "password"}
+
+	// the variables of the application share the prefix of the binding "app", and it sets the
+	// root of the bindings itself
+	newSpec := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "app",
+				Env: []corev1.EnvVar{
+					{Name: "APP_HOME", Value: "/opt/app"},
+					{Name: serviceBindingRootEnv, Value: "/var/bindings"},
+				},
+				VolumeMounts: []corev1.VolumeMount{{Name: "cache", MountPath: "/var/cache"}},
+			}},
+			Volumes: []corev1.Volume{{Name: "cache"}},
+		}
+	}
+
+	for _, bindAsFiles := range []bool{false, true} {
+		sbr := &v1alpha1.ServiceBindingRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec:       v1alpha1.ServiceBindingRequestSpec{BindAsFiles: bindAsFiles},
+		}
+		spec := newSpec()
+		annotations := map[string]string{}
+		if err := injectPodSpec(sbr, annotations, spec, envs, bindingKeys); err != nil {
+			t.Fatalf("inject: (%v)", err)
+		}
+		bound, err := boundEnvs(annotations)
+		if err != nil {
+			t.Fatalf("bound envs: (%v)", err)
+		}
+		// bound as files, the variable is projected and the root set by the application is kept,
+		// the application being marked as bound still
+		expected := map[string][]string{"default/app": {"APP_PASSWORD"}}
+		if bindAsFiles {
+			expected = map[string][]string{"default/app": {}}
+		}
+		if !reflect.DeepEqual(bound, expected) {
+			t.Errorf("Expected %v recorded as bound, got %v", expected, bound)
+		}
+		if err = eject(sbr, annotations, spec); err != nil {
+			t.Fatalf("eject: (%v)", err)
+		}
+		if !reflect.DeepEqual(spec, newSpec()) {
+			t.Errorf("Expected the variables of the application kept, got %+v", spec)
+		}
+		if len(annotations) != 0 {
+			t.Errorf("Expected the bound envs annotation removed, got %v", annotations)
+		}
+	}
+}
+
+func TestBindingVolume(t *testing.T) {
+	secretEnv := func(name, secret, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+					Key:                  key,
+				},
+			},
+		}
+	}
+	sbr := &v1alpha1.ServiceBindingRequest{ObjectMeta: metav1.ObjectMeta{Name: "backends"}}
+	envs := []corev1.EnvVar{
+		secretEnv("BACKENDS_PASSWORD", "db-credentials", "password"),
+		secretEnv("BACKENDS_REDIS_PASSWORD", "redis-credentials", "password"),
+		// a second service of the same first label, the last one wins
+		secretEnv("BACKENDS_REDIS_PASSWORD", "redis-v2-credentials", "password"),
+	}
+	bindingKeys := map[string]string{
+		"BACKENDS_PASSWORD":       "password",
+		"BACKENDS_REDIS_PASSWORD": "redis/password",
+	}
+
+	volume := bindingVolume(sbr, envs, bindingKeys)
+	expected := []corev1.VolumeProjection{
+		{Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+			Items:                []corev1.KeyToPath{{Key: "password", Path: "password"}},
+		}},
+		{Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "redis-v2-credentials"},
+			Items:                []corev1.KeyToPath{{Key: "password", Path: "redis/password"}},
+		}},
+	}
+	if volume.Projected == nil || !reflect.DeepEqual(volume.Projected.Sources, expected) {
+		t.Errorf("Volume not matching: expected %+v, got %+v", expected, volume.Projected)
+	}
+}
+
+func TestInjectAttributesAsFiles(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres"},
+		Spec:       v1alpha1.ServiceBindingRequestSpec{BindAsFiles: true},
+	}
+	envs := []corev1.EnvVar{
+		{
+			Name: "POSTGRES_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+					Key:                  "password",
+				},
+			},
+		},
+		// an attribute read from the backing resource
+		{Name: "POSTGRES_PORT", Value: "5432"},
+	}
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}
+
+	if err := injectPodSpec(sbr, map[string]string{}, spec, envs, map[string]string{}); err != nil {
+		t.Fatalf("inject: (%v)", err)
+	}
+	if len(spec.Volumes) != 1 || len(spec.Volumes[0].Projected.Sources) != 1 {
+		t.Errorf("Volumes not matching: %+v", spec.Volumes)
+	}
+	expected := []corev1.EnvVar{
+		{Name: "SERVICE_BINDING_ROOT", Value: "/bindings"},
+		{Name: "POSTGRES_PORT", Value: "5432"},
+	}
+	if !reflect.DeepEqual(spec.Containers[0].Env, expected) {
+		t.Errorf("Environment not matching: expected %v, got %v", expected, spec.Containers[0].Env)
+	}
+}
+
+func TestSelects(t *testing.T) {
+	deployment := getGVK("Deployment")
+	app := &metav1.ObjectMeta{
+		Name:      "my-app",
+		Namespace: "default",
+		Labels:    map[string]string{"connects-to": "postgres"},
+	}
+
+	tests := []struct {
+		name     string
+		selector v1alpha1.ApplicationSelector
+		gvk      schema.GroupVersionKind
+		want     bool
+	}{
+		{
+			name:     "matching labels",
+			selector: v1alpha1.ApplicationSelector{MatchLabels: map[string]string{"connects-to": "postgres"}},
+			gvk:      deployment,
+			want:     true,
+		},
+		{
+			name:     "other labels",
+			selector: v1alpha1.ApplicationSelector{MatchLabels: map[string]string{"connects-to": "mysql"}},
+			gvk:      deployment,
+		},
+		{
+			name: "other kind",
+			selector: v1alpha1.ApplicationSelector{
+				MatchLabels:  map[string]string{"connects-to": "postgres"},
+				ResourceKind: "StatefulSet",
+			},
+			gvk: deployment,
+		},
+		{
+			name: "referenced",
+			selector: v1alpha1.ApplicationSelector{
+				Refs: []v1alpha1.NamespacedRef{{Namespace: "default", Name: "my-app"}},
+			},
+			gvk:  deployment,
+			want: true,
+		},
+		{
+			name:     "named",
+			selector: v1alpha1.ApplicationSelector{ResourceRef: "my-app"},
+			gvk:      deployment,
+			want:     true,
+		},
+		{
+			name:     "empty",
+			selector: v1alpha1.ApplicationSelector{},
+			gvk:      deployment,
+		},
+		{
+			name: "not referenced",
+			selector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+				Refs:        []v1alpha1.NamespacedRef{{Namespace: "default", Name: "other-app"}},
+			},
+			gvk: deployment,
+		},
+		{
+			name: "matching expressions",
+			selector: v1alpha1.ApplicationSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "connects-to", Operator: metav1.LabelSelectorOpIn, Values: []string{"mysql", "postgres"}},
+				},
+			},
+			gvk:  deployment,
+			want: true,
+		},
+		{
+			name: "labels not matching expressions",
+			selector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: metav1.LabelSelectorOpExists},
+				},
+			},
+			gvk: deployment,
+		},
+		{
+			name: "invalid expressions",
+			selector: v1alpha1.ApplicationSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "connects-to", Operator: "Matches"},
+				},
+			},
+			gvk: deployment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+				Spec:       v1alpha1.ServiceBindingRequestSpec{ApplicationSelector: tt.selector},
+			}
+			got, err := NewBinder(fake.NewFakeClient(), scheme.Scheme, sbr, nil).selects(tt.gvk, app)
+			if err != nil {
+				t.Fatalf("selects: (%v)", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected selects to be %v, found %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	namespace := "default"
+	deployment := func(name string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		}
+	}
+	cl := &selectingClient{Client: fake.NewFakeClient(
+		deployment("my-app", map[string]string{"connects-to": "postgres"}),
+		deployment("other-app", map[string]string{"connects-to": "postgres"}),
+		deployment("unlabeled-app", nil),
+	)}
+
+	tests := []struct {
+		name     string
+		selector v1alpha1.ApplicationSelector
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:     "named",
+			selector: v1alpha1.ApplicationSelector{ResourceRef: "unlabeled-app"},
+			want:     []string{"unlabeled-app"},
+		},
+		{
+			name:     "labels",
+			selector: v1alpha1.ApplicationSelector{MatchLabels: map[string]string{"connects-to": "postgres"}},
+			want:     []string{"my-app", "other-app"},
+		},
+		{
+			name: "named along with labels",
+			selector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+				ResourceRef: "other-app",
+			},
+			want: []string{"other-app"},
+		},
+		{
+			name: "label exists",
+			selector: v1alpha1.ApplicationSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "connects-to", Operator: metav1.LabelSelectorOpExists},
+				},
+			},
+			want: []string{"my-app", "other-app"},
+		},
+		{
+			name: "label does not exist",
+			selector: v1alpha1.ApplicationSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "connects-to", Operator: metav1.LabelSelectorOpDoesNotExist},
+				},
+			},
+			want: []string{"unlabeled-app"},
+		},
+		{
+			name:     "neither named nor labels",
+			selector: v1alpha1.ApplicationSelector{ResourceKind: "Deployment"},
+			wantErr:  ErrEmptyApplicationSelector,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: namespace},
+				Spec:       v1alpha1.ServiceBindingRequestSpec{ApplicationSelector: tt.selector},
+			}
+			objs, err := NewBinder(cl, scheme.Scheme, sbr, nil).search()
+			if err != tt.wantErr {
+				t.Fatalf("Expected error '%v', found '%v'", tt.wantErr, err)
+			}
+			names := []string{}
+			for _, obj := range objs {
+				names = append(names, obj.(*appsv1.Deployment).GetName())
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("Expected %v, found %v", tt.want, names)
+			}
+		})
+	}
+
+	t.Run("invalid expressions", func(t *testing.T) {
+		sbr := &v1alpha1.ServiceBindingRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: namespace},
+			Spec: v1alpha1.ServiceBindingRequestSpec{ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: metav1.LabelSelectorOpIn},
+				},
+			}},
+		}
+		_, err := NewBinder(cl, scheme.Scheme, sbr, nil).search()
+		if _, ok := err.(*InvalidApplicationSelectorError); !ok {
+			t.Errorf("Expected an InvalidApplicationSelectorError, found '%v'", err)
+		}
+	})
+}
+
+func TestSearchOwner(t *testing.T) {
+	namespace := "default"
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: namespace, UID: "1"}}
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name:            "my-app-6d4cf56db6",
+		Namespace:       namespace,
+		UID:             "2",
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+	}}
+	pod := func(name string, owners ...metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, OwnerReferences: owners}}
+	}
+	cl := fake.NewFakeClient(
+		deployment,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other-app", Namespace: namespace}},
+		replicaSet,
+		pod("my-app-6d4cf56db6-x7k2p", *metav1.NewControllerRef(replicaSet, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))),
+		pod("standalone"),
+	)
+	sbrOwning := func(apiVersion, kind, name string) *v1alpha1.ServiceBindingRequest {
+		return &v1alpha1.ServiceBindingRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: namespace},
+			Spec: v1alpha1.ServiceBindingRequestSpec{ApplicationSelector: v1alpha1.ApplicationSelector{
+				ResourceKind: "Deployment",
+				OwnedRef:     &v1alpha1.OwnedRef{APIVersion: apiVersion, Kind: kind, Name: name},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		sbr  *v1alpha1.ServiceBindingRequest
+	}{
+		{name: "pod", sbr: sbrOwning("v1", "Pod", "my-app-6d4cf56db6-x7k2p")},
+		{name: "replica set", sbr: sbrOwning("apps/v1", "ReplicaSet", "my-app-6d4cf56db6")},
+		{name: "application", sbr: sbrOwning("apps/v1", "Deployment", "my-app")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binder := NewBinder(cl, scheme.Scheme, tt.sbr, nil)
+			objs, err := binder.search()
+			if err != nil {
+				t.Fatalf("search: (%v)", err)
+			}
+			if len(objs) != 1 || objs[0].(*appsv1.Deployment).GetName() != "my-app" {
+				t.Fatalf("Expected the owning Deployment 'my-app', found %v", objs)
+			}
+
+			gvk := appsv1.SchemeGroupVersion.WithKind("Deployment")
+			for obj, want := range map[*appsv1.Deployment]bool{deployment: true, {ObjectMeta: metav1.ObjectMeta{Name: "other-app", Namespace: namespace}}: false} {
+				if selected, err := binder.selects(gvk, obj); err != nil || selected != want {
+					t.Errorf("Expected '%s' selected to be %v, found %v (%v)", obj.GetName(), want, selected, err)
+				}
+			}
+		})
+	}
+
+	t.Run("no owner", func(t *testing.T) {
+		_, err := NewBinder(cl, scheme.Scheme, sbrOwning("v1", "Pod", "standalone"), nil).search()
+		if _, ok := err.(*ApplicationOwnerNotFoundError); !ok {
+			t.Errorf("Expected an ApplicationOwnerNotFoundError, found '%v'", err)
+		}
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		_, err := NewBinder(cl, scheme.Scheme, sbrOwning("v1", "Pod", "missing"), nil).search()
+		if !errors.IsNotFound(err) {
+			t.Errorf("Expected a not found error, found '%v'", err)
+		}
+	})
+}
+
+func TestRetryTransient(t *testing.T) {
+	namespace := "default"
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+		},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: namespace},
+	}
+	timeout := errors.NewServerTimeout(appsv1.Resource("deployments"), "list", 1)
+
+	t.Run("transient error", func(t *testing.T) {
+		cl := &failingClient{Client: fake.NewFakeClient(dp), err: timeout, failures: 1}
+		objs, err := NewBinder(cl, scheme.Scheme, sbr, nil).search()
+		if err != nil {
+			t.Fatalf("search: (%v)", err)
+		}
+		if len(objs) != 1 || cl.calls != 2 {
+			t.Errorf("Expected the search to succeed on the second attempt, found %d objects in %d calls", len(objs), cl.calls)
+		}
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		forbidden := errors.NewForbidden(appsv1.Resource("deployments"), "", errs.New("no role"))
+		cl := &failingClient{Client: fake.NewFakeClient(dp), err: forbidden, failures: 1}
+		if _, err := NewBinder(cl, scheme.Scheme, sbr, nil).search(); err != forbidden {
+			t.Errorf("Expected the forbidden error, found '%v'", err)
+		}
+		if cl.calls != 1 {
+			t.Errorf("Expected no retry of a permanent error, found %d calls", cl.calls)
+		}
+	})
+
+	t.Run("backoff exhausted", func(t *testing.T) {
+		cl := &failingClient{Client: fake.NewFakeClient(dp), err: timeout, failures: 100}
+		if _, err := NewBinder(cl, scheme.Scheme, sbr, nil).search(); err != timeout {
+			t.Errorf("Expected the last timeout error, found '%v'", err)
+		}
+		if cl.calls != retry.DefaultBackoff.Steps {
+			t.Errorf("Expected %d attempts, found %d", retry.DefaultBackoff.Steps, cl.calls)
+		}
+	})
+
+	t.Run("secret creation", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+			Data:       map[string][]byte{"password": []byte("secret")},
+		}
+		unavailable := errors.NewServiceUnavailable("etcd is unavailable")
+		cl := &failingClient{Client: fake.NewFakeClient(secret), err: unavailable, failures: 1}
+		if err := NewBinder(cl, scheme.Scheme, sbr, nil).copySources(namespace, "payments", []string{"db-credentials"}, nil); err != nil {
+			t.Fatalf("copy: (%v)", err)
+		}
+		copied := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "payments", Name: "db-credentials"}, copied); err != nil {
+			t.Fatalf("get copied secret: (%v)", err)
+		}
+	})
+
+	t.Run("secret update conflict", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+			Data:       map[string][]byte{"password": []byte("rotated")},
+		}
+		stale := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "payments"},
+			Data:       map[string][]byte{"password": []byte("secret")},
+		}
+		cl := &conflictingClient{Client: fake.NewFakeClient(secret, stale), conflicts: 1}
+		if err := NewBinder(cl, scheme.Scheme, sbr, nil).copySources(namespace, "payments", []string{"db-credentials"}, nil); err != nil {
+			t.Fatalf("copy: (%v)", err)
+		}
+		if cl.updates != 2 {
+			t.Errorf("Expected the update to succeed on the second attempt, found %d updates", cl.updates)
+		}
+		copied := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "payments", Name: "db-credentials"}, copied); err != nil {
+			t.Fatalf("get copied secret: (%v)", err)
+		}
+		if string(copied.Data["password"]) != "rotated" {
+			t.Errorf("Copied secret not updated: %v", copied.Data)
+		}
+	})
+}
+
+func TestSearchCancelled(t *testing.T) {
+	namespace := "default"
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+		},
+	}
+	timeout := errors.NewServerTimeout(appsv1.Resource("deployments"), "list", 1)
+
+	t.Run("cancelled during the search", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// the timeout would be retried, the cancelled context stops the retries
+		cl := &cancellingClient{Client: fake.NewFakeClient(), cancel: cancel, err: timeout}
+		if _, err := NewBinder(cl, scheme.Scheme, sbr, nil).WithContext(ctx).search(); err != context.Canceled {
+			t.Errorf("Expected the context error, found '%v'", err)
+		}
+		if cl.calls != 1 {
+			t.Errorf("Expected no retry once the context is cancelled, found %d calls", cl.calls)
+		}
+	})
+
+	t.Run("cancelled before the search", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cl := &cancellingClient{Client: fake.NewFakeClient(), cancel: cancel, err: timeout}
+		if _, err := NewBinder(cl, scheme.Scheme, sbr, nil).WithContext(ctx).search(); err != context.Canceled {
+			t.Errorf("Expected the context error, found '%v'", err)
+		}
+		if cl.calls != 0 {
+			t.Errorf("Expected no call with a cancelled context, found %d calls", cl.calls)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"fmt"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BackingService is a backing service as the collectors read it.
+type BackingService struct {
+	// Namespace is the namespace of the backing service, its CSV, resource, secrets and config maps.
+	Namespace string
+	// CRD is the CRD description of the backing service, from its CSV.
+	CRD olmv1alpha1.CRDDescription
+	// Resource is the backing resource, nil when none is referenced.
+	Resource *unstructured.Unstructured
+	// SecretRef is the template of the name of the secret of the binding data, see
+	// v1alpha1.BackingSelector.
+	SecretRef string
+}
+
+// BindingCollector collects the binding descriptors of a backing service from one of its sources,
+// e.g. the descriptors of its CSV. The collectors of a ServiceBindingRequest run in order, each
+// given the descriptors collected before it, and return the descriptors they add. The values of
+// secrets are never read, the descriptors name the secrets the binding references. The API calls
+// are made with the context of the reconcile.
+//
+// Descriptors are returned rather than the binding data, so the collectors of a backing service
+// see what the ones before them named, e.g. LabelSecretCollector backing off once a secret is
+// described, and the reconciler alone reads the data, applying the include and exclude lists of
+// the ServiceBindingRequest and watching the secrets and config maps read.
+type BindingCollector interface {
+	Collect(ctx context.Context, service *BackingService, collected []Descriptor) ([]Descriptor, error)
+}
+
+// DefaultCollectors returns the collectors used unless the reconciler is given others, see
+// WithCollectors: the CSV descriptors, the annotations of the backing resource, the secret named
+// after it, and the secrets labeled as provided by it.
+func DefaultCollectors(c client.Client) []BindingCollector {
+	return []BindingCollector{
+		OLMDescriptorCollector{},
+		AnnotationCollector{},
+		SecretRefCollector{Client: c},
+		LabelSecretCollector{Client: c},
+	}
+}
+
+// sourceCollector returns the collector of the source a BackingSelector lists by name, see
+// v1alpha1.BackingSelector.Sources.
+func sourceCollector(c client.Client, source string) (BindingCollector, bool) {
+	switch source {
+	case "descriptors":
+		return OLMDescriptorCollector{}, true
+	case "annotations":
+		return AnnotationCollector{}, true
+	case "secretRef":
+		return SecretRefCollector{Client: c}, true
+	case "labels":
+		return LabelSecretCollector{Client: c}, true
+	}
+	return nil, false
+}
+
+// UnknownSourceError is returned when a BackingSelector lists a source that doesn't exist.
+type UnknownSourceError struct {
+	Source string
+}
+
+func (e *UnknownSourceError) Error() string {
+	return fmt.Sprintf("unknown source '%s', expected one of descriptors, annotations, secretRef or labels", e.Source)
+}
+
+// OLMDescriptorCollector collects the spec and status descriptors of the CSV. Without a backing
+// resource, the paths of the spec descriptors are the names of the secrets and config maps, and
+// attributes, with no resource to read them from, are left out. With one, only the fields of the
+// binding descriptors are read, the fields of the others, e.g. UI hints, may be missing or lists.
+// A secret descriptor of a list field names several secrets, see secretListDescriptors.
+type OLMDescriptorCollector struct{}
+
+// Collect implements BindingCollector.
+func (OLMDescriptorCollector) Collect(_ context.Context, service *BackingService, _ []Descriptor) ([]Descriptor, error) {
+	descs := []Descriptor{}
+	if service.Resource == nil {
+		for _, spec := range service.CRD.SpecDescriptors {
+			xds := []string{}
+			for _, xd := range spec.XDescriptors {
+				if _, ok := xDescriptorKey(xd, "attribute"); !ok {
+					xds = append(xds, xd)
+				}
+			}
+			descs = append(descs, Descriptor{Value: spec.Path, XDescriptors: xds})
+		}
+		return descs, nil
+	}
+
+	for _, spec := range service.CRD.SpecDescriptors {
+		if !bindingXDescriptor(spec.XDescriptors) {
+			continue
+		}
+		resourceDescs, err := resourceDescriptors(service.Resource, "spec", spec.Path, spec.XDescriptors)
+		if err != nil {
+			return nil, err
+		}
+		descs = append(descs, resourceDescs...)
+	}
+	for _, status := range service.CRD.StatusDescriptors {
+		if !bindingXDescriptor(status.XDescriptors) {
+			continue
+		}
+		resourceDescs, err := resourceDescriptors(service.Resource, "status", status.Path, status.XDescriptors)
+		if err != nil {
+			return nil, err
+		}
+		descs = append(descs, resourceDescs...)
+	}
+	return descs, nil
+}
+
+// AnnotationCollector collects the attributes named by the binding annotations of the backing
+// resource, see annotationDescriptors.
+type AnnotationCollector struct{}
+
+// Collect implements BindingCollector.
+func (AnnotationCollector) Collect(_ context.Context, service *BackingService, _ []Descriptor) ([]Descriptor, error) {
+	if service.Resource == nil {
+		return nil, nil
+	}
+	return annotationDescriptors(service.Resource)
+}
+
+// SecretRefCollector collects the secret named by the SecretRef template evaluated over the
+// backing resource, see secretRefName, for operators naming the secret after the resource rather
+// than in its status. All the keys of the secret are bound.
+type SecretRefCollector struct {
+	Client client.Client
+}
+
+// Collect implements BindingCollector.
+func (s SecretRefCollector) Collect(ctx context.Context, service *BackingService, _ []Descriptor) ([]Descriptor, error) {
+	if service.Resource == nil || service.SecretRef == "" {
+		return nil, nil
+	}
+	name, err := secretRefName(service.Resource, service.SecretRef)
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{}
+	if err = s.Client.Get(ctx, types.NamespacedName{Namespace: service.Namespace, Name: name}, secret); err != nil {
+		return nil, err
+	}
+	return []Descriptor{secretDescriptor(secret)}, nil
+}
+
+// LabelSecretCollector collects the secrets labeled as provided by the backing resource, see
+// providedDescriptors, for operators whose CSV has no secret descriptor: nothing is collected once
+// a descriptor names a secret.
+type LabelSecretCollector struct {
+	Client client.Client
+}
+
+// Collect implements BindingCollector.
+func (l LabelSecretCollector) Collect(ctx context.Context, service *BackingService, collected []Descriptor) ([]Descriptor, error) {
+	if service.Resource == nil {
+		return nil, nil
+	}
+	for _, desc := range collected {
+		for _, xd := range desc.XDescriptors {
+			if _, ok := xDescriptorKey(xd, "secret"); ok {
+				return nil, nil
+			}
+		}
+	}
+	return providedDescriptors(ctx, l.Client, service.Namespace, service.Resource.GetName())
+}
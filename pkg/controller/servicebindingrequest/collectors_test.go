@@ -0,0 +1,312 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// collectedBackingService returns a backing service with a port attribute and a secret descriptor,
+// and the resource when referenced.
+func collectedBackingService(referenced bool) *BackingService {
+	service := &BackingService{
+		Namespace: "default",
+		CRD: olmv1alpha1.CRDDescription{
+			Name:    "databases.example.org",
+			Version: "v1alpha1",
+			Kind:    "Database",
+			SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+				{Path: "port", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:port"}},
+			},
+			StatusDescriptors: []olmv1alpha1.StatusDescriptor{
+				{Path: "dbCredentials", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"}},
+			},
+		},
+	}
+	if referenced {
+		service.Resource = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": "Database",
+				"metadata": map[string]interface{}{
+					"name":        "orders-db",
+					"namespace":   "default",
+					"annotations": map[string]interface{}{"servicebinding.io/host": ".status.address"},
+				},
+				"spec":   map[string]interface{}{"port": int64(5432)},
+				"status": map[string]interface{}{"dbCredentials": "orders-credentials", "address": "orders-db.default.svc"},
+			},
+		}
+	}
+	return service
+}
+
+func TestOLMDescriptorCollector(t *testing.T) {
+	descs, err := OLMDescriptorCollector{}.Collect(context.TODO(), collectedBackingService(true), nil)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected := []Descriptor{
+		{Value: "5432", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:port"}},
+		{Value: "orders-credentials", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"}},
+	}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+
+	// the paths are taken as names, there is no resource to read the attribute from
+	descs, err = OLMDescriptorCollector{}.Collect(context.TODO(), collectedBackingService(false), nil)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected = []Descriptor{{Value: "port", XDescriptors: []string{}}}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+}
+
+func TestOLMDescriptorCollectorSkipsNonBindingDescriptors(t *testing.T) {
+	service := collectedBackingService(true)
+	service.CRD.SpecDescriptors = append(service.CRD.SpecDescriptors, olmv1alpha1.SpecDescriptor{
+		Path: "replicas", XDescriptors: []string{"urn:alm:descriptor:com.tectonic.ui:podCount"},
+	})
+	service.CRD.StatusDescriptors = append(service.CRD.StatusDescriptors, olmv1alpha1.StatusDescriptor{
+		Path: "conditions", XDescriptors: []string{"urn:alm:descriptor:io.kubernetes.conditions"},
+	})
+	service.Resource.Object["status"].(map[string]interface{})["conditions"] = []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True"},
+	}
+
+	// the replicas field is missing and the conditions are a list, neither is read
+	descs, err := OLMDescriptorCollector{}.Collect(context.TODO(), service, nil)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected := []Descriptor{
+		{Value: "5432", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:port"}},
+		{Value: "orders-credentials", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"}},
+	}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+}
+
+func TestOLMDescriptorCollectorSecretList(t *testing.T) {
+	service := collectedBackingService(true)
+	service.Resource.Object["status"].(map[string]interface{})["dbCredentials"] = []interface{}{"orders-admin", "orders-app"}
+
+	// each secret listed is described, its keys qualified by its name
+	descs, err := OLMDescriptorCollector{}.Collect(context.TODO(), service, nil)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	xds := []string{"urn:alm:descriptor:servicebindingrequest:secret:password"}
+	expected := []Descriptor{
+		{Value: "5432", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:port"}},
+		{Value: "orders-admin", XDescriptors: xds, KeyPrefix: "orders-admin-"},
+		{Value: "orders-app", XDescriptors: xds, KeyPrefix: "orders-app-"},
+	}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+}
+
+func TestAnnotationCollector(t *testing.T) {
+	descs, err := AnnotationCollector{}.Collect(context.TODO(), collectedBackingService(true), nil)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected := []Descriptor{
+		{Value: "orders-db.default.svc", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:host"}},
+	}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+
+	if descs, err = (AnnotationCollector{}).Collect(context.TODO(), collectedBackingService(false), nil); err != nil || len(descs) != 0 {
+		t.Errorf("Expected nothing collected without a resource, got %+v (%v)", descs, err)
+	}
+}
+
+func TestSecretRefCollector(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders-db-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("secret")},
+	}
+	collector := SecretRefCollector{Client: fake.NewFakeClient(secret)}
+	service := collectedBackingService(true)
+	service.SecretRef = "{{ .metadata.name }}-credentials"
+
+	descs, err := collector.Collect(context.TODO(), service, nil)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected := []Descriptor{{
+		Value: "orders-db-credentials",
+		XDescriptors: []string{
+			"urn:alm:descriptor:servicebindingrequest:secret:password",
+			"urn:alm:descriptor:servicebindingrequest:secret:username",
+		},
+	}}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+
+	// a field the resource doesn't have, or a secret not created yet, fails the collection
+	service.SecretRef = "{{ .status.credentials }}"
+	if _, err = collector.Collect(context.TODO(), service, nil); err == nil {
+		t.Error("Expected an error for a missing field")
+	}
+	service.SecretRef = "{{ .metadata.name }}-admin"
+	if _, err = collector.Collect(context.TODO(), service, nil); !secretNotFound(err) {
+		t.Errorf("Expected the secret not found, got (%v)", err)
+	}
+	// without a resource there is nothing to evaluate the template over
+	if descs, err = collector.Collect(context.TODO(), collectedBackingService(false), nil); err != nil || len(descs) != 0 {
+		t.Errorf("Expected nothing collected, got %+v (%v)", descs, err)
+	}
+}
+
+func TestLabelSecretCollector(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "orders-db-credentials",
+			Namespace: "default",
+			Labels:    map[string]string{providerLabel: "orders-db"},
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}
+	collector := LabelSecretCollector{Client: &selectingClient{Client: fake.NewFakeClient(secret)}}
+
+	descs, err := collector.Collect(context.TODO(), collectedBackingService(true), nil)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected := []Descriptor{
+		{Value: "orders-db-credentials", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"}},
+	}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+
+	// a secret collected before takes the place of the labeled ones
+	described := []Descriptor{{Value: "orders-credentials", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"}}}
+	if descs, err = collector.Collect(context.TODO(), collectedBackingService(true), described); err != nil || len(descs) != 0 {
+		t.Errorf("Expected nothing collected along with a secret, got %+v (%v)", descs, err)
+	}
+}
+
+// staticCollector collects its descriptors, recording the ones collected before it.
+type staticCollector struct {
+	descs     []Descriptor
+	collected *[]Descriptor
+}
+
+func (s staticCollector) Collect(_ context.Context, _ *BackingService, collected []Descriptor) ([]Descriptor, error) {
+	*s.collected = collected
+	return s.descs, nil
+}
+
+func TestCollectDescriptors(t *testing.T) {
+	service := collectedBackingService(false)
+	seen := []Descriptor{}
+	custom := staticCollector{
+		descs:     []Descriptor{{Value: "vault", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:provider"}}},
+		collected: &seen,
+	}
+
+	cl := fake.NewFakeClient()
+	descs, err := collectDescriptors(context.TODO(), cl, service.Namespace, service.CRD, v1alpha1.BackingSelector{}, []BindingCollector{OLMDescriptorCollector{}, custom})
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected := []Descriptor{
+		{Value: "port", XDescriptors: []string{}},
+		{Value: "vault", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:provider"}},
+	}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+	if !reflect.DeepEqual(seen, expected[:1]) {
+		t.Errorf("Expected the collector to see the descriptors collected before it, got %+v", seen)
+	}
+
+	// the backing resource is read before the collectors run
+	s := scheme.Scheme
+	s.AddKnownTypeWithName(crdGVK(service.CRD), &unstructured.Unstructured{})
+	if _, err = collectDescriptors(context.TODO(), cl, service.Namespace, service.CRD, v1alpha1.BackingSelector{ResourceRef: "missing-db"}, []BindingCollector{custom}); err == nil {
+		t.Error("Expected an error for a missing backing resource")
+	}
+}
+
+func TestCollectSourceDescriptors(t *testing.T) {
+	service := collectedBackingService(true)
+	// the CSV of this version of the operator has no binding descriptor
+	service.CRD.SpecDescriptors = nil
+	service.CRD.StatusDescriptors = nil
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders-db-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	service.Resource.SetAPIVersion("example.org/v1alpha1")
+	s := scheme.Scheme
+	s.AddKnownTypeWithName(crdGVK(service.CRD), &unstructured.Unstructured{})
+	cl := fake.NewFakeClient(service.Resource, secret)
+	selector := v1alpha1.BackingSelector{
+		ResourceName: service.CRD.Name,
+		ResourceRef:  "orders-db",
+		SecretRef:    "{{ .metadata.name }}-credentials",
+		Sources:      []string{"descriptors", "secretRef", "annotations"},
+	}
+
+	// the descriptors yield nothing, the secret is collected, the annotations are left out
+	descs, source, err := collectSourceDescriptors(context.TODO(), cl, service.Namespace, service.CRD, selector)
+	if err != nil {
+		t.Fatalf("collect: (%v)", err)
+	}
+	expected := []Descriptor{
+		{Value: "orders-db-credentials", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"}},
+	}
+	if source != "secretRef" || !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Expected %+v collected from secretRef, got %+v from '%s'", expected, descs, source)
+	}
+
+	// a source failing falls back to the next one
+	selector.SecretRef = "{{ .status.credentials }}"
+	if descs, source, err = collectSourceDescriptors(context.TODO(), cl, service.Namespace, service.CRD, selector); err != nil || source != "annotations" {
+		t.Errorf("Expected the annotations collected, got %+v from '%s' (%v)", descs, source, err)
+	}
+	// the error is returned when no source collects
+	selector.Sources = []string{"descriptors", "secretRef"}
+	if _, _, err = collectSourceDescriptors(context.TODO(), cl, service.Namespace, service.CRD, selector); err == nil {
+		t.Error("Expected the error of the secretRef source")
+	}
+
+	selector.Sources = []string{"status"}
+	if _, _, err = collectSourceDescriptors(context.TODO(), cl, service.Namespace, service.CRD, selector); err == nil {
+		t.Error("Expected an error for an unknown source")
+	} else if _, ok := err.(*UnknownSourceError); !ok {
+		t.Errorf("Expected an UnknownSourceError, got (%v)", err)
+	}
+}
+
+func TestWithCollectors(t *testing.T) {
+	cl := fake.NewFakeClient()
+	r := &ReconcileServiceBindingRequest{client: cl}
+	if collectors := r.bindingCollectors(); !reflect.DeepEqual(collectors, DefaultCollectors(cl)) {
+		t.Errorf("Expected the default collectors, got %+v", collectors)
+	}
+
+	custom := staticCollector{collected: &[]Descriptor{}}
+	collectors := append(DefaultCollectors(cl), custom)
+	if got := r.WithCollectors(collectors...).bindingCollectors(); !reflect.DeepEqual(got, collectors) {
+		t.Errorf("Expected the collectors set, got %+v", got)
+	}
+}
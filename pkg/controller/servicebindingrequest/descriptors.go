@@ -0,0 +1,428 @@
+package servicebindingrequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// xDescriptorNamespaces are the namespaces of the binding x-descriptors, as in
+// "urn:alm:descriptor:<namespace>:<kind>:<key>", both spellings used by operators being accepted.
+var xDescriptorNamespaces = []string{"servicebindingrequest", "io.servicebindingrequest"}
+
+// xDescriptorKey returns the key of a binding x-descriptor of the given kind, "secret", "configmap"
+// or "attribute", e.g. "password" for "urn:alm:descriptor:servicebindingrequest:secret:password".
+// Attributes are fields of the backing resource bound as they are.
+func xDescriptorKey(xd, kind string) (string, bool) {
+	for _, ns := range xDescriptorNamespaces {
+		prefix := "urn:alm:descriptor:" + ns + ":" + kind + ":"
+		if strings.HasPrefix(xd, prefix) {
+			return strings.TrimPrefix(xd, prefix), true
+		}
+	}
+	return "", false
+}
+
+// bindingXDescriptor returns whether one of the x-descriptors is a binding x-descriptor, of a
+// secret, a config map or an attribute, the other descriptors being left to the console.
+func bindingXDescriptor(xds []string) bool {
+	for _, xd := range xds {
+		for _, kind := range []string{"secret", "configmap", "attribute"} {
+			if _, ok := xDescriptorKey(xd, kind); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bindingAnnotationPrefix prefixes the annotations of backing resources naming binding keys, their
+// values being the paths of the fields to bind, e.g. "servicebinding.io/host: .status.address".
+const bindingAnnotationPrefix = "servicebinding.io/"
+
+// providerLabel is set, with the name of the backing resource, on the secrets holding its binding
+// data, for operators whose CSV has no secret descriptor, e.g. "service.binding/provider: orders-db".
+const providerLabel = "service.binding/provider"
+
+// Descriptor is a binding descriptor of a backing service, along with the x-descriptors naming its
+// keys. The value is the name of the secret or config map holding the keys, or the value bound for
+// attributes. The key prefix qualifies the binding keys of a secret named along with others by a
+// list field, see secretListDescriptors.
+type Descriptor struct {
+	Value        string
+	XDescriptors []string
+	KeyPrefix    string
+}
+
+// crdGVK returns the GroupVersionKind of the resources of the CRD description, the group being
+// the CRD name without the plural ("plural.group").
+func crdGVK(crd olmv1alpha1.CRDDescription) schema.GroupVersionKind {
+	group := ""
+	if i := strings.Index(crd.Name, "."); i >= 0 {
+		group = crd.Name[i+1:]
+	}
+	return schema.GroupVersionKind{Group: group, Version: crd.Version, Kind: crd.Kind}
+}
+
+// collectDescriptors returns the binding descriptors of the CRD description collected by the
+// collectors, in their order, reading the backing resource the selector references first.
+func collectDescriptors(
+	ctx context.Context,
+	c client.Client,
+	ns string,
+	crd olmv1alpha1.CRDDescription,
+	selector v1alpha1.BackingSelector,
+	collectors []BindingCollector,
+) ([]Descriptor, error) {
+	service, err := backingService(ctx, c, ns, crd, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	descs := []Descriptor{}
+	for _, collector := range collectors {
+		collected, err := collector.Collect(ctx, service, descs)
+		if err != nil {
+			return nil, err
+		}
+		descs = append(descs, collected...)
+	}
+	return descs, nil
+}
+
+// collectSourceDescriptors returns the binding descriptors of the CRD description collected from
+// the first of the Sources of the selector collecting a binding descriptor, in order, along with
+// its name, see sourceCollector. A source failing to collect falls back to the next one, the
+// error of the last failing being returned when none collects.
+func collectSourceDescriptors(
+	ctx context.Context,
+	c client.Client,
+	ns string,
+	crd olmv1alpha1.CRDDescription,
+	selector v1alpha1.BackingSelector,
+) ([]Descriptor, string, error) {
+	collectors := []BindingCollector{}
+	for _, source := range selector.Sources {
+		collector, ok := sourceCollector(c, source)
+		if !ok {
+			return nil, "", &UnknownSourceError{Source: source}
+		}
+		collectors = append(collectors, collector)
+	}
+	service, err := backingService(ctx, c, ns, crd, selector)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var failed error
+	for i, collector := range collectors {
+		descs, err := collector.Collect(ctx, service, nil)
+		if err != nil {
+			failed = err
+			continue
+		}
+		for _, desc := range descs {
+			if bindingXDescriptor(desc.XDescriptors) {
+				return descs, selector.Sources[i], nil
+			}
+		}
+	}
+	return []Descriptor{}, "", failed
+}
+
+// backingService returns the backing service of the CRD description as the collectors read it,
+// along with the backing resource the selector references.
+func backingService(
+	ctx context.Context,
+	c client.Client,
+	ns string,
+	crd olmv1alpha1.CRDDescription,
+	selector v1alpha1.BackingSelector,
+) (*BackingService, error) {
+	service := &BackingService{Namespace: ns, CRD: crd, SecretRef: selector.SecretRef}
+	if selector.ResourceRef != "" {
+		cr := &unstructured.Unstructured{}
+		cr.SetGroupVersionKind(crdGVK(crd))
+		if err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: selector.ResourceRef}, cr); err != nil {
+			return nil, err
+		}
+		service.Resource = cr
+	}
+	return service, nil
+}
+
+// providedDescriptors returns the secret descriptors of the secrets labeled as provided by the
+// backing resource, all their keys being bound, in the order of the secret names and keys.
+func providedDescriptors(ctx context.Context, c client.Client, ns string, resourceRef string) ([]Descriptor, error) {
+	sl := &corev1.SecretList{}
+	lo := &client.ListOptions{
+		Namespace:     ns,
+		LabelSelector: labels.SelectorFromSet(labels.Set{providerLabel: resourceRef}),
+	}
+	if err := c.List(ctx, lo, sl); err != nil {
+		return nil, err
+	}
+	sort.Slice(sl.Items, func(i, j int) bool { return sl.Items[i].GetName() < sl.Items[j].GetName() })
+
+	descs := []Descriptor{}
+	for _, secret := range sl.Items {
+		descs = append(descs, secretDescriptor(&secret))
+	}
+	return descs, nil
+}
+
+// secretDescriptor returns the descriptor of the secret binding all its keys, in their order.
+func secretDescriptor(secret *corev1.Secret) Descriptor {
+	keys := []string{}
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	xds := []string{}
+	for _, key := range keys {
+		xds = append(xds, "urn:alm:descriptor:"+xDescriptorNamespaces[0]+":secret:"+key)
+	}
+	return Descriptor{Value: secret.GetName(), XDescriptors: xds}
+}
+
+// secretRefName returns the name of the secret of the binding data, the SecretRef template
+// evaluated over the fields of the backing resource, e.g. "orders-db-credentials" for
+// "{{ .metadata.name }}-credentials".
+func secretRefName(cr *unstructured.Unstructured, secretRef string) (string, error) {
+	tmpl, err := template.New("secretRef").Option("missingkey=error").Parse(secretRef)
+	if err != nil {
+		return "", fmt.Errorf("secretRef '%s' is invalid: %v", secretRef, err)
+	}
+	name := &bytes.Buffer{}
+	if err = tmpl.Execute(name, cr.Object); err != nil {
+		return "", fmt.Errorf("secretRef '%s' of %s '%s' can't be evaluated: %v", secretRef, cr.GetKind(), cr.GetName(), err)
+	}
+	if name.Len() == 0 {
+		return "", fmt.Errorf("secretRef '%s' of %s '%s' names no secret", secretRef, cr.GetKind(), cr.GetName())
+	}
+	return name.String(), nil
+}
+
+// annotationDescriptors returns the attributes named by the binding annotations of the backing
+// resource, in the order of their keys. The values are JSONPath-like paths of the fields, e.g.
+// ".status.address" or "{.status.address}".
+func annotationDescriptors(cr *unstructured.Unstructured) ([]Descriptor, error) {
+	keys := []string{}
+	for annotation := range cr.GetAnnotations() {
+		if key := strings.TrimPrefix(annotation, bindingAnnotationPrefix); key != annotation && key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	descs := []Descriptor{}
+	for _, key := range keys {
+		annotation := bindingAnnotationPrefix + key
+		path := cr.GetAnnotations()[annotation]
+		path = strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}"), ".")
+		fields := strings.SplitN(path, ".", 2)
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("annotation '%s' of %s '%s' is not a field path", annotation, cr.GetKind(), cr.GetName())
+		}
+		value, err := fieldValue(cr, fields[0], fields[1])
+		if err != nil {
+			return nil, err
+		}
+		descs = append(descs, Descriptor{
+			Value:        value,
+			XDescriptors: []string{"urn:alm:descriptor:" + xDescriptorNamespaces[0] + ":attribute:" + key},
+		})
+	}
+	return descs, nil
+}
+
+// resourceDescriptors returns the descriptors of the field at the path of the spec or status of the
+// backing resource, a single one unless the field is a list of secrets, see secretListDescriptors.
+func resourceDescriptors(cr *unstructured.Unstructured, section string, path string, xds []string) ([]Descriptor, error) {
+	if descs, ok := secretListDescriptors(cr, section, path, xds); ok {
+		return descs, nil
+	}
+	value, err := fieldValue(cr, section, path)
+	if err != nil {
+		return nil, err
+	}
+	return []Descriptor{{Value: value, XDescriptors: xds}}, nil
+}
+
+// secretListDescriptors returns the descriptors of the secrets named by the list field at the dot
+// separated path of the spec or status of the backing resource, when the x-descriptors name
+// secret keys, e.g. "secrets" for "status: {secrets: [orders-db-admin, orders-db-app]}". The
+// secrets are bound together, in the order of the list, the keys of each being prefixed by its
+// name so the keys they share don't conflict, e.g. "orders-db-app-password". It returns false
+// when the field isn't a list of names.
+func secretListDescriptors(cr *unstructured.Unstructured, section string, path string, xds []string) ([]Descriptor, bool) {
+	secret := false
+	for _, xd := range xds {
+		if _, ok := xDescriptorKey(xd, "secret"); ok {
+			secret = true
+		}
+	}
+	if !secret || strings.ContainsAny(path, "[{") {
+		return nil, false
+	}
+
+	fields := append([]string{section}, strings.Split(path, ".")...)
+	value, found, err := unstructured.NestedFieldNoCopy(cr.Object, fields...)
+	items, ok := value.([]interface{})
+	if err != nil || !found || !ok || len(items) == 0 {
+		return nil, false
+	}
+	descs := []Descriptor{}
+	for _, item := range items {
+		name, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		descs = append(descs, Descriptor{Value: name, XDescriptors: xds, KeyPrefix: name + "-"})
+	}
+	return descs, true
+}
+
+// FieldNotFoundError is returned when the backing resource has no field at the path of a
+// descriptor. A status field may just not be populated yet by the backing service operator.
+type FieldNotFoundError struct {
+	Kind    string
+	Name    string
+	Section string
+	Path    string
+}
+
+func (e *FieldNotFoundError) Error() string {
+	return fmt.Sprintf("%s '%s' has no %s field '%s'", e.Kind, e.Name, e.Section, e.Path)
+}
+
+// fieldValue returns the value of the field at the dot separated path of the spec or status of
+// the backing resource, or at the JSONPath, relative to the spec or status, when the path has
+// brackets or braces, e.g. "connections[0].host". Values that aren't strings, like ports, are
+// formatted.
+func fieldValue(cr *unstructured.Unstructured, section string, path string) (string, error) {
+	if strings.ContainsAny(path, "[{") {
+		return jsonPathValue(cr, section, path)
+	}
+
+	fields := append([]string{section}, strings.Split(path, ".")...)
+	value, found, err := unstructured.NestedFieldNoCopy(cr.Object, fields...)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", &FieldNotFoundError{Kind: cr.GetKind(), Name: cr.GetName(), Section: section, Path: path}
+	}
+	return formatValue(cr, section, path, value)
+}
+
+// jsonPathValue returns the value of the field at the JSONPath, with or without braces, relative
+// to the spec or status of the backing resource. The path must lead to a single value.
+func jsonPathValue(cr *unstructured.Unstructured, section string, path string) (string, error) {
+	expr := strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+	if !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "[") {
+		expr = "." + expr
+	}
+
+	jp := jsonpath.New(section).AllowMissingKeys(true)
+	if err := jp.Parse("{." + section + expr + "}"); err != nil {
+		return "", fmt.Errorf("%s path '%s' of %s '%s' is invalid: %v", section, path, cr.GetKind(), cr.GetName(), err)
+	}
+	results, err := jp.FindResults(cr.Object)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 || len(results[0]) == 0 {
+		return "", &FieldNotFoundError{Kind: cr.GetKind(), Name: cr.GetName(), Section: section, Path: path}
+	}
+	if len(results) > 1 || len(results[0]) > 1 {
+		return "", fmt.Errorf("%s field '%s' of %s '%s' is not a single value", section, path, cr.GetKind(), cr.GetName())
+	}
+	return formatValue(cr, section, path, results[0][0].Interface())
+}
+
+// formatValue returns the value of the field as bound, formatted unless a string. Objects and
+// lists can't be bound.
+func formatValue(cr *unstructured.Unstructured, section string, path string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("%s field '%s' of %s '%s' is not a single value", section, path, cr.GetKind(), cr.GetName())
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// flattenJSON returns the values of the fields of the JSON object, base64 encoded or not, by their
+// paths: the names of the nested fields and the indexes of the list items joined by dashes, e.g.
+// "auth-user" for {"auth": {"user": "admin"}}. Values that aren't strings are formatted, nulls
+// are left out.
+func flattenJSON(data []byte) (map[string]string, error) {
+	obj, err := decodeJSONObject(data)
+	if err != nil {
+		// some operators encode the value once more
+		decoded, decodeErr := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if decodeErr != nil {
+			return nil, err
+		}
+		if obj, err = decodeJSONObject(decoded); err != nil {
+			return nil, err
+		}
+	}
+	values := map[string]string{}
+	flattenValue(values, "", obj)
+	return values, nil
+}
+
+// decodeJSONObject decodes the JSON object, keeping the numbers as written.
+func decodeJSONObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	obj := map[string]interface{}{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// flattenValue records the values of the JSON value at the path, see flattenJSON.
+func flattenValue(values map[string]string, path string, value interface{}) {
+	child := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "-" + name
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			flattenValue(values, child(name), field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(values, child(strconv.Itoa(i)), item)
+		}
+	case nil:
+	case string:
+		values[path] = v
+	default:
+		values[path] = fmt.Sprintf("%v", v)
+	}
+}
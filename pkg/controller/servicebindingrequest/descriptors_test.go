@@ -0,0 +1,325 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// descriptors returns the binding descriptors of the CRD description collected by the default
+// collectors, as a ServiceBindingRequest given no collectors collects them.
+func descriptors(
+	ctx context.Context,
+	c client.Client,
+	ns string,
+	crd olmv1alpha1.CRDDescription,
+	resourceRef string,
+) ([]Descriptor, error) {
+	return collectDescriptors(ctx, c, ns, crd, v1alpha1.BackingSelector{ResourceRef: resourceRef}, DefaultCollectors(c))
+}
+
+func TestCRDGVK(t *testing.T) {
+	crd := olmv1alpha1.CRDDescription{Name: "databases.example.org", Version: "v1alpha1", Kind: "Database"}
+	expected := schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "Database"}
+	if gvk := crdGVK(crd); gvk != expected {
+		t.Errorf("GVK not matching: expected %v, got %v", expected, gvk)
+	}
+}
+
+func TestXDescriptorKey(t *testing.T) {
+	tests := []struct {
+		xd   string
+		kind string
+		key  string
+		ok   bool
+	}{
+		{"urn:alm:descriptor:servicebindingrequest:secret:password", "secret", "password", true},
+		{"urn:alm:descriptor:io.servicebindingrequest:configmap:username", "configmap", "username", true},
+		{"urn:alm:descriptor:servicebindingrequest:configmap:username", "secret", "", false},
+		{"urn:alm:descriptor:com.tectonic.ui:podCount", "secret", "", false},
+	}
+	for _, tt := range tests {
+		key, ok := xDescriptorKey(tt.xd, tt.kind)
+		if key != tt.key || ok != tt.ok {
+			t.Errorf("%s as %s: expected (%q, %v), got (%q, %v)", tt.xd, tt.kind, tt.key, tt.ok, key, ok)
+		}
+	}
+}
+
+func TestDescriptors(t *testing.T) {
+	namespace := "default"
+	portXDescriptor := "urn:alm:descriptor:servicebindingrequest:attribute:port"
+	secretXDescriptor := "urn:alm:descriptor:servicebindingrequest:secret:password"
+	crd := olmv1alpha1.CRDDescription{
+		Name:    "databases.example.org",
+		Version: "v1alpha1",
+		Kind:    "Database",
+		SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+			{Path: "port", XDescriptors: []string{portXDescriptor}},
+		},
+		StatusDescriptors: []olmv1alpha1.StatusDescriptor{
+			{Path: "dbCredentials", XDescriptors: []string{secretXDescriptor}},
+		},
+	}
+
+	// the fake client needs the backing resource type to be known
+	s := scheme.Scheme
+	s.AddKnownTypeWithName(crdGVK(crd), &unstructured.Unstructured{})
+
+	database := func(name string, port int64, secret string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "example.org/v1alpha1",
+				"kind":       "Database",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": namespace,
+				},
+				"spec": map[string]interface{}{
+					"port": port,
+				},
+				"status": map[string]interface{}{
+					"dbCredentials": secret,
+				},
+			},
+		}
+	}
+	annotated := database("annotated-db", 5434, "annotated-credentials")
+	annotated.SetAnnotations(map[string]string{
+		"servicebinding.io/port": "{.spec.port}",
+		"servicebinding.io/host": ".status.address",
+		"example.org/owner":      "payments",
+	})
+	annotated.Object["status"].(map[string]interface{})["address"] = "annotated-db.default.svc"
+	cl := fake.NewFakeClient(
+		database("orders-db", 5432, "orders-credentials"),
+		database("billing-db", 5433, "billing-credentials"),
+		annotated,
+	)
+
+	tests := []struct {
+		name        string
+		resourceRef string
+		expected    []Descriptor
+	}{
+		{
+			// the path is taken as a name, there is no resource to read the attribute from
+			name:     "spec descriptors",
+			expected: []Descriptor{{Value: "port", XDescriptors: []string{}}},
+		},
+		{
+			name:        "referenced resource",
+			resourceRef: "orders-db",
+			expected: []Descriptor{
+				{Value: "5432", XDescriptors: []string{portXDescriptor}},
+				{Value: "orders-credentials", XDescriptors: []string{secretXDescriptor}},
+			},
+		},
+		{
+			name:        "other referenced resource",
+			resourceRef: "billing-db",
+			expected: []Descriptor{
+				{Value: "5433", XDescriptors: []string{portXDescriptor}},
+				{Value: "billing-credentials", XDescriptors: []string{secretXDescriptor}},
+			},
+		},
+		{
+			name:        "annotated resource",
+			resourceRef: "annotated-db",
+			expected: []Descriptor{
+				{Value: "5434", XDescriptors: []string{portXDescriptor}},
+				{Value: "annotated-credentials", XDescriptors: []string{secretXDescriptor}},
+				{Value: "annotated-db.default.svc", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:attribute:host"}},
+				{Value: "5434", XDescriptors: []string{portXDescriptor}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			descs, err := descriptors(context.TODO(), cl, namespace, crd, test.resourceRef)
+			if err != nil {
+				t.Fatalf("descriptors: (%v)", err)
+			}
+			if !reflect.DeepEqual(descs, test.expected) {
+				t.Errorf("Descriptors not matching: expected %+v, got %+v", test.expected, descs)
+			}
+		})
+	}
+
+	if _, err := descriptors(context.TODO(), cl, namespace, crd, "missing-db"); err == nil {
+		t.Error("Expected an error for a missing backing resource")
+	}
+}
+
+func TestAnnotationDescriptors(t *testing.T) {
+	cr := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":     "Database",
+			"metadata": map[string]interface{}{"name": "orders-db"},
+			"status":   map[string]interface{}{"address": "orders-db.default.svc"},
+		},
+	}
+	for _, path := range []string{"status", ".status.", "{}", ".status.port"} {
+		cr.SetAnnotations(map[string]string{"servicebinding.io/host": path})
+		if _, err := annotationDescriptors(cr); err == nil {
+			t.Errorf("Expected an error for path '%s'", path)
+		}
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	cr := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":     "Database",
+			"metadata": map[string]interface{}{"name": "orders-db"},
+			"status": map[string]interface{}{
+				"connections": []interface{}{
+					map[string]interface{}{"name": "primary", "host": "orders-db-0", "port": int64(5432)},
+					map[string]interface{}{"name": "replica", "host": "orders-db-1", "port": int64(5433)},
+				},
+			},
+		},
+	}
+
+	values := map[string]string{
+		"connections[0].host":                    "orders-db-0",
+		"{.connections[1].port}":                 "5433",
+		`connections[?(@.name=="replica")].host`: "orders-db-1",
+	}
+	for path, expected := range values {
+		value, err := fieldValue(cr, "status", path)
+		if err != nil {
+			t.Errorf("Unexpected error for path '%s': %v", path, err)
+		} else if value != expected {
+			t.Errorf("Expected '%s' for path '%s', got '%s'", expected, path, value)
+		}
+	}
+
+	for _, path := range []string{"connections[2].host", "connections[*].host", "connections[0]", "connections[0"} {
+		if _, err := fieldValue(cr, "status", path); err == nil {
+			t.Errorf("Expected an error for path '%s'", path)
+		}
+	}
+}
+
+func TestFlattenJSON(t *testing.T) {
+	blob := `{"host": "orders-db", "port": 5432, "tls": true, "auth": {"user": "admin", "token": null}, "hosts": ["a", "b"]}`
+	expected := map[string]string{
+		"host":      "orders-db",
+		"port":      "5432",
+		"tls":       "true",
+		"auth-user": "admin",
+		"hosts-0":   "a",
+		"hosts-1":   "b",
+	}
+	for name, data := range map[string]string{
+		"plain":  blob,
+		"base64": base64.StdEncoding.EncodeToString([]byte(blob)),
+	} {
+		values, err := flattenJSON([]byte(data))
+		if err != nil {
+			t.Errorf("Unexpected error flattening the %s value: %v", name, err)
+		} else if !reflect.DeepEqual(values, expected) {
+			t.Errorf("Values of the %s value not matching: expected %v, got %v", name, expected, values)
+		}
+	}
+
+	for _, data := range []string{"orders-db", `["a", "b"]`, base64.StdEncoding.EncodeToString([]byte("orders-db"))} {
+		if _, err := flattenJSON([]byte(data)); err == nil {
+			t.Errorf("Expected an error flattening '%s'", data)
+		}
+	}
+}
+
+func TestProvidedDescriptors(t *testing.T) {
+	namespace := "default"
+	portXDescriptor := "urn:alm:descriptor:servicebindingrequest:attribute:port"
+	crd := olmv1alpha1.CRDDescription{
+		Name:    "caches.example.org",
+		Version: "v1alpha1",
+		Kind:    "Cache",
+		SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+			{Path: "port", XDescriptors: []string{portXDescriptor}},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypeWithName(crdGVK(crd), &unstructured.Unstructured{})
+
+	cache := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.org/v1alpha1",
+			"kind":       "Cache",
+			"metadata": map[string]interface{}{
+				"name":      "sessions",
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"port":        int64(6379),
+				"credentials": "sessions-credentials",
+			},
+		},
+	}
+	secret := func(name string, provider string, keys ...string) *corev1.Secret {
+		data := map[string][]byte{}
+		for _, key := range keys {
+			data[key] = []byte(key)
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       data,
+		}
+		if provider != "" {
+			secret.SetLabels(map[string]string{providerLabel: provider})
+		}
+		return secret
+	}
+	cl := &selectingClient{Client: fake.NewFakeClient(
+		cache,
+		secret("sessions-credentials", "sessions", "username", "password"),
+		secret("sessions-tls", "sessions", "ca.crt"),
+		secret("carts-credentials", "carts", "password"),
+		secret("unlabeled", "", "token"),
+	)}
+
+	descs, err := descriptors(context.TODO(), cl, namespace, crd, "sessions")
+	if err != nil {
+		t.Fatalf("descriptors: (%v)", err)
+	}
+	expected := []Descriptor{
+		{Value: "6379", XDescriptors: []string{portXDescriptor}},
+		{Value: "sessions-credentials", XDescriptors: []string{
+			"urn:alm:descriptor:servicebindingrequest:secret:password",
+			"urn:alm:descriptor:servicebindingrequest:secret:username",
+		}},
+		{Value: "sessions-tls", XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:ca.crt"}},
+	}
+	if !reflect.DeepEqual(descs, expected) {
+		t.Errorf("Descriptors not matching: expected %+v, got %+v", expected, descs)
+	}
+
+	// a secret descriptor takes the place of the labels
+	described := crd
+	described.SpecDescriptors = append(described.SpecDescriptors, olmv1alpha1.SpecDescriptor{
+		Path:         "credentials",
+		XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+	})
+	descs, err = descriptors(context.TODO(), cl, namespace, described, "sessions")
+	if err != nil {
+		t.Fatalf("descriptors: (%v)", err)
+	}
+	if len(descs) != 2 {
+		t.Errorf("Expected no provided secret along with a secret descriptor, got %+v", descs)
+	}
+}
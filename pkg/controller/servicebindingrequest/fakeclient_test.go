@@ -0,0 +1,337 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"encoding/json"
+	errs "errors"
+	"fmt"
+	"reflect"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// conflictingClient fails the first updates of application objects with a conflict, as the API
+// server does when the object was changed after it was read. Updates of the ServiceBindingRequest,
+// e.g. adding the finalizer, are not counted.
+type conflictingClient struct {
+	client.Client
+	conflicts int
+	updates   int
+}
+
+func (c *conflictingClient) Update(ctx context.Context, obj runtime.Object) error {
+	if _, ok := obj.(*v1alpha1.ServiceBindingRequest); ok {
+		return c.Client.Update(ctx, obj)
+	}
+	c.updates++
+	if c.conflicts > 0 {
+		c.conflicts--
+		return errors.NewConflict(appsv1.Resource("deployments"), "my-app", errs.New("object was modified"))
+	}
+	return c.Client.Update(ctx, obj)
+}
+
+// racingClient changes the Deployment with change before the first update, which then fails with
+// a conflict, as when another controller wrote the object after it was read.
+type racingClient struct {
+	client.Client
+	change  func(dp *appsv1.Deployment)
+	updates int
+}
+
+func (c *racingClient) Update(ctx context.Context, obj runtime.Object) error {
+	c.updates++
+	if c.updates > 1 {
+		return c.Client.Update(ctx, obj)
+	}
+	key, err := client.ObjectKeyFromObject(obj)
+	if err != nil {
+		return err
+	}
+	// reading into a new object, the one updated already carries the changes of the caller
+	latest := &appsv1.Deployment{}
+	if err = c.Client.Get(ctx, key, latest); err != nil {
+		return err
+	}
+	c.change(latest)
+	if err = c.Client.Update(ctx, latest); err != nil {
+		return err
+	}
+	return errors.NewConflict(appsv1.Resource("deployments"), key.Name, errs.New("object was modified"))
+}
+
+// rejectingClient rejects the updates of the named application object, as an admission webhook
+// denying the change does.
+type rejectingClient struct {
+	client.Client
+	name string
+}
+
+func (c *rejectingClient) Update(ctx context.Context, obj runtime.Object) error {
+	if key, err := client.ObjectKeyFromObject(obj); err == nil && key.Name == c.name {
+		return errors.NewForbidden(appsv1.Resource("deployments"), c.name, errs.New("denied by policy"))
+	}
+	return c.Client.Update(ctx, obj)
+}
+
+// failingClient fails the first lists and creates with the error, as an API server briefly
+// unavailable does, and counts the calls.
+type failingClient struct {
+	client.Client
+	err      error
+	failures int
+	calls    int
+}
+
+func (c *failingClient) fail() error {
+	c.calls++
+	if c.failures > 0 {
+		c.failures--
+		return c.err
+	}
+	return nil
+}
+
+func (c *failingClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	if err := c.fail(); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, opts, list)
+}
+
+func (c *failingClient) Create(ctx context.Context, obj runtime.Object) error {
+	if err := c.fail(); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj)
+}
+
+// cancellingClient cancels the context of the calls on the first list, which times out, as a
+// reconcile running out of time during a slow API call does, and counts the lists.
+type cancellingClient struct {
+	client.Client
+	cancel func()
+	err    error
+	calls  int
+}
+
+func (c *cancellingClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	c.calls++
+	c.cancel()
+	return c.err
+}
+
+// countingClient counts the lists and the secret updates, to assert on the API calls spared by
+// caching or by skipping unchanged objects.
+type countingClient struct {
+	client.Client
+	lists         int
+	secretUpdates int
+}
+
+func (c *countingClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	c.lists++
+	return c.Client.List(ctx, opts, list)
+}
+
+func (c *countingClient) Update(ctx context.Context, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		c.secretUpdates++
+	}
+	return c.Client.Update(ctx, obj)
+}
+
+// recordingClient records the objects created, updated and deleted, status updates aside, to
+// assert on the changes a reconcile makes.
+type recordingClient struct {
+	client.Client
+	mutations []string
+}
+
+func (c *recordingClient) record(verb string, obj runtime.Object) {
+	key, _ := client.ObjectKeyFromObject(obj)
+	c.mutations = append(c.mutations, fmt.Sprintf("%s %T %s", verb, obj, key))
+}
+
+func (c *recordingClient) Create(ctx context.Context, obj runtime.Object) error {
+	c.record("create", obj)
+	return c.Client.Create(ctx, obj)
+}
+
+func (c *recordingClient) Update(ctx context.Context, obj runtime.Object) error {
+	c.record("update", obj)
+	return c.Client.Update(ctx, obj)
+}
+
+func (c *recordingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOptionFunc) error {
+	c.record("delete", obj)
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// selectingClient filters listed objects by the label selector, which the fake client ignores.
+type selectingClient struct {
+	client.Client
+}
+
+func (c *selectingClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	if err := c.Client.List(ctx, opts, list); err != nil {
+		return err
+	}
+	if opts == nil || opts.LabelSelector == nil {
+		return nil
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	selected := []runtime.Object{}
+	for _, item := range items {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if opts.LabelSelector.Matches(labels.Set(accessor.GetLabels())) {
+			selected = append(selected, item)
+		}
+	}
+	return meta.SetList(list, selected)
+}
+
+// unstructuredItems are the items of the list types registered for kinds without Go types. The
+// fake client encodes the lists it creates from the scheme, and an UnstructuredList would be
+// encoded without its kind, so the kind is taken from the items. Each kind needs its own list type
+// in the scheme.
+type unstructuredItems struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []unstructured.Unstructured `json:"items"`
+}
+
+func (l *unstructuredItems) deepCopy() unstructuredItems {
+	out := unstructuredItems{TypeMeta: l.TypeMeta, ListMeta: *l.ListMeta.DeepCopy()}
+	for _, item := range l.Items {
+		out.Items = append(out.Items, *item.DeepCopy())
+	}
+	return out
+}
+
+func (l unstructuredItems) MarshalJSON() ([]byte, error) {
+	type plain unstructuredItems
+	l.APIVersion, l.Kind = "v1", "List"
+	if len(l.Items) > 0 {
+		l.APIVersion = l.Items[0].GetAPIVersion()
+		l.Kind = l.Items[0].GetKind() + "List"
+	}
+	return json.Marshal(plain(l))
+}
+
+// workloadList lists the synthetic example.org/v1 Workload kind.
+type workloadList struct {
+	unstructuredItems
+}
+
+func (l *workloadList) DeepCopyObject() runtime.Object {
+	return &workloadList{l.deepCopy()}
+}
+
+// knativeServiceList lists Knative Services.
+type knativeServiceList struct {
+	unstructuredItems
+}
+
+func (l *knativeServiceList) DeepCopyObject() runtime.Object {
+	return &knativeServiceList{l.deepCopy()}
+}
+
+// generationClient tracks the generation of ServiceBindingRequests as the API server does with the
+// status subresource enabled: updates bump it when the spec changes and leave the status as
+// stored, returning it in the object, status updates write the status only, counting the ones
+// carrying a changed spec, which the API server would drop.
+type generationClient struct {
+	client.Client
+	specChanges int
+}
+
+func (c *generationClient) stored(sbr *v1alpha1.ServiceBindingRequest) (*v1alpha1.ServiceBindingRequest, error) {
+	key, err := client.ObjectKeyFromObject(sbr)
+	if err != nil {
+		return nil, err
+	}
+	stored := &v1alpha1.ServiceBindingRequest{}
+	return stored, c.Client.Get(context.TODO(), key, stored)
+}
+
+func (c *generationClient) Update(ctx context.Context, obj runtime.Object) error {
+	sbr, ok := obj.(*v1alpha1.ServiceBindingRequest)
+	if !ok {
+		return c.Client.Update(ctx, obj)
+	}
+	stored, err := c.stored(sbr)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(stored.Spec, sbr.Spec) {
+		sbr.SetGeneration(stored.GetGeneration() + 1)
+	}
+	sbr.Status = stored.Status
+	return c.Client.Update(ctx, sbr)
+}
+
+func (c *generationClient) Status() client.StatusWriter {
+	return &generationStatusWriter{client: c}
+}
+
+type generationStatusWriter struct {
+	client *generationClient
+}
+
+func (w *generationStatusWriter) Update(ctx context.Context, obj runtime.Object) error {
+	sbr, ok := obj.(*v1alpha1.ServiceBindingRequest)
+	if !ok {
+		return w.client.Client.Status().Update(ctx, obj)
+	}
+	stored, err := w.client.stored(sbr)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(stored.Spec, sbr.Spec) {
+		w.client.specChanges++
+	}
+	stored.Status = sbr.Status
+	if err = w.client.Client.Update(ctx, stored); err != nil {
+		return err
+	}
+	sbr.SetResourceVersion(stored.GetResourceVersion())
+	return nil
+}
+
+// phaseRecordingClient records the phase of every status update of a ServiceBindingRequest.
+type phaseRecordingClient struct {
+	client.Client
+	phases []v1alpha1.ServiceBindingRequestPhase
+}
+
+func (c *phaseRecordingClient) Status() client.StatusWriter {
+	return &phaseRecordingStatusWriter{client: c}
+}
+
+type phaseRecordingStatusWriter struct {
+	client *phaseRecordingClient
+}
+
+func (w *phaseRecordingStatusWriter) Update(ctx context.Context, obj runtime.Object) error {
+	if sbr, ok := obj.(*v1alpha1.ServiceBindingRequest); ok {
+		w.client.phases = append(w.client.phases, sbr.Status.Phase)
+	}
+	return w.client.Client.Status().Update(ctx, obj)
+}
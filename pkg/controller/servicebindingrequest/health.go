@@ -0,0 +1,165 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// reconcileFailureThresholdEnvVar names the operator environment variable with the fraction of the
+// recent reconciles that, failing, make the operator report not ready, e.g. "0.5". The default is
+// used when it's empty or not a fraction.
+const reconcileFailureThresholdEnvVar = "RECONCILE_FAILURE_THRESHOLD"
+
+// healthProbeAddrEnvVar names the operator environment variable with the address the health probes
+// are served at, defaultHealthProbeAddr when empty.
+const healthProbeAddrEnvVar = "HEALTH_PROBE_ADDR"
+
+const (
+	defaultFailureThreshold = 0.5
+	defaultHealthProbeAddr  = ":8081"
+	// healthWindow is the number of recent reconciles the readiness is evaluated over.
+	healthWindow = 20
+	// healthMinReconciles is the number of reconciles recorded before the operator can report not
+	// ready, so the first reconciles after it starts don't decide alone.
+	healthMinReconciles = 10
+)
+
+// reconcileHealth evaluates the readiness of the operator from its recent reconciles, the operator
+// being not ready once the threshold fraction of them failed, e.g. the API server rejecting its
+// calls. A nil health records nothing and is always ready.
+type reconcileHealth struct {
+	mu        sync.Mutex
+	threshold float64
+	// failed are whether the recent reconciles failed, a ring of at most healthWindow results.
+	failed []bool
+	// next is the index of the ring the next result is recorded at.
+	next int
+}
+
+// newReconcileHealth returns a health with no reconcile recorded, not ready from the threshold.
+func newReconcileHealth(threshold float64) *reconcileHealth {
+	return &reconcileHealth{threshold: threshold}
+}
+
+// parseFailureThreshold parses the fraction of the RECONCILE_FAILURE_THRESHOLD variable.
+func parseFailureThreshold(value string) float64 {
+	if value == "" {
+		return defaultFailureThreshold
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		log.Info("Invalid reconcile failure threshold, using the default", "Value", value, "Default", defaultFailureThreshold)
+		return defaultFailureThreshold
+	}
+	return threshold
+}
+
+// record records the result of a reconcile, dropping the oldest one out of the window.
+func (h *reconcileHealth) record(err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.failed) < healthWindow {
+		h.failed = append(h.failed, err != nil)
+		return
+	}
+	h.failed[h.next] = err != nil
+	h.next = (h.next + 1) % healthWindow
+}
+
+// ready returns an error telling how many of the recent reconciles failed when at least the
+// threshold fraction of them did, nil otherwise.
+func (h *reconcileHealth) ready() error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.failed) < healthMinReconciles {
+		return nil
+	}
+	failures := 0
+	for _, failed := range h.failed {
+		if failed {
+			failures++
+		}
+	}
+	if float64(failures) >= h.threshold*float64(len(h.failed)) {
+		return fmt.Errorf("%d of the last %d reconciles failed", failures, len(h.failed))
+	}
+	return nil
+}
+
+// operatorHealth is the health the reconciles are recorded in and the probes answer from, see
+// sharedHealth.
+var (
+	operatorHealth     *reconcileHealth
+	operatorHealthOnce sync.Once
+)
+
+// sharedHealth returns the health of the operator, created on first use with the threshold of the
+// RECONCILE_FAILURE_THRESHOLD variable.
+func sharedHealth() *reconcileHealth {
+	operatorHealthOnce.Do(func() {
+		operatorHealth = newReconcileHealth(parseFailureThreshold(os.Getenv(reconcileFailureThresholdEnvVar)))
+	})
+	return operatorHealth
+}
+
+// ServeHealthProbes serves the health probes of the operator at HEALTH_PROBE_ADDR until stop is
+// closed. It's meant to be started before the leader election, so a replica on standby answers the
+// probes too, ready as it records no reconcile.
+func ServeHealthProbes(stop <-chan struct{}) error {
+	addr := os.Getenv(healthProbeAddrEnvVar)
+	if addr == "" {
+		addr = defaultHealthProbeAddr
+	}
+	return (&healthServer{addr: addr, health: sharedHealth()}).Start(stop)
+}
+
+// healthServer serves the health probes of the operator: /healthz answers as long as the operator
+// runs, and /readyz as long as it's ready, see reconcileHealth.
+type healthServer struct {
+	addr   string
+	health *reconcileHealth
+}
+
+// handler returns the handler of the probes.
+func (s *healthServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if err := s.health.ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+	return mux
+}
+
+// Start serves the probes until stop is closed, failing when the address can't be served.
+func (s *healthServer) Start(stop <-chan struct{}) error {
+	srv := &http.Server{Addr: s.addr, Handler: s.handler()}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	}
+}
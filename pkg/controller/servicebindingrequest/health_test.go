@@ -0,0 +1,97 @@
+package servicebindingrequest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReconcileHealth(t *testing.T) {
+	failure := errors.New("forbidden")
+	h := newReconcileHealth(0.5)
+
+	// too few reconciles to tell
+	for i := 0; i < healthMinReconciles-1; i++ {
+		h.record(failure)
+	}
+	if err := h.ready(); err != nil {
+		t.Fatalf("Expected ready before %d reconciles, found: %v", healthMinReconciles, err)
+	}
+	h.record(failure)
+	if err := h.ready(); err == nil || err.Error() != "10 of the last 10 reconciles failed" {
+		t.Fatalf("Expected not ready, found: %v", err)
+	}
+
+	// successes fill the window, the failures still at the threshold
+	for i := 0; i < healthWindow-healthMinReconciles; i++ {
+		h.record(nil)
+	}
+	if err := h.ready(); err == nil || err.Error() != "10 of the last 20 reconciles failed" {
+		t.Fatalf("Expected not ready at the threshold, found: %v", err)
+	}
+	h.record(nil)
+	if err := h.ready(); err != nil {
+		t.Fatalf("Expected ready once the oldest failure is dropped, found: %v", err)
+	}
+
+	// recovered, whatever failed before the window
+	for i := 0; i < healthWindow; i++ {
+		h.record(nil)
+	}
+	if err := h.ready(); err != nil {
+		t.Errorf("Expected ready, found: %v", err)
+	}
+
+	var none *reconcileHealth
+	none.record(failure)
+	if err := none.ready(); err != nil {
+		t.Errorf("Expected a nil health to be ready, found: %v", err)
+	}
+}
+
+func TestParseFailureThreshold(t *testing.T) {
+	tests := map[string]float64{
+		"":     defaultFailureThreshold,
+		"0.25": 0.25,
+		"1":    1,
+		"0":    defaultFailureThreshold,
+		"1.5":  defaultFailureThreshold,
+		"half": defaultFailureThreshold,
+	}
+	for value, expected := range tests {
+		if threshold := parseFailureThreshold(value); threshold != expected {
+			t.Errorf("Expected %v for '%s', found %v", expected, value, threshold)
+		}
+	}
+}
+
+func TestHealthServer(t *testing.T) {
+	h := newReconcileHealth(0.5)
+	server := httptest.NewServer((&healthServer{health: h}).handler())
+	defer server.Close()
+
+	status := func(path string) int {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("get %s: (%v)", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// a replica on standby records no reconcile, it's ready
+	if code := status("/readyz"); code != http.StatusOK {
+		t.Errorf("Expected ready, found status %d", code)
+	}
+	for i := 0; i < healthWindow; i++ {
+		h.record(errors.New("forbidden"))
+	}
+	if code := status("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected not ready, found status %d", code)
+	}
+	// the operator is alive while failing
+	if code := status("/healthz"); code != http.StatusOK {
+		t.Errorf("Expected alive, found status %d", code)
+	}
+}
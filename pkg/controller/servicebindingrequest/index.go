@@ -0,0 +1,178 @@
+package servicebindingrequest
+
+import (
+	"strings"
+	"sync"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// csvSourceKind is the kind of the sources naming the backing resource names the
+// ServiceBindingRequests look the CRD descriptions of their backing services up by, in lower case.
+const csvSourceKind = "ClusterServiceVersion"
+
+// sourceKey identifies an object a ServiceBindingRequest reads its binding data from.
+type sourceKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// sourceIndex maps the objects the ServiceBindingRequests read their binding data from, like the
+// secrets of the backing services, back to the ServiceBindingRequests, so a change to one of them
+// requeues the ServiceBindingRequests reading it. The index is kept up to date by the reconciles,
+// every ServiceBindingRequest being reconciled when the operator starts.
+type sourceIndex struct {
+	mu sync.RWMutex
+	// sbrs are the ServiceBindingRequests reading each source.
+	sbrs map[sourceKey]map[types.NamespacedName]bool
+	// sources are the sources read by each ServiceBindingRequest.
+	sources map[types.NamespacedName][]sourceKey
+}
+
+// newSourceIndex returns an empty index.
+func newSourceIndex() *sourceIndex {
+	return &sourceIndex{
+		sbrs:    map[sourceKey]map[types.NamespacedName]bool{},
+		sources: map[types.NamespacedName][]sourceKey{},
+	}
+}
+
+// set replaces the sources read by the ServiceBindingRequest.
+func (i *sourceIndex) set(sbr types.NamespacedName, sources []sourceKey) {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.unset(sbr)
+	for _, source := range sources {
+		if i.sbrs[source] == nil {
+			i.sbrs[source] = map[types.NamespacedName]bool{}
+		}
+		i.sbrs[source][sbr] = true
+	}
+	i.sources[sbr] = sources
+}
+
+// remove drops the ServiceBindingRequest from the index.
+func (i *sourceIndex) remove(sbr types.NamespacedName) {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.unset(sbr)
+}
+
+// unset drops the ServiceBindingRequest from the index, the lock being held.
+func (i *sourceIndex) unset(sbr types.NamespacedName) {
+	for _, source := range i.sources[sbr] {
+		delete(i.sbrs[source], sbr)
+		if len(i.sbrs[source]) == 0 {
+			delete(i.sbrs, source)
+		}
+	}
+	delete(i.sources, sbr)
+}
+
+// requests returns the requests of the ServiceBindingRequests reading the source.
+func (i *sourceIndex) requests(source sourceKey) []reconcile.Request {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	requests := []reconcile.Request{}
+	for sbr := range i.sbrs[source] {
+		requests = append(requests, reconcile.Request{NamespacedName: sbr})
+	}
+	return requests
+}
+
+// has returns whether a ServiceBindingRequest reads the source.
+func (i *sourceIndex) has(source sourceKey) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.sbrs[source]) > 0
+}
+
+// predicate returns a predicate dropping the events of the objects of the kind no
+// ServiceBindingRequest reads, most of the secrets and config maps of the cluster, before they're
+// queued to the handler.
+func (i *sourceIndex) predicate(kind string) predicate.Predicate {
+	indexed := func(meta metav1.Object) bool {
+		return i.has(sourceKey{kind: kind, namespace: meta.GetNamespace(), name: meta.GetName()})
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return indexed(e.Meta) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return indexed(e.Meta) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return indexed(e.MetaNew) },
+		GenericFunc: func(e event.GenericEvent) bool { return indexed(e.Meta) },
+	}
+}
+
+// eventHandler returns a handler enqueuing the ServiceBindingRequests reading the objects of the
+// kind changed.
+func (i *sourceIndex) eventHandler(kind string) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			return i.requests(sourceKey{kind: kind, namespace: o.Meta.GetNamespace(), name: o.Meta.GetName()})
+		}),
+	}
+}
+
+// csvHandler returns a handler enqueuing the ServiceBindingRequests backed by the CRDs owned by the
+// CSVs changed, e.g. the descriptors of an upgraded operator, by CRD name or bare Kind.
+func (i *sourceIndex) csvHandler() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			csv, ok := o.Object.(*olmv1alpha1.ClusterServiceVersion)
+			if !ok {
+				return nil
+			}
+			requests := []reconcile.Request{}
+			for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
+				requests = append(requests, i.requests(sourceKey{kind: csvSourceKind, name: crd.Name})...)
+				requests = append(requests, i.requests(sourceKey{kind: csvSourceKind, name: strings.ToLower(crd.Kind)})...)
+			}
+			return requests
+		}),
+	}
+}
+
+// kindWatcher starts the watches of the backing resource kinds, once per kind, as the
+// ServiceBindingRequests reference backing resources. The kinds are only known from the CSVs,
+// which may be installed after the operator started.
+type kindWatcher struct {
+	mu      sync.Mutex
+	watched map[schema.GroupVersionKind]bool
+	watch   func(gvk schema.GroupVersionKind) error
+}
+
+// newKindWatcher returns a watcher starting the watches with the function.
+func newKindWatcher(watch func(gvk schema.GroupVersionKind) error) *kindWatcher {
+	return &kindWatcher{watched: map[schema.GroupVersionKind]bool{}, watch: watch}
+}
+
+// ensure starts the watch of the kind, unless already started. A failed watch is started again
+// the next time.
+func (w *kindWatcher) ensure(gvk schema.GroupVersionKind) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.watched[gvk] {
+		return nil
+	}
+	if err := w.watch(gvk); err != nil {
+		return err
+	}
+	w.watched[gvk] = true
+	return nil
+}
@@ -0,0 +1,68 @@
+package servicebindingrequest
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// reconcileTotal counts the reconciles, by result, "success" or "error".
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "sbr_reconcile_total",
+		Help: "Total number of ServiceBindingRequest reconciles, by result.",
+	}, []string{"result"})
+	// reconcileDuration observes the duration of the reconciles.
+	reconcileDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "sbr_reconcile_duration_seconds",
+		Help:    "Duration of the ServiceBindingRequest reconciles in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+	// bindingsActive is the number of ServiceBindingRequests with applications bound.
+	bindingsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "sbr_bindings_active",
+		Help: "Number of ServiceBindingRequests with applications bound.",
+	})
+	// applicationsBoundTotal counts the application objects updated with binding data.
+	applicationsBoundTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "sbr_applications_bound_total",
+		Help: "Total number of application objects updated with binding data.",
+	})
+)
+
+func init() {
+	// the manager serves the metrics of its registry
+	metrics.Registry.MustRegister(reconcileTotal, reconcileDuration, bindingsActive, applicationsBoundTotal)
+}
+
+// reconcileResult returns the result label of a reconcile.
+func reconcileResult(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// activeBindings tracks the ServiceBindingRequests with applications bound, reported by the
+// sbr_bindings_active gauge.
+type activeBindings struct {
+	mu   sync.Mutex
+	sbrs map[types.NamespacedName]bool
+}
+
+// active are the ServiceBindingRequests with applications bound.
+var active = &activeBindings{sbrs: map[types.NamespacedName]bool{}}
+
+// set tracks whether the ServiceBindingRequest has applications bound.
+func (a *activeBindings) set(sbr types.NamespacedName, bound bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if bound {
+		a.sbrs[sbr] = true
+	} else {
+		delete(a.sbrs, sbr)
+	}
+	bindingsActive.Set(float64(len(a.sbrs)))
+}
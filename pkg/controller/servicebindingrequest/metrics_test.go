@@ -0,0 +1,204 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"testing"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// metricValue returns the value of the counter or gauge, or the sample count of the histogram.
+func metricValue(t *testing.T, m prometheus.Metric) float64 {
+	out := &dto.Metric{}
+	if err := m.Write(out); err != nil {
+		t.Fatalf("write metric: (%v)", err)
+	}
+	switch {
+	case out.Counter != nil:
+		return out.Counter.GetValue()
+	case out.Gauge != nil:
+		return out.Gauge.GetValue()
+	default:
+		return float64(out.Histogram.GetSampleCount())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres-metrics"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			InlineNonSensitive: true,
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path:         "db-config",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:configmap:username"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"username": "postgres",
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, cm, dp.DeepCopy())
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	successes := metricValue(t, reconcileTotal.WithLabelValues("success"))
+	failures := metricValue(t, reconcileTotal.WithLabelValues("error"))
+	observed := metricValue(t, reconcileDuration)
+	bound := metricValue(t, applicationsBoundTotal)
+	activeBefore := metricValue(t, bindingsActive)
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if v := metricValue(t, reconcileTotal.WithLabelValues("success")); v != successes+1 {
+		t.Errorf("Expected a successful reconcile to be counted, found %v", v-successes)
+	}
+	if v := metricValue(t, reconcileDuration); v != observed+1 {
+		t.Errorf("Expected the reconcile duration to be observed, found %v", v-observed)
+	}
+	if v := metricValue(t, applicationsBoundTotal); v != bound+1 {
+		t.Errorf("Expected the bound deployment to be counted, found %v", v-bound)
+	}
+	if v := metricValue(t, bindingsActive); v != activeBefore+1 {
+		t.Errorf("Expected the binding to be active, found %v", v-activeBefore)
+	}
+
+	// binding again changes nothing
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if v := metricValue(t, applicationsBoundTotal); v != bound+1 {
+		t.Errorf("Expected the deployment already bound not to be counted, found %v", v-bound)
+	}
+	if v := metricValue(t, bindingsActive); v != activeBefore+1 {
+		t.Errorf("Expected the binding to be active once, found %v", v-activeBefore)
+	}
+
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	now := metav1.Now()
+	sbrOut.SetDeletionTimestamp(&now)
+	if err := cl.Update(context.TODO(), sbrOut); err != nil {
+		t.Fatalf("update sbr: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if v := metricValue(t, bindingsActive); v != activeBefore {
+		t.Errorf("Expected the unbound binding not to be active, found %v", v-activeBefore)
+	}
+
+	// a reconcile failing without the config map to inline
+	cl = fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp.DeepCopy())
+	r = &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	if _, err := r.Reconcile(req); err == nil {
+		t.Fatalf("reconcile worked without the config map to inline")
+	}
+	if v := metricValue(t, reconcileTotal.WithLabelValues("error")); v != failures+1 {
+		t.Errorf("Expected a failed reconcile to be counted, found %v", v-failures)
+	}
+}
@@ -0,0 +1,330 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// csvCacheTTL is how long the CSVs listed in a namespace are reused, when not invalidated before.
+const csvCacheTTL = 30 * time.Second
+
+var (
+	// ErrBackingServiceNotFound is returned when no CSV owns the requested CRD.
+	ErrBackingServiceNotFound = errors.New("backing service not found")
+	// ErrVersionNotMatching is returned when the requested CRD is owned, but not in the requested
+	// version.
+	ErrVersionNotMatching = errors.New("Version not matching")
+)
+
+// AmbiguousKindError is returned when a bare Kind is owned in more than one group.
+type AmbiguousKindError struct {
+	Kind     string
+	CRDNames []string
+}
+
+func (e *AmbiguousKindError) Error() string {
+	return fmt.Sprintf("kind '%s' is ambiguous, owned as: %s", e.Kind, strings.Join(e.CRDNames, ", "))
+}
+
+// csvCache keeps the CSVs listed per namespace for a while, sparing a list of the CSVs on every
+// reconcile. It's shared by the OLM instances of the reconciles, and invalidated when a CSV of the
+// namespace changes. A nil cache caches nothing.
+type csvCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]csvCacheEntry
+}
+
+// csvCacheEntry are the CSVs of a namespace, along with the time they expire at.
+type csvCacheEntry struct {
+	csvs    []olmv1alpha1.ClusterServiceVersion
+	expires time.Time
+}
+
+// newCSVCache returns an empty cache keeping the CSVs for ttl.
+func newCSVCache(ttl time.Duration) *csvCache {
+	return &csvCache{ttl: ttl, now: time.Now, entries: map[string]csvCacheEntry{}}
+}
+
+// get returns the CSVs cached for the namespace, unless expired.
+func (c *csvCache) get(ns string) ([]olmv1alpha1.ClusterServiceVersion, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[ns]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.csvs, true
+}
+
+// set caches the CSVs of the namespace.
+func (c *csvCache) set(ns string, csvs []olmv1alpha1.ClusterServiceVersion) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[ns] = csvCacheEntry{csvs: csvs, expires: c.now().Add(c.ttl)}
+}
+
+// invalidate drops the CSVs cached for the namespace, so the next lookup lists them again.
+func (c *csvCache) invalidate(ns string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, ns)
+}
+
+// eventHandler returns a handler invalidating the namespace of the CSVs created, updated or
+// deleted, without enqueuing any request.
+func (c *csvCache) eventHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, _ workqueue.RateLimitingInterface) {
+			c.invalidate(e.Meta.GetNamespace())
+		},
+		UpdateFunc: func(e event.UpdateEvent, _ workqueue.RateLimitingInterface) {
+			c.invalidate(e.MetaNew.GetNamespace())
+		},
+		DeleteFunc: func(e event.DeleteEvent, _ workqueue.RateLimitingInterface) {
+			c.invalidate(e.Meta.GetNamespace())
+		},
+	}
+}
+
+// OLM represents the actions this operator needs to take upon Operator-Lifecycle-Manager resources,
+// like ClusterServiceVersions (CSV) and their CRD descriptions.
+type OLM struct {
+	client   client.Client
+	ns       string
+	globalNS string
+	cache    *csvCache
+	ctx      context.Context
+}
+
+// NewOLM returns a new OLM instance looking up CSVs in the namespace.
+func NewOLM(client client.Client, ns string) *OLM {
+	return &OLM{client: client, ns: ns, ctx: context.Background()}
+}
+
+// newCachedOLM returns a new OLM instance looking up CSVs in the namespace through the cache.
+func newCachedOLM(client client.Client, ns string, cache *csvCache) *OLM {
+	return &OLM{client: client, ns: ns, cache: cache, ctx: context.Background()}
+}
+
+// WithGlobalNamespace returns the OLM instance looking up CSVs in the namespace of the operators
+// installed cluster wide as well, e.g. "openshift-operators", as their CSVs are not always copied
+// into the other namespaces. Nothing more is looked up when empty.
+func (o *OLM) WithGlobalNamespace(ns string) *OLM {
+	o.globalNS = ns
+	return o
+}
+
+// WithContext returns the OLM instance listing CSVs with the context, e.g. the one bounding a
+// reconcile.
+func (o *OLM) WithContext(ctx context.Context) *OLM {
+	o.ctx = ctx
+	return o
+}
+
+// listCSVs returns the CSVs in the namespace, followed by the ones of the global namespace when
+// set. A CSV in both, copied by OLM, is only returned once, from the namespace.
+func (o *OLM) listCSVs() ([]olmv1alpha1.ClusterServiceVersion, error) {
+	csvs, err := o.listNamespaceCSVs(o.ns)
+	if err != nil || o.globalNS == "" || o.globalNS == o.ns {
+		return csvs, err
+	}
+	global, err := o.listNamespaceCSVs(o.globalNS)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	merged := append([]olmv1alpha1.ClusterServiceVersion{}, csvs...)
+	for _, csv := range csvs {
+		names[csv.GetName()] = true
+	}
+	for _, csv := range global {
+		if !names[csv.GetName()] {
+			merged = append(merged, csv)
+		}
+	}
+	return merged, nil
+}
+
+// listNamespaceCSVs returns the CSVs in the namespace, from the cache when they were listed
+// recently.
+func (o *OLM) listNamespaceCSVs(ns string) ([]olmv1alpha1.ClusterServiceVersion, error) {
+	if csvs, ok := o.cache.get(ns); ok {
+		return csvs, nil
+	}
+	csvl := &olmv1alpha1.ClusterServiceVersionList{}
+	err := o.client.List(o.ctx, &client.ListOptions{Namespace: ns}, csvl)
+	if err != nil {
+		return nil, err
+	}
+	o.cache.set(ns, csvl.Items)
+	return csvl.Items, nil
+}
+
+// extractOwnedCRDs flattens the CRD descriptions owned by the CSVs. The same CRD may show up more
+// than once, for instance once per version. Malformed descriptions are logged and left out, see
+// validateCRDDescription.
+func (o *OLM) extractOwnedCRDs(csvs []olmv1alpha1.ClusterServiceVersion) []olmv1alpha1.CRDDescription {
+	crdDescriptions := []olmv1alpha1.CRDDescription{}
+	for _, csv := range csvs {
+		for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
+			if err := validateCRDDescription(crd); err != nil {
+				log.Info("Skipping malformed CRD description", "CSV", csv.GetName(), "Error", err.Error())
+				continue
+			}
+			crdDescriptions = append(crdDescriptions, crd)
+		}
+	}
+	return crdDescriptions
+}
+
+// validateCRDDescription returns an error when the name of the CRD description is not
+// "plural.group", with neither empty: its resources would be looked up in a bogus group.
+func validateCRDDescription(crd olmv1alpha1.CRDDescription) error {
+	gr := schema.ParseGroupResource(crd.Name)
+	if gr.Resource == "" || gr.Group == "" {
+		return fmt.Errorf("CRD name '%s' is not \"plural.group\"", crd.Name)
+	}
+	return nil
+}
+
+// SelectCRDDescriptions returns the owned CRD descriptions of the CRD name for a single version.
+// The name is either the CRD name ("plural.group") or a bare Kind. When several versions are owned,
+// the requested version is selected; when no version is requested, or "*", the newest one is, as
+// Kubernetes orders versions: v2, v1, v1beta2, v1beta1, v1alpha1.
+// Descriptions of the same name and version are all returned, so their descriptors can be merged.
+func (o *OLM) SelectCRDDescriptions(name, version string) ([]olmv1alpha1.CRDDescription, error) {
+	csvs, err := o.listCSVs()
+	if err != nil {
+		return nil, err
+	}
+	owned := o.extractOwnedCRDs(csvs)
+
+	crdName, err := resolveCRDName(owned, name)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []olmv1alpha1.CRDDescription{}
+	for _, crd := range owned {
+		if crd.Name == crdName {
+			matching = append(matching, crd)
+		}
+	}
+	if len(matching) == 0 {
+		return nil, ErrBackingServiceNotFound
+	}
+
+	if version == "" || version == v1alpha1.LatestResourceVersion {
+		version = matching[0].Version
+		for _, crd := range matching[1:] {
+			if k8sversion.CompareKubeAwareVersionStrings(crd.Version, version) > 0 {
+				version = crd.Version
+			}
+		}
+	}
+	selected := []olmv1alpha1.CRDDescription{}
+	for _, crd := range matching {
+		if crd.Version == version {
+			selected = append(selected, crd)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, ErrVersionNotMatching
+	}
+	return selected, nil
+}
+
+// SelectCRDsByGVK returns all the owned CRD descriptions of the GroupVersionKind, e.g. the ones of
+// two versions of an operator owning the same CRD while upgrading, in the order of their CSVs,
+// callers deciding how to handle more than one.
+func (o *OLM) SelectCRDsByGVK(gvk schema.GroupVersionKind) ([]olmv1alpha1.CRDDescription, error) {
+	csvs, err := o.listCSVs()
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []olmv1alpha1.CRDDescription{}
+	for _, crd := range o.extractOwnedCRDs(csvs) {
+		if crdGVK(crd) == gvk {
+			matching = append(matching, crd)
+		}
+	}
+	if len(matching) == 0 {
+		return nil, ErrBackingServiceNotFound
+	}
+	return matching, nil
+}
+
+// SelectCRDByGVK returns the first owned CRD description of the GroupVersionKind, see
+// SelectCRDsByGVK. The ambiguity is logged as a warning when more than one matches, the others
+// being ignored.
+func (o *OLM) SelectCRDByGVK(gvk schema.GroupVersionKind) (*olmv1alpha1.CRDDescription, error) {
+	crds, err := o.SelectCRDsByGVK(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if len(crds) > 1 {
+		log.Info("Warning: several CRD descriptions match, selecting the first", "GVK", gvk.String(), "Matching", len(crds))
+	}
+	return &crds[0], nil
+}
+
+// resolveCRDName returns the CRD name ("plural.group") for the BackingSelector resource name. A
+// name without a group is taken as a bare Kind, and resolved against the Kind of the owned CRDs;
+// it's an error when the Kind is owned in more than one group. Names that can't be resolved are
+// returned as they are.
+func resolveCRDName(owned []olmv1alpha1.CRDDescription, name string) (string, error) {
+	if strings.Contains(name, ".") {
+		return name, nil
+	}
+
+	crdNames := []string{}
+	for _, crd := range owned {
+		if !strings.EqualFold(name, crd.Kind) {
+			continue
+		}
+		found := false
+		for _, n := range crdNames {
+			if n == crd.Name {
+				found = true
+			}
+		}
+		if !found {
+			crdNames = append(crdNames, crd.Name)
+		}
+	}
+
+	switch len(crdNames) {
+	case 0:
+		return name, nil
+	case 1:
+		return crdNames[0], nil
+	default:
+		return "", &AmbiguousKindError{Kind: name, CRDNames: crdNames}
+	}
+}
@@ -0,0 +1,455 @@
+package servicebindingrequest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestSelectCRDDescriptions(t *testing.T) {
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "postgresql-operator.v0.2.0",
+			Namespace: "default",
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					// the newest version isn't necessarily listed first
+					{
+						Name:    "databases.postgresql.baiju.dev",
+						Version: "v1alpha1",
+						Kind:    "Database",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{{
+							Path:         "db-credentials",
+							XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:username"},
+						}},
+					},
+					{
+						Name:    "databases.postgresql.baiju.dev",
+						Version: "v1beta1",
+						Kind:    "Database",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{{
+							Path:         "credentials",
+							XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:user"},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(olmv1alpha1.SchemeGroupVersion, csv, &olmv1alpha1.ClusterServiceVersionList{})
+	olm := NewOLM(fake.NewFakeClient([]runtime.Object{csv}...), "default")
+
+	tests := []struct {
+		name     string
+		crdName  string
+		version  string
+		wantPath string
+		wantErr  error
+	}{
+		{name: "requested version", crdName: "databases.postgresql.baiju.dev", version: "v1alpha1", wantPath: "db-credentials"},
+		{name: "other requested version", crdName: "databases.postgresql.baiju.dev", version: "v1beta1", wantPath: "credentials"},
+		{name: "newest version", crdName: "databases.postgresql.baiju.dev", wantPath: "credentials"},
+		{name: "latest version", crdName: "databases.postgresql.baiju.dev", version: "*", wantPath: "credentials"},
+		{name: "bare kind", crdName: "Database", version: "v1alpha1", wantPath: "db-credentials"},
+		{name: "version not owned", crdName: "databases.postgresql.baiju.dev", version: "v1", wantErr: ErrVersionNotMatching},
+		{name: "crd not owned", crdName: "caches.redis.example.org", wantErr: ErrBackingServiceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crds, err := olm.SelectCRDDescriptions(tt.crdName, tt.version)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("Expected error '%v', found '%v'", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("select: (%v)", err)
+			}
+			if len(crds) != 1 {
+				t.Fatalf("Expected a single CRD description, found %d", len(crds))
+			}
+			if crds[0].SpecDescriptors[0].Path != tt.wantPath {
+				t.Errorf("Selected CRD description not matching: %s", crds[0].SpecDescriptors[0].Path)
+			}
+		})
+	}
+}
+
+func TestSelectCRDDescriptionsByGroup(t *testing.T) {
+	csv := func(name, crdName, path string) *olmv1alpha1.ClusterServiceVersion {
+		return &olmv1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: olmv1alpha1.ClusterServiceVersionSpec{
+				CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+					Owned: []olmv1alpha1.CRDDescription{{
+						Name:            crdName,
+						Version:         "v1alpha1",
+						Kind:            "Database",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{{Path: path}},
+					}},
+				},
+			},
+		}
+	}
+	postgres := csv("postgresql-operator.v0.1.0", "databases.postgresql.baiju.dev", "postgres-credentials")
+	mysql := csv("mysql-operator.v0.1.0", "databases.mysql.example.org", "mysql-credentials")
+
+	s := scheme.Scheme
+	s.AddKnownTypes(olmv1alpha1.SchemeGroupVersion, postgres, &olmv1alpha1.ClusterServiceVersionList{})
+	olm := NewOLM(fake.NewFakeClient(postgres, mysql), "default")
+
+	for crdName, path := range map[string]string{
+		"databases.postgresql.baiju.dev": "postgres-credentials",
+		"databases.mysql.example.org":    "mysql-credentials",
+	} {
+		crds, err := olm.SelectCRDDescriptions(crdName, "v1alpha1")
+		if err != nil {
+			t.Fatalf("select %s: (%v)", crdName, err)
+		}
+		if len(crds) != 1 || crds[0].SpecDescriptors[0].Path != path {
+			t.Errorf("Expected the %s description, found %v", crdName, crds)
+		}
+	}
+
+	// the kind alone can't tell the groups apart
+	if _, err := olm.SelectCRDDescriptions("database", "v1alpha1"); err == nil {
+		t.Errorf("Expected the kind owned in two groups to be ambiguous")
+	}
+}
+
+func TestSelectCRDDescriptionsAllMatching(t *testing.T) {
+	// two versions of the operator own the same CRD while upgrading
+	csv := func(name, path string) *olmv1alpha1.ClusterServiceVersion {
+		return &olmv1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: olmv1alpha1.ClusterServiceVersionSpec{
+				CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+					Owned: []olmv1alpha1.CRDDescription{{
+						Name:            "databases.postgresql.baiju.dev",
+						Version:         "v1alpha1",
+						Kind:            "Database",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{{Path: path}},
+					}},
+				},
+			},
+		}
+	}
+	previous := csv("postgresql-operator.v0.1.0", "db-credentials")
+	next := csv("postgresql-operator.v0.2.0", "db-config")
+
+	s := scheme.Scheme
+	s.AddKnownTypes(olmv1alpha1.SchemeGroupVersion, previous, &olmv1alpha1.ClusterServiceVersionList{})
+	olm := NewOLM(fake.NewFakeClient(previous, next), "default")
+
+	crds, err := olm.SelectCRDDescriptions("databases.postgresql.baiju.dev", "v1alpha1")
+	if err != nil {
+		t.Fatalf("select: (%v)", err)
+	}
+	paths := map[string]bool{}
+	for _, crd := range crds {
+		paths[crd.SpecDescriptors[0].Path] = true
+	}
+	if len(crds) != 2 || !paths["db-credentials"] || !paths["db-config"] {
+		t.Errorf("Expected both matching descriptions, found %v", crds)
+	}
+}
+
+func TestSelectCRDsByGVK(t *testing.T) {
+	// two versions of the operator own the same CRD while upgrading, a third one another kind
+	csv := func(name, kind, path string) *olmv1alpha1.ClusterServiceVersion {
+		return &olmv1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: olmv1alpha1.ClusterServiceVersionSpec{
+				CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+					Owned: []olmv1alpha1.CRDDescription{{
+						Name:            strings.ToLower(kind) + "s.postgresql.baiju.dev",
+						Version:         "v1alpha1",
+						Kind:            kind,
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{{Path: path}},
+					}},
+				},
+			},
+		}
+	}
+	previous := csv("postgresql-operator.v0.1.0", "Database", "db-credentials")
+	next := csv("postgresql-operator.v0.2.0", "Database", "db-config")
+	backup := csv("postgresql-backup-operator.v0.1.0", "Backup", "backup-credentials")
+
+	s := scheme.Scheme
+	s.AddKnownTypes(olmv1alpha1.SchemeGroupVersion, previous, &olmv1alpha1.ClusterServiceVersionList{})
+	olm := NewOLM(fake.NewFakeClient(previous, next, backup), "default")
+	gvk := schema.GroupVersionKind{Group: "postgresql.baiju.dev", Version: "v1alpha1", Kind: "Database"}
+
+	crds, err := olm.SelectCRDsByGVK(gvk)
+	if err != nil {
+		t.Fatalf("select: (%v)", err)
+	}
+	paths := map[string]bool{}
+	for _, crd := range crds {
+		paths[crd.SpecDescriptors[0].Path] = true
+	}
+	if len(crds) != 2 || !paths["db-credentials"] || !paths["db-config"] {
+		t.Errorf("Expected both matching descriptions, found %v", crds)
+	}
+
+	// the first one is selected, the others ignored
+	crd, err := olm.SelectCRDByGVK(gvk)
+	if err != nil {
+		t.Fatalf("select: (%v)", err)
+	}
+	if !reflect.DeepEqual(*crd, crds[0]) {
+		t.Errorf("Expected the first matching description %v, found %v", crds[0], *crd)
+	}
+
+	gvk.Version = "v1beta1"
+	if _, err = olm.SelectCRDByGVK(gvk); err != ErrBackingServiceNotFound {
+		t.Errorf("Expected ErrBackingServiceNotFound for an unowned version, got (%v)", err)
+	}
+}
+
+func TestResolveCRDName(t *testing.T) {
+	csvs := []olmv1alpha1.ClusterServiceVersion{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0"},
+			Spec: olmv1alpha1.ClusterServiceVersionSpec{
+				CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+					Owned: []olmv1alpha1.CRDDescription{
+						{Name: "databases.postgresql.baiju.dev", Version: "v1alpha1", Kind: "Database"},
+						{Name: "databases.postgresql.baiju.dev", Version: "v1beta1", Kind: "Database"},
+						{Name: "backups.postgresql.baiju.dev", Version: "v1alpha1", Kind: "Backup"},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysql-operator.v0.1.0"},
+			Spec: olmv1alpha1.ClusterServiceVersionSpec{
+				CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+					Owned: []olmv1alpha1.CRDDescription{
+						{Name: "backups.mysql.example.org", Version: "v1alpha1", Kind: "Backup"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "crd name", input: "databases.postgresql.baiju.dev", want: "databases.postgresql.baiju.dev"},
+		{name: "bare kind", input: "Database", want: "databases.postgresql.baiju.dev"},
+		{name: "bare kind ignoring case", input: "database", want: "databases.postgresql.baiju.dev"},
+		{name: "unknown kind", input: "Cache", want: "Cache"},
+		{name: "ambiguous kind", input: "Backup", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveCRDName(NewOLM(nil, "").extractOwnedCRDs(csvs), tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected error resolving '%s', found '%s'", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve: (%v)", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolved name not matching: %s", got)
+			}
+		})
+	}
+}
+
+func TestCSVCache(t *testing.T) {
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "postgresql-operator.v0.1.0",
+			Namespace: "default",
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{Name: "databases.postgresql.baiju.dev", Version: "v1alpha1", Kind: "Database"},
+				},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(olmv1alpha1.SchemeGroupVersion, csv, &olmv1alpha1.ClusterServiceVersionList{})
+	cl := &countingClient{Client: fake.NewFakeClient(csv)}
+
+	now := time.Now()
+	cache := newCSVCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	selectCRD := func(expectedLists int) {
+		t.Helper()
+		// each reconcile has its own OLM instance, sharing the cache
+		olm := newCachedOLM(cl, "default", cache)
+		if _, err := olm.SelectCRDDescriptions("databases.postgresql.baiju.dev", ""); err != nil {
+			t.Fatalf("select: (%v)", err)
+		}
+		if cl.lists != expectedLists {
+			t.Errorf("Expected %d lists of the CSVs, found %d", expectedLists, cl.lists)
+		}
+	}
+
+	t.Run("consecutive lookups", func(t *testing.T) {
+		selectCRD(1)
+		selectCRD(1)
+	})
+
+	t.Run("invalidated by a CSV event", func(t *testing.T) {
+		cache.eventHandler().Update(event.UpdateEvent{MetaOld: csv, ObjectOld: csv, MetaNew: csv, ObjectNew: csv}, nil)
+		selectCRD(2)
+		selectCRD(2)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		selectCRD(3)
+	})
+
+	t.Run("without cache", func(t *testing.T) {
+		olm := NewOLM(cl, "default")
+		for i := 0; i < 2; i++ {
+			if _, err := olm.SelectCRDDescriptions("databases.postgresql.baiju.dev", ""); err != nil {
+				t.Fatalf("select: (%v)", err)
+			}
+		}
+		if cl.lists != 5 {
+			t.Errorf("Expected every lookup to list the CSVs, found %d lists", cl.lists)
+		}
+	})
+}
+
+func TestGlobalNamespaceCSVs(t *testing.T) {
+	csv := func(ns, name, path string) *olmv1alpha1.ClusterServiceVersion {
+		return &olmv1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec: olmv1alpha1.ClusterServiceVersionSpec{
+				CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+					Owned: []olmv1alpha1.CRDDescription{{
+						Name:            "databases.postgresql.baiju.dev",
+						Version:         "v1alpha1",
+						Kind:            "Database",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{{Path: path}},
+					}},
+				},
+			},
+		}
+	}
+	// the postgresql CSV is copied, the redis one only lives in the global namespace
+	local := csv("default", "postgresql-operator.v0.1.0", "local-credentials")
+	copied := csv("openshift-operators", "postgresql-operator.v0.1.0", "global-credentials")
+	global := csv("openshift-operators", "redis-operator.v0.1.0", "redis-credentials")
+	global.Spec.CustomResourceDefinitions.Owned[0].Name = "caches.redis.example.org"
+	global.Spec.CustomResourceDefinitions.Owned[0].Kind = "Cache"
+
+	s := scheme.Scheme
+	s.AddKnownTypes(olmv1alpha1.SchemeGroupVersion, local, &olmv1alpha1.ClusterServiceVersionList{})
+	cl := fake.NewFakeClient(local, copied, global)
+
+	tests := []struct {
+		name     string
+		globalNS string
+		crdName  string
+		wantPath string
+		wantErr  error
+	}{
+		{name: "namespace only", crdName: "databases.postgresql.baiju.dev", wantPath: "local-credentials"},
+		{name: "not in the namespace", crdName: "caches.redis.example.org", wantErr: ErrBackingServiceNotFound},
+		{name: "copied into the namespace", globalNS: "openshift-operators", crdName: "databases.postgresql.baiju.dev", wantPath: "local-credentials"},
+		{name: "global namespace", globalNS: "openshift-operators", crdName: "caches.redis.example.org", wantPath: "redis-credentials"},
+		{name: "global namespace by kind", globalNS: "openshift-operators", crdName: "Cache", wantPath: "redis-credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			olm := NewOLM(cl, "default").WithGlobalNamespace(tt.globalNS)
+			crds, err := olm.SelectCRDDescriptions(tt.crdName, "")
+			if err != tt.wantErr {
+				t.Fatalf("Expected error '%v', found '%v'", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(crds) != 1 || crds[0].SpecDescriptors[0].Path != tt.wantPath {
+				t.Errorf("Expected a single CRD description with path '%s', found %+v", tt.wantPath, crds)
+			}
+		})
+	}
+
+	csvs, err := NewOLM(cl, "default").WithGlobalNamespace("openshift-operators").listCSVs()
+	if err != nil {
+		t.Fatalf("list: (%v)", err)
+	}
+	names := []string{}
+	for _, c := range csvs {
+		names = append(names, c.GetNamespace()+"/"+c.GetName())
+	}
+	expected := []string{"default/postgresql-operator.v0.1.0", "openshift-operators/redis-operator.v0.1.0"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected the CSVs merged without duplicates %v, found %v", expected, names)
+	}
+}
+
+func TestExtractOwnedCRDsMalformed(t *testing.T) {
+	csvs := []olmv1alpha1.ClusterServiceVersion{{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: "default"},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{Name: "databases", Version: "v1alpha1", Kind: "Database"},
+					{Name: "databases.", Version: "v1alpha1", Kind: "Database"},
+					{Name: ".postgresql.baiju.dev", Version: "v1alpha1", Kind: "Database"},
+					{Name: "", Version: "v1alpha1", Kind: "Database"},
+					{Name: "databases.postgresql.baiju.dev", Version: "v1alpha1", Kind: "Database"},
+				},
+			},
+		},
+	}}
+
+	owned := NewOLM(nil, "default").extractOwnedCRDs(csvs)
+	if len(owned) != 1 || owned[0].Name != "databases.postgresql.baiju.dev" {
+		t.Fatalf("Expected only the well formed CRD description, found %+v", owned)
+	}
+	if gvk := crdGVK(owned[0]); gvk.Group != "postgresql.baiju.dev" {
+		t.Errorf("Group not matching: %s", gvk)
+	}
+
+	// the malformed ones can't be selected, by name or by kind
+	s := scheme.Scheme
+	s.AddKnownTypes(olmv1alpha1.SchemeGroupVersion, &csvs[0], &olmv1alpha1.ClusterServiceVersionList{})
+	olm := NewOLM(fake.NewFakeClient(&csvs[0]), "default")
+	if _, err := olm.SelectCRDDescriptions("databases.", ""); err != ErrBackingServiceNotFound {
+		t.Errorf("Expected a malformed name not to be found, found '%v'", err)
+	}
+	crds, err := olm.SelectCRDDescriptions("Database", "")
+	if err != nil {
+		t.Fatalf("select: (%v)", err)
+	}
+	if len(crds) != 1 || crds[0].Name != "databases.postgresql.baiju.dev" {
+		t.Errorf("Expected the kind resolved to the well formed CRD, found %+v", crds)
+	}
+}
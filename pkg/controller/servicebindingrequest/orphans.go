@@ -0,0 +1,271 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// orphanSweepInterval is how often the applications left bound by deleted ServiceBindingRequests
+// are looked for, see orphanSweeper.
+const orphanSweepInterval = 10 * time.Minute
+
+// UnbindOrphans cleans up, in the namespace or in all of them when empty, after the
+// ServiceBindingRequests deleted without their finalizer running, e.g. removed by hand during
+// development or while the operator was down. They are found by the applications they leave bound,
+// marked with their "namespace/name", see boundEnvsAnnotation, and by the copies mirrored for them
+// into application namespaces, see mirroredForAnnotation: the applications are unbound as the
+// finalizer would, and the copies released, see releaseMirrored, the copies kept for no other
+// ServiceBindingRequest being deleted. The secret of the flattened keys is garbage collected along
+// with them. The applications searched are the supported kinds and the generic workloads of the
+// kinds the existing ServiceBindingRequests bind, see orphanListKinds. It returns the "namespace/name" of the ServiceBindingRequests cleaned up after,
+// sorted.
+func UnbindOrphans(ctx context.Context, c client.Client, scheme *runtime.Scheme, ns string) ([]string, error) {
+	lister := NewBinder(c, scheme, &v1alpha1.ServiceBindingRequest{}, nil).WithContext(ctx)
+	deleted := map[string]bool{}
+	orphans := []string{}
+	kinds, err := orphanListKinds(ctx, c, ns)
+	if err != nil {
+		return orphans, err
+	}
+	for _, gvk := range kinds {
+		list, err := lister.newObject(gvk)
+		if err != nil {
+			return orphans, err
+		}
+		err = c.List(ctx, &client.ListOptions{Namespace: ns}, list)
+		// the kinds not installed in the cluster, e.g. Knative Services, have nothing bound
+		if meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			continue
+		}
+		if err != nil {
+			return orphans, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return orphans, err
+		}
+
+		for _, obj := range items {
+			refs, err := boundRefs(scheme, obj)
+			if err != nil {
+				return orphans, err
+			}
+			for _, ref := range refs {
+				gone, checked := deleted[ref]
+				if !checked {
+					if gone, err = bindingDeleted(ctx, c, ref); err != nil {
+						return orphans, err
+					}
+					deleted[ref] = gone
+					if gone {
+						orphans = append(orphans, ref)
+					}
+				}
+				if !gone {
+					continue
+				}
+
+				// the spec is gone along with the ServiceBindingRequest, the binding is found by
+				// its name
+				if _, err = NewBinder(c, scheme, orphanedBinding(ref), nil).WithContext(ctx).unbind(obj); err != nil {
+					return orphans, err
+				}
+				key, err := client.ObjectKeyFromObject(obj)
+				if err != nil {
+					return orphans, err
+				}
+				log.Info("Unbound application of a deleted ServiceBindingRequest", "ServiceBindingRequest", ref, "Application", key)
+			}
+		}
+	}
+
+	// the copies are left behind with no application bound as well, e.g. unbound by hand
+	refs, err := mirroredRefs(ctx, c, ns)
+	if err != nil {
+		return orphans, err
+	}
+	for _, ref := range refs {
+		if _, checked := deleted[ref]; checked {
+			continue
+		}
+		gone, err := bindingDeleted(ctx, c, ref)
+		if err != nil {
+			return orphans, err
+		}
+		deleted[ref] = gone
+		if gone {
+			orphans = append(orphans, ref)
+		}
+	}
+
+	for _, ref := range orphans {
+		if err := NewBinder(c, scheme, orphanedBinding(ref), nil).WithContext(ctx).releaseMirrored(); err != nil {
+			return orphans, err
+		}
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// orphanListKinds returns the list types of the applications to search in the namespace, or in
+// all of them when empty, for the ones left bound: the supported kinds, along with the kinds of the
+// generic workloads the ServiceBindingRequests of the namespace bind, which can't be enumerated
+// otherwise. The generic workloads of a kind no existing ServiceBindingRequest binds anymore are
+// not found.
+func orphanListKinds(ctx context.Context, c client.Client, ns string) ([]schema.GroupVersionKind, error) {
+	kinds := []schema.GroupVersionKind{}
+	seen := map[schema.GroupVersionKind]bool{}
+	add := func(gvk schema.GroupVersionKind) {
+		if !seen[gvk] {
+			seen[gvk] = true
+			kinds = append(kinds, gvk)
+		}
+	}
+	for _, k := range applicationKinds {
+		add(k.list)
+	}
+
+	sbrl := &v1alpha1.ServiceBindingRequestList{}
+	if err := c.List(ctx, &client.ListOptions{Namespace: ns}, sbrl); err != nil {
+		return nil, err
+	}
+	for i := range sbrl.Items {
+		sbr := &sbrl.Items[i]
+		selector := sbr.Spec.ApplicationSelector
+		if selector.Version == "" {
+			continue
+		}
+		if selector.ResourceKind != "" {
+			add(applicationGVK(sbr, selector.ResourceKind+"List"))
+		}
+		for _, ref := range selector.Refs {
+			if ref.Kind != "" {
+				add(applicationGVK(sbr, ref.Kind+"List"))
+			}
+		}
+	}
+	return kinds, nil
+}
+
+// boundRefs returns the "namespace/name" of the ServiceBindingRequests the application object is
+// bound by, sorted.
+func boundRefs(scheme *runtime.Scheme, obj runtime.Object) ([]string, error) {
+	refs := []string{}
+	// the change only reads the annotations, the object is left as it is
+	_, err := changePodSpec(&v1alpha1.ServiceBindingRequest{}, scheme, obj, func(annotations map[string]string, _ *corev1.PodSpec) error {
+		bound, err := boundEnvs(annotations)
+		if err != nil {
+			return err
+		}
+		for ref := range bound {
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	sort.Strings(refs)
+	return refs, err
+}
+
+// mirroredRefs returns the "namespace/name" of the ServiceBindingRequests the secrets and config
+// maps mirrored into the namespace, or into all of them when empty, are kept for, sorted.
+func mirroredRefs(ctx context.Context, c client.Client, ns string) ([]string, error) {
+	mirrored, err := labels.NewRequirement(mirroredFromLabel, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	lo := &client.ListOptions{Namespace: ns, LabelSelector: labels.NewSelector().Add(*mirrored)}
+	copies := []runtime.Object{}
+	sl := &corev1.SecretList{}
+	if err = c.List(ctx, lo, sl); err != nil {
+		return nil, err
+	}
+	for i := range sl.Items {
+		copies = append(copies, &sl.Items[i])
+	}
+	cml := &corev1.ConfigMapList{}
+	if err = c.List(ctx, lo, cml); err != nil {
+		return nil, err
+	}
+	for i := range cml.Items {
+		copies = append(copies, &cml.Items[i])
+	}
+
+	refs := []string{}
+	for _, obj := range copies {
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		from := objMeta.GetLabels()[mirroredFromLabel]
+		for _, name := range parseList(objMeta.GetAnnotations()[mirroredForAnnotation]) {
+			refs = append(refs, from+"/"+name)
+		}
+	}
+	return sortedNames(refs), nil
+}
+
+// bindingDeleted returns whether the ServiceBindingRequest of the "namespace/name" no longer
+// exists.
+func bindingDeleted(ctx context.Context, c client.Client, ref string) (bool, error) {
+	err := c.Get(ctx, orphanedKey(ref), &v1alpha1.ServiceBindingRequest{})
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// orphanedKey returns the key of the "namespace/name" of a ServiceBindingRequest.
+func orphanedKey(ref string) types.NamespacedName {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) < 2 {
+		return types.NamespacedName{Name: ref}
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+}
+
+// orphanedBinding returns a ServiceBindingRequest of the "namespace/name" to unbind with, the
+// binding being found by its name only.
+func orphanedBinding(ref string) *v1alpha1.ServiceBindingRequest {
+	key := orphanedKey(ref)
+	return &v1alpha1.ServiceBindingRequest{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+}
+
+// orphanSweeper runs UnbindOrphans in the watched namespaces when the operator starts, its cache
+// synced, and every orphanSweepInterval after, started and stopped with the manager.
+type orphanSweeper struct {
+	client   client.Client
+	scheme   *runtime.Scheme
+	interval time.Duration
+}
+
+// Start sweeps until stop is closed. A failed sweep is logged, the next one sweeping again.
+func (s *orphanSweeper) Start(stop <-chan struct{}) error {
+	wait.Until(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+		defer cancel()
+		orphans, err := UnbindOrphans(ctx, s.client, s.scheme, "")
+		if err != nil {
+			log.Error(err, "Failed to unbind the applications of deleted ServiceBindingRequests")
+			return
+		}
+		if len(orphans) > 0 {
+			log.Info("Cleaned up after deleted ServiceBindingRequests", "ServiceBindingRequests", orphans)
+		}
+	}, s.interval, stop)
+	return nil
+}
@@ -0,0 +1,247 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUnbindOrphans(t *testing.T) {
+	secretEnv := func(name string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+					Key:                  "password",
+				},
+			},
+		}
+	}
+	// the variables of the application may share the prefix of the binding
+	appEnvs := []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}, {Name: "ORDERS_HOST", Value: "orders-db"}}
+	deployment := func(ns, name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "app", Env: append([]corev1.EnvVar{}, appEnvs...)}},
+					},
+				},
+			},
+		}
+	}
+	orders := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default", Finalizers: []string{finalizer}},
+		Spec:       v1alpha1.ServiceBindingRequestSpec{BindAsFiles: true},
+	}
+	payments := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "default", Finalizers: []string{finalizer}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, orders, &v1alpha1.ServiceBindingRequestList{})
+	cl := &selectingClient{Client: fake.NewFakeClient(
+		orders, payments, secret,
+		deployment("default", "orders-app"), deployment("jobs", "orders-worker"), deployment("default", "payments-app"),
+	)}
+
+	bind := func(sbr *v1alpha1.ServiceBindingRequest, ns, name string, env corev1.EnvVar) {
+		dp := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: name}, dp); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		if _, err := NewBinder(cl, s, sbr, nil).BindOne(dp, []corev1.EnvVar{env}, map[string]string{env.Name: "password"}); err != nil {
+			t.Fatalf("bind: (%v)", err)
+		}
+	}
+	bind(orders, "default", "orders-app", secretEnv("ORDERS_PASSWORD"))
+	bind(orders, "jobs", "orders-worker", secretEnv("ORDERS_PASSWORD"))
+	bind(payments, "default", "orders-app", secretEnv("PAYMENTS_PASSWORD"))
+	bind(payments, "default", "payments-app", secretEnv("PAYMENTS_PASSWORD"))
+
+	// "orders" is deleted with no finalizer running, as when removed by hand; the fake client
+	// deletes right away
+	if err := cl.Delete(context.TODO(), orders); err != nil {
+		t.Fatalf("delete sbr: (%v)", err)
+	}
+
+	orphans, err := UnbindOrphans(context.TODO(), cl, s, "")
+	if err != nil {
+		t.Fatalf("unbind orphans: (%v)", err)
+	}
+	if !reflect.DeepEqual(orphans, []string{"default/orders"}) {
+		t.Errorf("Expected 'default/orders' cleaned up after, got %v", orphans)
+	}
+
+	get := func(ns, name string) *appsv1.Deployment {
+		dp := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: name}, dp); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		return dp
+	}
+	worker := get("jobs", "orders-worker")
+	if env := worker.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(env, appEnvs) {
+		t.Errorf("Expected only the variables of the application left, found %v", env)
+	}
+	if volumes := worker.Spec.Template.Spec.Volumes; len(volumes) != 0 {
+		t.Errorf("Expected the binding volume removed, found %v", volumes)
+	}
+	if annotations := worker.Spec.Template.GetAnnotations(); len(annotations) != 0 {
+		t.Errorf("Expected the bound envs annotation removed, found %v", annotations)
+	}
+	if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "jobs", Name: "db-credentials"}, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected the mirrored secret deleted, got (%v)", err)
+	}
+
+	// the bindings of the existing ServiceBindingRequest are left alone, in the applications shared
+	// with the deleted one as well
+	app := get("default", "orders-app")
+	if volumes := app.Spec.Template.Spec.Volumes; len(volumes) != 0 {
+		t.Errorf("Expected the binding volume removed, found %v", volumes)
+	}
+	bound, err := boundEnvs(app.Spec.Template.GetAnnotations())
+	if err != nil {
+		t.Fatalf("bound envs: (%v)", err)
+	}
+	if expected := map[string][]string{"default/payments": {"PAYMENTS_PASSWORD"}}; !reflect.DeepEqual(bound, expected) {
+		t.Errorf("Expected %v bound, found %v", expected, bound)
+	}
+	if env := get("default", "payments-app").Spec.Template.Spec.Containers[0].Env; len(env) != 3 {
+		t.Errorf("Expected the bound variable kept, found %v", env)
+	}
+
+	// nothing is left to clean up
+	if orphans, err = UnbindOrphans(context.TODO(), cl, s, ""); err != nil || len(orphans) != 0 {
+		t.Errorf("Expected no orphans left, got %v (%v)", orphans, err)
+	}
+}
+
+func TestUnbindOrphansMirrored(t *testing.T) {
+	payments := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "default", Finalizers: []string{finalizer}},
+	}
+	mirrored := func(name, sbrs string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "jobs",
+				Labels:      map[string]string{mirroredFromLabel: "default"},
+				Annotations: map[string]string{mirroredForAnnotation: sbrs},
+			},
+			Data: map[string][]byte{"password": []byte("secret")},
+		}
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, payments, &v1alpha1.ServiceBindingRequestList{})
+	// the copies were mirrored for "orders", deleted along with the applications it bound
+	cl := &selectingClient{Client: fake.NewFakeClient(
+		payments, mirrored("db-credentials", "orders"), mirrored("cache-credentials", "orders,payments"),
+	)}
+
+	orphans, err := UnbindOrphans(context.TODO(), cl, s, "")
+	if err != nil {
+		t.Fatalf("unbind orphans: (%v)", err)
+	}
+	if !reflect.DeepEqual(orphans, []string{"default/orders"}) {
+		t.Errorf("Expected 'default/orders' cleaned up after, got %v", orphans)
+	}
+	if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "jobs", Name: "db-credentials"}, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected the orphaned copy deleted, got (%v)", err)
+	}
+	shared := &corev1.Secret{}
+	if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "jobs", Name: "cache-credentials"}, shared); err != nil {
+		t.Fatalf("Expected the shared copy kept, got (%v)", err)
+	}
+	if sbrs := shared.GetAnnotations()[mirroredForAnnotation]; sbrs != "payments" {
+		t.Errorf("Expected the shared copy kept for 'payments' only, found %q", sbrs)
+	}
+}
+
+func TestUnbindOrphansUnstructured(t *testing.T) {
+	workloadGV := schema.GroupVersion{Group: "example.org", Version: "v1"}
+	app := func(gvk schema.GroupVersionKind, name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": name, "namespace": "default"},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": []interface{}{map[string]interface{}{"name": "app"}},
+						},
+					},
+				},
+			},
+		}
+		u.SetGroupVersionKind(gvk)
+		return u
+	}
+	generic := v1alpha1.ApplicationSelector{Group: workloadGV.Group, Version: workloadGV.Version, ResourceKind: "Workload"}
+	orders := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default", Finalizers: []string{finalizer}},
+		Spec:       v1alpha1.ServiceBindingRequestSpec{ApplicationSelector: generic},
+	}
+	// the kind of the generic workloads is known by the ServiceBindingRequests still binding it
+	payments := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "default", Finalizers: []string{finalizer}},
+		Spec:       v1alpha1.ServiceBindingRequestSpec{ApplicationSelector: generic},
+	}
+
+	// the fake client needs the Knative and workload types to be known
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, orders, &v1alpha1.ServiceBindingRequestList{})
+	s.AddKnownTypeWithName(knativeServingGroupVersion.WithKind("Service"), &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(knativeServingGroupVersion.WithKind("ServiceList"), &knativeServiceList{})
+	s.AddKnownTypeWithName(workloadGV.WithKind("Workload"), &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(workloadGV.WithKind("WorkloadList"), &workloadList{})
+	ksvc := app(knativeServingGroupVersion.WithKind("Service"), "orders-app")
+	workload := app(workloadGV.WithKind("Workload"), "orders-worker")
+	cl := &selectingClient{Client: fake.NewFakeClient(orders, payments, ksvc, workload)}
+
+	envs := []corev1.EnvVar{{Name: "ORDERS_HOST", Value: "orders-db"}}
+	for _, obj := range []*unstructured.Unstructured{ksvc, workload} {
+		if _, err := NewBinder(cl, s, orders, nil).BindOne(obj, envs, map[string]string{"ORDERS_HOST": "host"}); err != nil {
+			t.Fatalf("bind: (%v)", err)
+		}
+	}
+	if err := cl.Delete(context.TODO(), orders); err != nil {
+		t.Fatalf("delete sbr: (%v)", err)
+	}
+
+	orphans, err := UnbindOrphans(context.TODO(), cl, s, "")
+	if err != nil {
+		t.Fatalf("unbind orphans: (%v)", err)
+	}
+	if !reflect.DeepEqual(orphans, []string{"default/orders"}) {
+		t.Errorf("Expected 'default/orders' cleaned up after, got %v", orphans)
+	}
+	for _, obj := range []*unstructured.Unstructured{ksvc, workload} {
+		out := &unstructured.Unstructured{}
+		out.SetGroupVersionKind(obj.GroupVersionKind())
+		if err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: obj.GetName()}, out); err != nil {
+			t.Fatalf("get %s: (%v)", obj.GetKind(), err)
+		}
+		containers, _, _ := unstructured.NestedSlice(out.Object, "spec", "template", "spec", "containers")
+		if env, found, _ := unstructured.NestedSlice(containers[0].(map[string]interface{}), "env"); found && len(env) > 0 {
+			t.Errorf("Expected the %s unbound, found %v", obj.GetKind(), env)
+		}
+	}
+}
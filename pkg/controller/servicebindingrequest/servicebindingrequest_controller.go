@@ -2,19 +2,32 @@ package servicebindingrequest
 
 import (
 	"context"
-	errs "errors"
+	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	osappsv1 "github.com/openshift/api/apps/v1"
 	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -25,15 +38,71 @@ import (
 
 var log = logf.Log.WithName("controller_servicebindingrequest")
 
+// forceRebindAnnotation is set on a ServiceBindingRequest, usually with a timestamp, to force the
+// applications to be bound again without changing the spec. It's removed once bound.
+const forceRebindAnnotation = "servicebinding.dev/force-rebind"
+
+// finalizer is set on ServiceBindingRequests to unbind the applications before they're deleted.
+const finalizer = "finalizer.servicebindingrequest.apps.openshift.io"
+
+// consumedKeysAnnotation is set on an application object, by the application or its tooling, to
+// report the comma separated binding keys (environment variable names) it actually uses. The keys
+// are surfaced in the ServiceBindingRequest status.
+const consumedKeysAnnotation = "servicebinding.dev/consumed-keys"
+
+// allowedAppKindsEnvVar names the operator environment variable restricting the application kinds
+// that can be bound, a comma separated list like "Deployment,StatefulSet". All supported kinds
+// are allowed when it's empty.
+const allowedAppKindsEnvVar = "ALLOWED_APP_KINDS"
+
+// allowedBackingNamespacesEnvVar names the operator environment variable listing the namespaces
+// backing services can be read from, besides the one of the ServiceBindingRequest, a comma
+// separated list like "data,shared". No other namespace is allowed when it's empty.
+const allowedBackingNamespacesEnvVar = "ALLOWED_BACKING_NAMESPACES"
+
+// globalOperatorsNamespaceEnvVar names the environment variable with the namespace of the operators
+// installed cluster wide, e.g. "openshift-operators", where CSVs are looked up as well.
+const globalOperatorsNamespaceEnvVar = "GLOBAL_OPERATORS_NAMESPACE"
+
+// reconcileTimeout bounds the API calls of a reconcile, and of mapping an application to its
+// ServiceBindingRequests, so a hung call doesn't hold a worker forever. The request is retried
+// once it's exceeded.
+const reconcileTimeout = 2 * time.Minute
+
+// notReadyMinDelay and notReadyMaxDelay bound the delay before a ServiceBindingRequest waiting on
+// its backing service is reconciled again, see notReadyResult.
+const (
+	notReadyMinDelay = 5 * time.Second
+	notReadyMaxDelay = 5 * time.Minute
+)
+
 // Add creates a new ServiceBindingRequest Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
 	return add(mgr, newReconciler(mgr))
 }
 
+// AddWithCollectors adds the ServiceBindingRequest Controller as Add does, the binding descriptors
+// of the backing services being collected by the collectors, e.g. DefaultCollectors along with
+// one reading a vault, instead of the default ones.
+func AddWithCollectors(mgr manager.Manager, collectors ...BindingCollector) error {
+	r := newReconciler(mgr).(*ReconcileServiceBindingRequest)
+	return add(mgr, r.WithCollectors(collectors...))
+}
+
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileServiceBindingRequest{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileServiceBindingRequest{
+		client:                   mgr.GetClient(),
+		scheme:                   mgr.GetScheme(),
+		recorder:                 mgr.GetRecorder("servicebindingrequest-controller"),
+		allowedKinds:             parseAllowedKinds(os.Getenv(allowedAppKindsEnvVar)),
+		allowedBackingNamespaces: parseList(os.Getenv(allowedBackingNamespacesEnvVar)),
+		globalOperatorsNamespace: os.Getenv(globalOperatorsNamespaceEnvVar),
+		csvs:                     newCSVCache(csvCacheTTL),
+		sources:                  newSourceIndex(),
+		health:                   sharedHealth(),
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -44,20 +113,76 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Unbind the applications left bound by the ServiceBindingRequests deleted without their
+	// finalizer running
+	if sbr, ok := r.(*ReconcileServiceBindingRequest); ok {
+		err = mgr.Add(&orphanSweeper{client: sbr.client, scheme: sbr.scheme, interval: orphanSweepInterval})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Watch for changes to primary resource ServiceBindingRequest
 	err = c.Watch(&source.Kind{Type: &v1alpha1.ServiceBindingRequest{}}, &handler.EnqueueRequestForObject{})
 	if err != nil {
 		return err
 	}
 
-	// TODO(user): Modify this to be the types you create that are owned by the primary resource
-	// Watch for changes to secondary resource Pods and requeue the owner ServiceBindingRequest
-	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
-		IsController: true,
-		OwnerType:    &v1alpha1.ServiceBindingRequest{},
-	})
-	if err != nil {
-		return err
+	// Watch for changes to the applications, e.g. a Deployment recreated by a pipeline, and requeue
+	// the ServiceBindingRequests selecting them
+	if sbr, ok := r.(*ReconcileServiceBindingRequest); ok {
+		apps := []runtime.Object{
+			&appsv1.Deployment{}, &appsv1.StatefulSet{}, &appsv1.DaemonSet{}, &batchv1.Job{}, &batchv1beta1.CronJob{},
+			&appsv1.ReplicaSet{}, &corev1.ReplicationController{},
+		}
+		for _, obj := range apps {
+			if err = c.Watch(&source.Kind{Type: obj}, sbr.applicationHandler()); err != nil {
+				return err
+			}
+		}
+		// DeploymentConfigs only exist in OpenShift
+		err = c.Watch(&source.Kind{Type: &osappsv1.DeploymentConfig{}}, sbr.applicationHandler())
+		if err != nil {
+			log.Info("DeploymentConfigs not watched", "Error", err)
+		}
+	}
+
+	// Watch for changes to CSVs, only to drop the CSVs cached for their namespace. CSVs only exist
+	// along with OLM, the cached CSVs then expire after csvCacheTTL only
+	if sbr, ok := r.(*ReconcileServiceBindingRequest); ok && sbr.csvs != nil {
+		err = c.Watch(&source.Kind{Type: &olmv1alpha1.ClusterServiceVersion{}}, sbr.csvs.eventHandler())
+		if err != nil {
+			log.Info("CSVs not watched, cached CSVs expire after their TTL", "Error", err)
+		}
+	}
+
+	// Watch for changes to the secrets and config maps of the backing services, e.g. rotated
+	// credentials, and to the CSVs describing them, and requeue the ServiceBindingRequests reading
+	// them. The watches cache every secret and config map of the watched namespaces in memory, and
+	// need list and watch on them, see deploy/role.yaml; the events of the ones no
+	// ServiceBindingRequest reads are dropped by the predicates
+	if sbr, ok := r.(*ReconcileServiceBindingRequest); ok && sbr.sources != nil {
+		err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, sbr.sources.eventHandler("Secret"), sbr.sources.predicate("Secret"))
+		if err != nil {
+			return err
+		}
+		err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, sbr.sources.eventHandler("ConfigMap"), sbr.sources.predicate("ConfigMap"))
+		if err != nil {
+			return err
+		}
+		// CSVs only exist along with OLM, as for the cache above
+		err = c.Watch(&source.Kind{Type: &olmv1alpha1.ClusterServiceVersion{}}, sbr.sources.csvHandler())
+		if err != nil {
+			log.Info("CSVs not watched for changed descriptors", "Error", err)
+		}
+
+		// Watch for changes to the backing resources referenced, their kinds being watched as
+		// they're found, given the role of hack/backing-role.sh
+		sbr.backingKinds = newKindWatcher(func(gvk schema.GroupVersionKind) error {
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(gvk)
+			return c.Watch(&source.Kind{Type: u}, sbr.sources.eventHandler(gvk.GroupKind().String()))
+		})
 	}
 
 	return nil
@@ -72,197 +197,1150 @@ type ReconcileServiceBindingRequest struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// recorder records the binding milestones and failures as events of the ServiceBindingRequest.
+	recorder record.EventRecorder
+	// allowedKinds are the lower case application kinds that can be bound, all when empty.
+	allowedKinds []string
+	// allowedBackingNamespaces are the other namespaces backing services can be read from, none
+	// when empty.
+	allowedBackingNamespaces []string
+	// globalOperatorsNamespace is where the CSVs of the operators installed cluster wide are looked
+	// up, besides the namespace of the backing service, none when empty.
+	globalOperatorsNamespace string
+	// csvs caches the CSVs looked up by namespace, the CSVs are listed on every reconcile when nil.
+	csvs *csvCache
+	// sources maps the objects the binding data is read from back to the ServiceBindingRequests,
+	// nothing is indexed when nil.
+	sources *sourceIndex
+	// backingKinds starts the watches of the kinds of the backing resources referenced, none are
+	// watched when nil.
+	backingKinds *kindWatcher
+	// collectors collect the binding descriptors of the backing services, the default collectors
+	// when nil.
+	collectors []BindingCollector
+	// health records the results of the reconciles for the readiness probe, nothing is recorded
+	// when nil.
+	health *reconcileHealth
+}
+
+// parseAllowedKinds parses the comma separated kinds of the ALLOWED_APP_KINDS variable.
+func parseAllowedKinds(value string) []string {
+	kinds := []string{}
+	for _, kind := range parseList(value) {
+		kinds = append(kinds, strings.ToLower(kind))
+	}
+	return kinds
+}
+
+// parseList parses a comma separated list, leaving out the empty items.
+func parseList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// WithCollectors makes the reconciler collect the binding descriptors of the backing services with
+// the collectors, in order, instead of DefaultCollectors.
+func (r *ReconcileServiceBindingRequest) WithCollectors(collectors ...BindingCollector) *ReconcileServiceBindingRequest {
+	r.collectors = collectors
+	return r
+}
+
+// bindingCollectors returns the collectors of the binding descriptors.
+func (r *ReconcileServiceBindingRequest) bindingCollectors() []BindingCollector {
+	if r.collectors == nil {
+		return DefaultCollectors(r.client)
+	}
+	return r.collectors
+}
+
+// backingNamespaceAllowed returns whether backing services can be read from the namespace, other
+// than the ServiceBindingRequest one.
+func (r *ReconcileServiceBindingRequest) backingNamespaceAllowed(ns string) bool {
+	for _, allowed := range r.allowedBackingNamespaces {
+		if allowed == ns {
+			return true
+		}
+	}
+	return false
 }
 
 // Reconcile reads that state of the cluster for a ServiceBindingRequest object and makes changes based on the state read
-// and what is in the ServiceBindingRequest.Spec
-// TODO(user): Modify this Reconcile function to implement your Controller logic.  This example creates
-// a Pod as an example
+// and what is in the ServiceBindingRequest.Spec, recording the reconcile metrics and health.
 // Note:
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileServiceBindingRequest) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+	defer cancel()
+	result, err := r.reconcileBinding(ctx, request)
+	reconcileDuration.Observe(time.Since(start).Seconds())
+	reconcileTotal.WithLabelValues(reconcileResult(err)).Inc()
+	r.health.record(err)
+	return result, err
+}
+
+// reconcileBinding binds the applications of the ServiceBindingRequest, or unbinds them when it's
+// being deleted.
+func (r *ReconcileServiceBindingRequest) reconcileBinding(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling ServiceBindingRequest")
 
 	// Fetch the ServiceBindingRequest instance
 	instance := &v1alpha1.ServiceBindingRequest{}
-	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	err := r.client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.sources.remove(request.NamespacedName)
+			active.set(request.NamespacedName, false)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
-	crdName := instance.Spec.BackingSelector.ResourceName
-	crdVersion := instance.Spec.BackingSelector.ResourceVersion
-
-	clo := &client.ListOptions{
-		Namespace: request.Namespace,
-	}
-	csvl := &olmv1alpha1.ClusterServiceVersionList{}
-	err = r.client.List(context.TODO(), clo, csvl)
-	if err != nil {
-		return reconcile.Result{}, err
+	if instance.GetDeletionTimestamp() != nil {
+		return r.unbind(ctx, instance)
 	}
-
-	operatorName := ""
-outerLoop:
-	for _, csv := range csvl.Items {
-		for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
-			if crdName == crd.Name {
-				if crdVersion != "" {
-					if crdVersion != crd.Version {
-						return reconcile.Result{}, errs.New("Version not matching")
-					}
-				}
-				operatorName = csv.Name
-				break outerLoop
-			}
+	// a dry run binds nothing, there is nothing to unbind either
+	if !hasFinalizer(instance) && !instance.Spec.DryRun {
+		instance.SetFinalizers(append(instance.GetFinalizers(), finalizer))
+		if err = r.client.Update(ctx, instance); err != nil {
+			return reconcile.Result{}, err
 		}
 	}
 
-	nn := types.NamespacedName{Namespace: request.Namespace,
-		Name: operatorName}
-	csv := &olmv1alpha1.ClusterServiceVersion{}
-	err = r.client.Get(context.TODO(), nn, csv)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
-			return reconcile.Result{}, nil
+	// the first binding goes through Collecting and Binding, later ones only change the phase once done
+	if getCondition(&instance.Status, v1alpha1.CollectionReady) == nil {
+		setCondition(&instance.Status, newCondition(v1alpha1.CollectionReady, corev1.ConditionUnknown, collectingReason,
+			"collecting the binding data from the backing services"))
+		if err = r.updatePhase(ctx, instance); err != nil {
+			return reconcile.Result{}, err
 		}
-		// Error reading the object - requeue the request.
-		return reconcile.Result{Requeue: true}, err
 	}
 
 	evList := []corev1.EnvVar{}
+	secretNames := []string{}
+	configMapNames := []string{}
+	// binding keys by environment variable name, for applications mapping keys to their own names
+	bindingKeys := map[string]string{}
+	// keys flattened from JSON values, bound from a secret of their own
+	flattened := map[string][]byte{}
+	// the sources the backing services listing Sources were collected from
+	var collectedSources []v1alpha1.CollectedSource
+	// the kind of the backing service, typing the bindings projected as files
+	var backingGVK schema.GroupVersionKind
 
-	for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
-		for _, spec := range crd.SpecDescriptors {
-			pt := spec.Path
-			for _, xd := range spec.XDescriptors {
-				if strings.HasPrefix(xd, "urn:alm:descriptor:servicebindingrequest:secret:") {
-					key := strings.Split(xd, ":")[5]
-					sks := &corev1.SecretKeySelector{
-						Key: key,
-					}
-					sks.Name = pt
-					evs := &corev1.EnvVarSource{
-						SecretKeyRef: sks,
-					}
-					evn := strings.ToUpper(strings.ReplaceAll(instance.Name, "-", "_")) + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
-					ev := corev1.EnvVar{
-						Name:      evn,
-						ValueFrom: evs,
-					}
-					evList = append(evList, ev)
+	// the objects read are indexed even when reading them fails, so their creation requeues too
+	backingResources := []sourceKey{}
+	// the copies mirrored into application namespaces, recreated when deleted
+	mirroredResources := []sourceKey{}
+	defer func() {
+		sources := append(backingResources, bindingSources(request.Namespace, secretNames, configMapNames)...)
+		sources = append(sources, mirroredResources...)
+		r.sources.set(request.NamespacedName, sources)
+	}()
+
+	// on dry runs, the secrets and config maps that would be copied are read from their namespace,
+	// by name, and reported in status along with the flattened secret, none of them written
+	secretOrigins, configMapOrigins := map[string]string{}, map[string]string{}
+	unwrittenSecrets, unwrittenConfigMaps := []string{}, []string{}
+
+	binder := NewBinder(r.client, r.scheme, instance, r.allowedKinds).WithContext(ctx)
+	for i, selector := range backingServices(instance) {
+		backingNS := request.Namespace
+		if selector.Namespace != "" && selector.Namespace != request.Namespace {
+			if !r.backingNamespaceAllowed(selector.Namespace) {
+				// a policy of the operator, retrying won't help
+				cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "NamespaceNotAllowed",
+					fmt.Sprintf("reading backing services from namespace '%s' is not allowed by the operator", selector.Namespace))
+				return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+			}
+			backingNS = selector.Namespace
+		}
+		// secrets and config maps of another namespace, copied into the ServiceBindingRequest one
+		copiedSecrets, copiedConfigMaps := []string{}, []string{}
+
+		// a change to the CSVs, e.g. an upgrade changing the descriptors, requeues the collection
+		backingResources = append(backingResources, sourceKey{kind: csvSourceKind, name: strings.ToLower(selector.ResourceName)})
+		olm := newCachedOLM(r.client, backingNS, r.csvs).WithGlobalNamespace(r.globalOperatorsNamespace).WithContext(ctx)
+		crdDescriptions, err := olm.SelectCRDDescriptions(selector.ResourceName, selector.ResourceVersion)
+		if err != nil {
+			reason := "CollectionFailed"
+			if _, ok := err.(*AmbiguousKindError); ok {
+				reason = "AmbiguousKind"
+			}
+			switch err {
+			case ErrBackingServiceNotFound:
+				// The backing service operator may not be installed yet, don't requeue
+				cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionUnknown, "BackingServiceNotFound",
+					fmt.Sprintf("no operator owning '%s' was found", selector.ResourceName))
+				return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+			case ErrVersionNotMatching:
+				reason = "VersionNotMatching"
+			}
+			cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, reason, err.Error())
+			if statusErr := r.updateStatus(ctx, instance, cond); statusErr != nil {
+				return reconcile.Result{}, statusErr
+			}
+			return reconcile.Result{}, err
+		}
+
+		if i == 0 && len(crdDescriptions) > 0 {
+			backingGVK = crdGVK(crdDescriptions[0])
+		}
+
+		// keys of the additional backing services are qualified by the service, see serviceLabel
+		envPrefix, keyPrefix := envVarPrefix(instance), ""
+		if i > 0 {
+			label := serviceLabel(selector)
+			envPrefix += strings.ToUpper(strings.ReplaceAll(label, "-", "_")) + "_"
+			keyPrefix = label + "/"
+		}
+
+		for _, crd := range crdDescriptions {
+			if selector.ResourceRef != "" {
+				gvk := crdGVK(crd)
+				if err = r.backingKinds.ensure(gvk); err != nil {
+					// the binding doesn't depend on the watch, only its refresh does
+					reqLogger.Error(err, "Failed to watch the backing resources", "GVK", gvk)
 				}
-				if strings.HasPrefix(xd, "urn:alm:descriptor:servicebindingrequest:configmap:") {
-					key := strings.Split(xd, ":")[5]
-					cmks := &corev1.ConfigMapKeySelector{
-						Key: key,
+				backingResources = append(backingResources, sourceKey{
+					kind:      gvk.GroupKind().String(),
+					namespace: backingNS,
+					name:      selector.ResourceRef,
+				})
+			}
+			var descs []Descriptor
+			if len(selector.Sources) > 0 {
+				var source string
+				descs, source, err = collectSourceDescriptors(ctx, r.client, backingNS, crd, selector)
+				if source != "" {
+					collectedSources = append(collectedSources, v1alpha1.CollectedSource{ResourceName: selector.ResourceName, Source: source})
+				}
+			} else {
+				descs, err = collectDescriptors(ctx, r.client, backingNS, crd, selector, r.bindingCollectors())
+			}
+			if err != nil {
+				if _, ok := err.(*UnknownSourceError); ok {
+					// the spec needs fixing, retrying won't help
+					cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "UnknownSource", err.Error())
+					return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+				}
+				if notFound, ok := err.(*FieldNotFoundError); ok && notFound.Section == "status" {
+					// the backing service operator has yet to populate the status, binding now
+					// would reference nothing
+					reqLogger.Info("Backing resource status not populated yet, requeueing", "Error", err)
+					cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionUnknown, "CollectionNotReady", err.Error())
+					err = r.updateStatus(ctx, instance, cond)
+					return notReadyResult(instance, v1alpha1.CollectionReady), err
+				}
+				if secretNotFound(err) {
+					// e.g. the secret named by secretRef, yet to be created by the backing service
+					// operator
+					reqLogger.Info("Backing service secret not found, requeueing", "Error", err)
+					cond := newCondition(v1alpha1.SourceSecretFound, corev1.ConditionFalse, "SecretNotFound", err.Error())
+					err = r.updateStatus(ctx, instance, cond)
+					return notReadyResult(instance, v1alpha1.SourceSecretFound), err
+				}
+				reason := "CollectionFailed"
+				if errors.IsNotFound(err) {
+					reason = "BackingResourceNotFound"
+				}
+				cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, reason, err.Error())
+				if statusErr := r.updateStatus(ctx, instance, cond); statusErr != nil {
+					return reconcile.Result{}, statusErr
+				}
+				return reconcile.Result{}, err
+			}
+			for _, desc := range descs {
+				pt := desc.Value
+				for _, xd := range desc.XDescriptors {
+					if key, ok := xDescriptorKey(xd, "secret"); ok && secretKeyIncluded(selector, key) {
+						// the keys of the secrets named by a list are qualified by the secret
+						descKey := desc.KeyPrefix + key
+						if mapping, ok := flattenedMapping(instance, descKey); ok {
+							// the secret is read, the fields are bound from the flattened one
+							backingResources = append(backingResources, bindingSources(backingNS, []string{pt}, nil)...)
+							values, err := r.flattenedValues(ctx, backingNS, pt, key)
+							if err != nil {
+								if errors.IsNotFound(err) {
+									cond := newCondition(v1alpha1.SourceSecretFound, corev1.ConditionFalse, "SecretNotFound", err.Error())
+									err = r.updateStatus(ctx, instance, cond)
+									return notReadyResult(instance, v1alpha1.SourceSecretFound), err
+								}
+								cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "MappingFailed", err.Error())
+								if statusErr := r.updateStatus(ctx, instance, cond); statusErr != nil {
+									return reconcile.Result{}, statusErr
+								}
+								return reconcile.Result{}, err
+							}
+							fields := []string{}
+							for field := range values {
+								fields = append(fields, field)
+							}
+							sort.Strings(fields)
+							for _, field := range fields {
+								bindingKey := mapping.To + "-" + field
+								// secret keys can't hold the slash qualifying the keys of additional services
+								dataKey := strings.ReplaceAll(keyPrefix+bindingKey, "/", "-")
+								flattened[dataKey] = []byte(values[field])
+								sks := &corev1.SecretKeySelector{Key: dataKey}
+								sks.Name = flattenedSecretName(instance)
+								evn := envPrefix + strings.ToUpper(strings.ReplaceAll(bindingKey, "-", "_"))
+								evList = append(evList, corev1.EnvVar{Name: evn, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: sks}})
+								bindingKeys[evn] = keyPrefix + bindingKey
+							}
+							continue
+						}
+						sks := &corev1.SecretKeySelector{
+							Key: key,
+						}
+						sks.Name = pt
+						secretNames = append(secretNames, pt)
+						copiedSecrets = append(copiedSecrets, pt)
+						evs := &corev1.EnvVarSource{
+							SecretKeyRef: sks,
+						}
+						bindingKey := mappedKey(instance, descKey)
+						evn := envPrefix + strings.ToUpper(strings.ReplaceAll(bindingKey, "-", "_"))
+						ev := corev1.EnvVar{
+							Name:      evn,
+							ValueFrom: evs,
+						}
+						evList = append(evList, ev)
+						bindingKeys[evn] = keyPrefix + bindingKey
 					}
-					cmks.Name = pt
-					evs := &corev1.EnvVarSource{
-						ConfigMapKeyRef: cmks,
+					if key, ok := xDescriptorKey(xd, "configmap"); ok {
+						cmks := &corev1.ConfigMapKeySelector{
+							Key: key,
+						}
+						cmks.Name = pt
+						configMapNames = append(configMapNames, pt)
+						copiedConfigMaps = append(copiedConfigMaps, pt)
+						evs := &corev1.EnvVarSource{
+							ConfigMapKeyRef: cmks,
+						}
+						bindingKey := mappedKey(instance, key)
+						evn := envPrefix + strings.ToUpper(strings.ReplaceAll(bindingKey, "-", "_"))
+						ev := corev1.EnvVar{
+							Name:      evn,
+							ValueFrom: evs,
+						}
+						// config map values are not sensitive, and can be inlined when requested, files
+						// are always projected from the config map
+						if instance.Spec.InlineNonSensitive && !instance.Spec.BindAsFiles {
+							value, err := r.configMapValue(ctx, backingNS, pt, key)
+							if err != nil {
+								cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "ConfigMapValueNotFound", err.Error())
+								if statusErr := r.updateStatus(ctx, instance, cond); statusErr != nil {
+									return reconcile.Result{}, statusErr
+								}
+								return reconcile.Result{}, err
+							}
+							ev = corev1.EnvVar{
+								Name:  evn,
+								Value: value,
+							}
+						}
+						evList = append(evList, ev)
+						bindingKeys[evn] = keyPrefix + bindingKey
 					}
-					evn := strings.ToUpper(strings.ReplaceAll(instance.Name, "-", "_")) + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
-					ev := corev1.EnvVar{
-						Name:      evn,
-						ValueFrom: evs,
+					if key, ok := xDescriptorKey(xd, "attribute"); ok {
+						bindingKey := mappedKey(instance, key)
+						evn := envPrefix + strings.ToUpper(strings.ReplaceAll(bindingKey, "-", "_"))
+						evList = append(evList, corev1.EnvVar{Name: evn, Value: pt})
+						bindingKeys[evn] = keyPrefix + bindingKey
 					}
-					evList = append(evList, ev)
+
 				}
 
 			}
+		}
 
+		if backingNS != request.Namespace {
+			// the originals are indexed, a change to them requeues the copy
+			backingResources = append(backingResources, bindingSources(backingNS, copiedSecrets, copiedConfigMaps)...)
+			if instance.Spec.DryRun {
+				for _, name := range copiedSecrets {
+					secretOrigins[name] = backingNS
+					unwrittenSecrets = append(unwrittenSecrets, types.NamespacedName{Namespace: request.Namespace, Name: name}.String())
+				}
+				for _, name := range copiedConfigMaps {
+					configMapOrigins[name] = backingNS
+					unwrittenConfigMaps = append(unwrittenConfigMaps, types.NamespacedName{Namespace: request.Namespace, Name: name}.String())
+				}
+			} else if err = binder.copySources(backingNS, request.Namespace, copiedSecrets, copiedConfigMaps); err != nil {
+				cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "CopyFailed",
+					fmt.Sprintf("copying the binding data of namespace '%s': %v", backingNS, err))
+				if statusErr := r.updateStatus(ctx, instance, cond); statusErr != nil {
+					return reconcile.Result{}, statusErr
+				}
+				return reconcile.Result{}, err
+			}
 		}
 	}
 
-	lo := &client.ListOptions{
-		Namespace:     request.Namespace,
-		LabelSelector: labels.SelectorFromSet(instance.Spec.ApplicationSelector.MatchLabels),
+	if instance.Spec.BindAsFiles && backingGVK.Kind != "" {
+		// the type and provider files of the projection, unless collected from the backing service
+		bound := map[string]bool{}
+		for _, key := range bindingKeys {
+			bound[key] = true
+		}
+		for _, key := range []struct{ name, value string }{
+			{"type", strings.ToLower(backingGVK.Kind)},
+			{"provider", backingGVK.Group},
+		} {
+			if bound[key.name] || key.value == "" {
+				continue
+			}
+			flattened[key.name] = []byte(key.value)
+			sks := &corev1.SecretKeySelector{Key: key.name}
+			sks.Name = flattenedSecretName(instance)
+			evn := envVarPrefix(instance) + strings.ToUpper(key.name)
+			evList = append(evList, corev1.EnvVar{Name: evn, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: sks}})
+			bindingKeys[evn] = key.name
+		}
 	}
 
-	switch strings.ToLower(instance.Spec.ApplicationSelector.ResourceKind) {
-	case "deploymentconfig":
-		dcl := &osappsv1.DeploymentConfigList{}
-		err = r.client.List(context.TODO(), lo, dcl)
+	for _, value := range instance.Spec.ApplicationValues {
+		gvk := schema.FromAPIVersionAndKind(value.APIVersion, value.Kind)
+		if err = r.backingKinds.ensure(gvk); err != nil {
+			reqLogger.Error(err, "Failed to watch the application values", "GVK", gvk)
+		}
+		backingResources = append(backingResources, sourceKey{kind: gvk.GroupKind().String(), namespace: request.Namespace, name: value.Name})
+		if _, ok := flattened[value.Key]; ok {
+			cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "ApplicationValueFailed",
+				fmt.Sprintf("the secret key '%s' of the application value is a key of the binding data", value.Key))
+			return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+		}
+		data, err := r.applicationValue(ctx, request.Namespace, value)
 		if err != nil {
+			if notFound, ok := err.(*FieldNotFoundError); ok && notFound.Section == "status" {
+				// e.g. a Route not admitted yet
+				reqLogger.Info("Application value not populated yet, requeueing", "Error", err)
+				cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionUnknown, "CollectionNotReady", err.Error())
+				err = r.updateStatus(ctx, instance, cond)
+				return notReadyResult(instance, v1alpha1.CollectionReady), err
+			}
+			cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "ApplicationValueFailed", err.Error())
+			if statusErr := r.updateStatus(ctx, instance, cond); statusErr != nil {
+				return reconcile.Result{}, statusErr
+			}
 			return reconcile.Result{}, err
 		}
+		flattened[value.Key] = []byte(data)
+	}
 
-		for _, d := range dcl.Items {
-			for i, c := range d.Spec.Template.Spec.Containers {
-				c.Env = evList
-				d.Spec.Template.Spec.Containers[i] = c
-			}
-			err = r.client.Update(context.TODO(), &d)
-			if err != nil {
+	composed, err := r.composeValues(ctx, instance, request.Namespace, configMapOrigins, evList, bindingKeys, flattened)
+	if err != nil {
+		cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "MappingFailed", err.Error())
+		if statusErr := r.updateStatus(ctx, instance, cond); statusErr != nil {
+			return reconcile.Result{}, statusErr
+		}
+		return reconcile.Result{}, err
+	}
+	evList = append(evList, composed...)
+
+	if len(flattened) > 0 {
+		if instance.Spec.DryRun {
+			unwrittenSecrets = append(unwrittenSecrets, types.NamespacedName{Namespace: request.Namespace, Name: flattenedSecretName(instance)}.String())
+		} else {
+			if err = binder.writeFlattened(flattenedSecretName(instance), flattened); err != nil {
+				if _, ok := err.(*SecretConflictError); ok {
+					// the spec or the other secret needs changing, the secret watch requeues
+					cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "SecretNameConflict", err.Error())
+					return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+				}
+				if _, ok := err.(*SecretTooLargeError); ok {
+					// the spec or the backing service needs changing, retrying won't help
+					cond := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "SecretTooLarge", err.Error())
+					return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+				}
 				return reconcile.Result{}, err
 			}
+			secretNames = append(secretNames, flattenedSecretName(instance))
+		}
+	} else if !instance.Spec.DryRun {
+		// e.g. the mapping flattening the keys removed, nothing references the secret anymore
+		if err = binder.deleteFlattened(flattenedSecretName(instance)); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// a missing secret would only surface once the application pods fail to start
+	if err = r.secretsExist(ctx, request.Namespace, secretOrigins, secretNames); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		reqLogger.Info("Backing service secret not found, requeueing", "Error", err)
+		cond := newCondition(v1alpha1.SourceSecretFound, corev1.ConditionFalse, "SecretNotFound", err.Error())
+		err = r.updateStatus(ctx, instance, cond)
+		return notReadyResult(instance, v1alpha1.SourceSecretFound), err
+	}
+	setCondition(&instance.Status, newCondition(v1alpha1.SourceSecretFound, corev1.ConditionTrue, "", ""))
+	setCondition(&instance.Status, newCondition(v1alpha1.CollectionReady, corev1.ConditionTrue, "", ""))
+	instance.Status.Secrets = sortedNames(secretNames)
+	instance.Status.CollectedSources = collectedSources
+	if instance.Status.Phase == v1alpha1.PhaseCollecting {
+		if err = r.updatePhase(ctx, instance); err != nil {
+			return reconcile.Result{}, err
 		}
-	case "statefulset":
-		ssl := &appsv1.StatefulSetList{}
-		err = r.client.List(context.TODO(), lo, ssl)
+	}
+
+	// the webhook rejects these already, when deployed; the message lists the fields, e.g.
+	// "spec.applicationSelector.resourceKind: Unsupported value: ..."
+	if errs := ValidateApplicationSelector(instance, field.NewPath("spec", "applicationSelector")); len(errs) > 0 {
+		// the spec needs fixing, retrying won't help
+		cond := newCondition(v1alpha1.InjectionReady, corev1.ConditionFalse, "ValidationFailed", errs.ToAggregate().Error())
+		return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+	}
+	objs, err := binder.search()
+	if err != nil {
+		if _, ok := err.(*KindNotAllowedError); ok {
+			// a policy of the operator, retrying won't help
+			cond := newCondition(v1alpha1.InjectionReady, corev1.ConditionFalse, "KindNotAllowed", err.Error())
+			return reconcile.Result{}, r.updateStatus(ctx, instance, cond)
+		}
+		if _, ok := err.(*ApplicationOwnerNotFoundError); ok {
+			// owners set their references asynchronously, the chain may be complete later
+			cond := newCondition(v1alpha1.InjectionReady, corev1.ConditionFalse, "ApplicationOwnerNotFound", err.Error())
+			err = r.updateStatus(ctx, instance, cond)
+			return notReadyResult(instance, v1alpha1.InjectionReady), err
+		}
+		return reconcile.Result{}, err
+	}
+	if len(objs) == 0 {
+		r.recorder.Event(instance, corev1.EventTypeWarning, "NoMatchingApplication", "No application matches the application selector")
+		// set without its own event, the one above tells already
+		setCondition(&instance.Status, newCondition(v1alpha1.ApplicationsMatched, corev1.ConditionFalse, "NoMatchingApplications",
+			fmt.Sprintf("no application matches the application selector %s", describeSelector(instance.Spec.ApplicationSelector))))
+	} else {
+		setCondition(&instance.Status, newCondition(v1alpha1.ApplicationsMatched, corev1.ConditionTrue, "",
+			fmt.Sprintf("%d application(s) matched", len(objs))))
+	}
+	if instance.Spec.DryRun {
+		return r.dryRun(ctx, instance, objs, evList, bindingKeys, unwrittenSecrets, unwrittenConfigMaps)
+	}
+	instance.Status.DryRun = nil
+
+	// whether each application rolls out, to warn about the ones bound that don't
+	rollouts := map[corev1.ObjectReference]bool{}
+	mirroredSecrets, mirroredConfigMaps := referencedSources(evList)
+	for _, obj := range objs {
+		ref, err := binder.objectReference(obj)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
+		rollouts[ref] = rollsOut(obj)
+		if ref.Namespace != request.Namespace {
+			mirroredResources = append(mirroredResources, bindingSources(ref.Namespace, mirroredSecrets, mirroredConfigMaps)...)
+		}
+	}
+	modified, err := binder.Bind(objs, evList, bindingKeys)
+	// the applications bound before a failure are reported as well
+	for _, ref := range modified {
+		key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		r.recorder.Eventf(instance, corev1.EventTypeNormal, "ApplicationBound", "Bound application '%s'", key)
+		if !rollouts[ref] {
+			r.recorder.Eventf(instance, corev1.EventTypeWarning, "RolloutNotTriggered",
+				"Application '%s' doesn't roll out on template changes, roll it out to apply the binding", key)
+		}
+	}
+	// the applications failing to be bound don't keep the others from being reported bound
+	bindErr, partial := err.(*BindError)
+	if err != nil && !partial {
+		return r.injectionFailed(ctx, instance, err)
+	}
+	failed := map[string]bool{}
+	var failures []v1alpha1.ApplicationFailure
+	if partial {
+		for _, f := range bindErr.Failures {
+			key := types.NamespacedName{Namespace: f.Ref.Namespace, Name: f.Ref.Name}.String()
+			failed[key] = true
+			failures = append(failures, v1alpha1.ApplicationFailure{Application: key, Message: f.Err.Error()})
+		}
+	}
 
-		for _, d := range ssl.Items {
-			for i, c := range d.Spec.Template.Spec.Containers {
-				c.Env = evList
-				d.Spec.Template.Spec.Containers[i] = c
-			}
-			err = r.client.Update(context.TODO(), &d)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
+	consumed := []v1alpha1.ConsumedKeys{}
+	bound := []string{}
+	for _, obj := range objs {
+		keys, err := consumedKeys(obj)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if keys != nil {
+			consumed = append(consumed, *keys)
 		}
-	case "daemonset":
-		ssl := &appsv1.DaemonSetList{}
-		err = r.client.List(context.TODO(), lo, ssl)
+		key, err := client.ObjectKeyFromObject(obj)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
+		if !failed[key.String()] {
+			bound = append(bound, key.String())
+		}
+	}
+	instance.Status.ConsumedKeys = consumed
+	instance.Status.ApplicationObjects = bound
+	instance.Status.BoundApplications = len(bound)
+	instance.Status.FailedApplications = failures
+	if partial {
+		active.set(request.NamespacedName, len(bound) > 0)
+		return r.injectionFailed(ctx, instance, bindErr)
+	}
 
-		for _, d := range ssl.Items {
-			for i, c := range d.Spec.Template.Spec.Containers {
-				c.Env = evList
-				d.Spec.Template.Spec.Containers[i] = c
-			}
-			err = r.client.Update(context.TODO(), &d)
+	cond := newCondition(v1alpha1.InjectionReady, corev1.ConditionTrue, "", "")
+	if err = r.updateStatus(ctx, instance, cond); err != nil {
+		return reconcile.Result{}, err
+	}
+	active.set(request.NamespacedName, len(bound) > 0)
+
+	// removed once the status is written, updating the object returns its status as stored
+	if _, ok := instance.GetAnnotations()[forceRebindAnnotation]; ok {
+		reqLogger.Info("Forced re-bind done, removing annotation", "Annotation", forceRebindAnnotation)
+		updated := instance.DeepCopy()
+		annotations := updated.GetAnnotations()
+		delete(annotations, forceRebindAnnotation)
+		updated.SetAnnotations(annotations)
+		if err = r.client.Update(ctx, updated); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{Requeue: true}, nil
+
+}
+
+// notReadyResult returns the result of a reconcile waiting on the backing service, e.g. for its
+// secret to be created, as told by the condition of the type. The reconcile is retried after as
+// long as the condition has been in its status, within notReadyMinDelay and notReadyMaxDelay, so
+// the delay doubles on each retry still waiting, no external event needed.
+func notReadyResult(sbr *v1alpha1.ServiceBindingRequest, t v1alpha1.ConditionType) reconcile.Result {
+	delay := notReadyMinDelay
+	if cond := getCondition(&sbr.Status, t); cond != nil {
+		if waited := time.Since(cond.LastTransitionTime.Time); waited > delay {
+			delay = waited
+		}
+	}
+	if delay > notReadyMaxDelay {
+		delay = notReadyMaxDelay
+	}
+	return reconcile.Result{RequeueAfter: delay}
+}
+
+// dryRun records in status the binding that would be applied to the applications, the environment
+// variables, the applications that would change and the secrets and config maps that would be
+// written, as "namespace/name", without modifying them.
+func (r *ReconcileServiceBindingRequest) dryRun(
+	ctx context.Context,
+	sbr *v1alpha1.ServiceBindingRequest,
+	objs []runtime.Object,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+	secrets []string,
+	configMaps []string,
+) (reconcile.Result, error) {
+	names := map[string]bool{}
+	for _, env := range envs {
+		names[env.Name] = true
+	}
+	status := &v1alpha1.DryRunStatus{EnvVars: []string{}, ApplicationObjects: []string{}}
+	for name := range names {
+		status.EnvVars = append(status.EnvVars, name)
+	}
+	sort.Strings(status.EnvVars)
+	status.Secrets = sortedNames(secrets)
+	status.ConfigMaps = sortedNames(configMaps)
+
+	for _, obj := range objs {
+		// injecting into a copy, the object read is left as it is in the cluster
+		changed, err := inject(sbr, r.scheme, obj.DeepCopyObject(), envs, bindingKeys)
+		if err != nil {
+			return r.injectionFailed(ctx, sbr, err)
+		}
+		if !changed {
+			continue
+		}
+		key, err := client.ObjectKeyFromObject(obj)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		status.ApplicationObjects = append(status.ApplicationObjects, key.String())
+	}
+	sbr.Status.DryRun = status
+
+	cond := newCondition(v1alpha1.InjectionReady, corev1.ConditionUnknown, "DryRun",
+		fmt.Sprintf("dry run, %d application(s) would change", len(status.ApplicationObjects)))
+	return reconcile.Result{}, r.updateStatus(ctx, sbr, cond)
+}
+
+// unbind removes the binding from the applications of a ServiceBindingRequest being deleted, and
+// then its finalizer, letting the deletion complete.
+func (r *ReconcileServiceBindingRequest) unbind(ctx context.Context, sbr *v1alpha1.ServiceBindingRequest) (reconcile.Result, error) {
+	if !hasFinalizer(sbr) {
+		return reconcile.Result{}, nil
+	}
+
+	binder := NewBinder(r.client, r.scheme, sbr, r.allowedKinds).WithContext(ctx)
+	objs, err := binder.search()
+	if err != nil {
+		_, notAllowed := err.(*KindNotAllowedError)
+		_, noOwner := err.(*ApplicationOwnerNotFoundError)
+		switch {
+		case notAllowed:
+			// applications of a kind not allowed were never bound
+		case noOwner:
+			// no application owns the object, none was bound
+		case err == ErrEmptyApplicationSelector:
+			// nothing was ever selected to be bound
+		case errors.IsNotFound(err):
+			// a referenced application is gone, the deletion shouldn't be held by it
+			log.Info("Application not found, skipping unbind", "Error", err)
+		default:
+			return reconcile.Result{}, err
+		}
+	}
+
+	for _, obj := range objs {
+		updated, err := binder.unbind(obj)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if updated {
+			key, err := client.ObjectKeyFromObject(obj)
 			if err != nil {
 				return reconcile.Result{}, err
 			}
+			r.recorder.Eventf(sbr, corev1.EventTypeNormal, "ApplicationUnbound", "Unbound application '%s'", key)
+		}
+	}
+	// the copies in other namespaces aren't garbage collected
+	if err = binder.releaseMirrored(); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	finalizers := []string{}
+	for _, f := range sbr.GetFinalizers() {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
 		}
+	}
+	sbr.SetFinalizers(finalizers)
+	if err = r.client.Update(ctx, sbr); err != nil {
+		return reconcile.Result{}, err
+	}
+	nn := types.NamespacedName{Namespace: sbr.GetNamespace(), Name: sbr.GetName()}
+	r.sources.remove(nn)
+	active.set(nn, false)
+	return reconcile.Result{}, nil
+}
+
+// applicationHandler returns a handler enqueuing the ServiceBindingRequests selecting the changed
+// application object.
+func (r *ReconcileServiceBindingRequest) applicationHandler() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			requests, err := r.selectingRequests(o.Object, o.Meta)
+			if err != nil {
+				log.Error(err, "Failed to map the application to its ServiceBindingRequests",
+					"Namespace", o.Meta.GetNamespace(), "Name", o.Meta.GetName())
+			}
+			return requests
+		}),
+	}
+}
 
-	default:
-		dpl := &appsv1.DeploymentList{}
-		err = r.client.List(context.TODO(), lo, dpl)
+// selectingRequests returns the requests of the ServiceBindingRequests selecting the application
+// object, in any namespace since applications can be selected across namespaces.
+func (r *ReconcileServiceBindingRequest) selectingRequests(obj runtime.Object, m metav1.Object) ([]reconcile.Request, error) {
+	gvk, err := apiutil.GVKForObject(obj, r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+	defer cancel()
+	sbrl := &v1alpha1.ServiceBindingRequestList{}
+	if err = r.client.List(ctx, &client.ListOptions{}, sbrl); err != nil {
+		return nil, err
+	}
+	requests := []reconcile.Request{}
+	for i := range sbrl.Items {
+		sbr := &sbrl.Items[i]
+		if sbr.GetDeletionTimestamp() != nil {
+			continue
+		}
+		selects, err := NewBinder(r.client, r.scheme, sbr, r.allowedKinds).WithContext(ctx).selects(gvk, m)
 		if err != nil {
-			return reconcile.Result{}, err
+			return nil, err
+		}
+		if selects {
+			nn := types.NamespacedName{Namespace: sbr.GetNamespace(), Name: sbr.GetName()}
+			requests = append(requests, reconcile.Request{NamespacedName: nn})
+		}
+	}
+	return requests, nil
+}
+
+// bindingSources returns the index keys of the secrets and config maps the binding data is read
+// from.
+func bindingSources(ns string, secretNames, configMapNames []string) []sourceKey {
+	sources := []sourceKey{}
+	for _, name := range secretNames {
+		sources = append(sources, sourceKey{kind: "Secret", namespace: ns, name: name})
+	}
+	for _, name := range configMapNames {
+		sources = append(sources, sourceKey{kind: "ConfigMap", namespace: ns, name: name})
+	}
+	return sources
+}
+
+// describeSelector returns the application selector as text, for messages, e.g. "{resourceKind:
+// Deployment, matchLabels: connects-to=postgres}".
+func describeSelector(selector v1alpha1.ApplicationSelector) string {
+	kind := selector.ResourceKind
+	if kind == "" {
+		kind = "Deployment"
+	}
+	fields := []string{"resourceKind: " + kind}
+	if selector.ResourceRef != "" {
+		fields = append(fields, "resourceRef: "+selector.ResourceRef)
+	}
+	if len(selector.Refs) > 0 {
+		refs := []string{}
+		for _, ref := range selector.Refs {
+			refs = append(refs, ref.Namespace+"/"+ref.Name)
+		}
+		fields = append(fields, "refs: "+strings.Join(refs, " "))
+	}
+	if ref := selector.OwnedRef; ref != nil {
+		fields = append(fields, "ownedRef: "+ref.Kind+"/"+ref.Name)
+	}
+	if len(selector.MatchLabels) > 0 {
+		fields = append(fields, "matchLabels: "+labels.SelectorFromSet(selector.MatchLabels).String())
+	}
+	if len(selector.MatchExpressions) > 0 {
+		// only valid expressions get this far, the search fails on the others
+		if ls, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: selector.MatchExpressions}); err == nil {
+			fields = append(fields, "matchExpressions: "+ls.String())
+		}
+	}
+	if len(selector.NamespaceSelector) > 0 {
+		fields = append(fields, "namespaceSelector: "+labels.SelectorFromSet(selector.NamespaceSelector).String())
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// sortedNames returns the names sorted, without duplicates.
+func sortedNames(names []string) []string {
+	seen := map[string]bool{}
+	sorted := []string{}
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// secretKeyIncluded returns whether the secret key is bound: listed in the Include field of the
+// BackingSelector, or any key when empty, and not listed in its Exclude field.
+func secretKeyIncluded(selector v1alpha1.BackingSelector, key string) bool {
+	for _, excluded := range selector.Exclude {
+		if excluded == key {
+			return false
+		}
+	}
+	if len(selector.Include) == 0 {
+		return true
+	}
+	for _, included := range selector.Include {
+		if included == key {
+			return true
 		}
+	}
+	return false
+}
 
-		for _, d := range dpl.Items {
-			for i, c := range d.Spec.Template.Spec.Containers {
-				c.Env = evList
-				d.Spec.Template.Spec.Containers[i] = c
+// mappedKey returns the key the descriptor key is bound as, renamed by the first mapping from it,
+// or else unchanged.
+func mappedKey(sbr *v1alpha1.ServiceBindingRequest, key string) string {
+	for _, mapping := range sbr.Spec.Mappings {
+		if mapping.Value == "" && mapping.Type == "" && mapping.From == key {
+			return mapping.To
+		}
+	}
+	return key
+}
+
+// flattenedMapping returns the first mapping flattening the JSON value of the secret key, see
+// v1alpha1.MappingTypeJSON.
+func flattenedMapping(sbr *v1alpha1.ServiceBindingRequest, key string) (v1alpha1.Mapping, bool) {
+	for _, mapping := range sbr.Spec.Mappings {
+		if mapping.Type == v1alpha1.MappingTypeJSON && mapping.From == key {
+			return mapping, true
+		}
+	}
+	return v1alpha1.Mapping{}, false
+}
+
+// flattenedSecretName returns the name of the secret holding the keys of the ServiceBindingRequest
+// flattened from JSON values, the type and provider of its files, its application values and the
+// values composed into it, SecretName when set.
+func flattenedSecretName(sbr *v1alpha1.ServiceBindingRequest) string {
+	if sbr.Spec.SecretName != "" {
+		return sbr.Spec.SecretName
+	}
+	return sbr.GetName() + "-flattened"
+}
+
+// applicationValue returns the value at the path of the status of the object of the application
+// value, in the namespace, formatted as the values of the status descriptors.
+func (r *ReconcileServiceBindingRequest) applicationValue(ctx context.Context, ns string, value v1alpha1.ApplicationValue) (string, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(value.APIVersion)
+	obj.SetKind(value.Kind)
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: ns, Name: value.Name}, obj); err != nil {
+		return "", err
+	}
+	return fieldValue(obj, "status", value.Path)
+}
+
+// composeValues returns the environment variables of the mappings composing a value, their
+// templates executed over the values of the binding keys, the attributes and config map keys, and
+// records their binding keys. A key missing, e.g. the one of a secret, fails the execution. The
+// config maps are read from the namespace, or from the one of their origin when not copied yet.
+// The values of the mappings setting Secret are added to the flattened keys, along with the values
+// they're composed over, and referenced by their variables.
+func (r *ReconcileServiceBindingRequest) composeValues(
+	ctx context.Context,
+	sbr *v1alpha1.ServiceBindingRequest,
+	ns string,
+	origins map[string]string,
+	envs []corev1.EnvVar,
+	bindingKeys map[string]string,
+	flattened map[string][]byte,
+) ([]corev1.EnvVar, error) {
+	composed := []corev1.EnvVar{}
+	var values map[string]string
+	for _, mapping := range sbr.Spec.Mappings {
+		if mapping.Value == "" {
+			continue
+		}
+		// read once, and only when some mapping composes a value
+		if values == nil {
+			values = map[string]string{}
+			for _, env := range envs {
+				switch {
+				case env.ValueFrom == nil:
+					values[bindingKeys[env.Name]] = env.Value
+				case env.ValueFrom.ConfigMapKeyRef != nil:
+					ref := env.ValueFrom.ConfigMapKeyRef
+					cmNS := ns
+					if origin, ok := origins[ref.Name]; ok {
+						cmNS = origin
+					}
+					value, err := r.configMapValue(ctx, cmNS, ref.Name, ref.Key)
+					if err != nil {
+						return nil, err
+					}
+					values[bindingKeys[env.Name]] = value
+				}
 			}
-			err = r.client.Update(context.TODO(), &d)
-			if err != nil {
-				return reconcile.Result{}, err
+		}
+
+		tmpl, err := template.New(mapping.To).Option("missingkey=error").Parse(mapping.Value)
+		if err != nil {
+			return nil, fmt.Errorf("mapping '%s': %v", mapping.To, err)
+		}
+		value := &strings.Builder{}
+		if err = tmpl.Execute(value, values); err != nil {
+			return nil, fmt.Errorf("mapping '%s': %v", mapping.To, err)
+		}
+		name := envVarPrefix(sbr) + strings.ToUpper(strings.ReplaceAll(mapping.To, "-", "_"))
+		env := corev1.EnvVar{Name: name, Value: value.String()}
+		if mapping.Secret {
+			if _, ok := flattened[mapping.To]; ok {
+				return nil, fmt.Errorf("mapping '%s': the key is a key of the binding data", mapping.To)
+			}
+			// the parts as they are, next to the value composed of them, the keys of the binding
+			// data taking precedence
+			for key, part := range values {
+				// secret keys can't hold the slash qualifying the keys of additional services
+				dataKey := strings.ReplaceAll(key, "/", "-")
+				if _, ok := flattened[dataKey]; !ok {
+					flattened[dataKey] = []byte(part)
+				}
 			}
+			flattened[mapping.To] = []byte(value.String())
+			sks := &corev1.SecretKeySelector{Key: mapping.To}
+			sks.Name = flattenedSecretName(sbr)
+			env = corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: sks}}
 		}
+		composed = append(composed, env)
+		bindingKeys[name] = mapping.To
 	}
+	return composed, nil
+}
 
-	return reconcile.Result{Requeue: true}, nil
+// hasFinalizer returns whether the ServiceBindingRequest has the operator finalizer.
+func hasFinalizer(sbr *v1alpha1.ServiceBindingRequest) bool {
+	for _, f := range sbr.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// backingServices returns the backing services of the ServiceBindingRequest, the one of the
+// BackingSelector first.
+func backingServices(sbr *v1alpha1.ServiceBindingRequest) []v1alpha1.BackingSelector {
+	return append([]v1alpha1.BackingSelector{sbr.Spec.BackingSelector}, sbr.Spec.BackingServices...)
+}
+
+// serviceLabel returns the lower case first label of the resource name of a backing service, e.g.
+// "redis" for "redis.example.org", qualifying the keys of the additional backing services.
+func serviceLabel(selector v1alpha1.BackingSelector) string {
+	return strings.ToLower(strings.Split(selector.ResourceName, ".")[0])
+}
+
+// envVarPrefix returns the prefix of the environment variables bound by the ServiceBindingRequest,
+// its EnvVarPrefix when set, or else its name in upper case with dashes replaced by underscores,
+// e.g. "MY_DB_".
+func envVarPrefix(sbr *v1alpha1.ServiceBindingRequest) string {
+	if sbr.Spec.EnvVarPrefix != "" {
+		return sbr.Spec.EnvVarPrefix
+	}
+	return strings.ToUpper(strings.ReplaceAll(sbr.GetName(), "-", "_")) + "_"
+}
 
+// consumedKeys reads the consumed keys annotation of the application object, returning nil when
+// the application didn't report any.
+func consumedKeys(obj runtime.Object) (*v1alpha1.ConsumedKeys, error) {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := objMeta.GetAnnotations()[consumedKeysAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	keys := []string{}
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return &v1alpha1.ConsumedKeys{Application: objMeta.GetName(), Keys: keys}, nil
+}
+
+// secretsExist returns the error of reading the first secret that can't be read, from the namespace
+// of its origin when not copied yet, on dry runs.
+func (r *ReconcileServiceBindingRequest) secretsExist(ctx context.Context, ns string, origins map[string]string, names []string) error {
+	for _, name := range names {
+		secretNS := ns
+		if origin, ok := origins[name]; ok {
+			secretNS = origin
+		}
+		secret := &corev1.Secret{}
+		err := r.client.Get(ctx, types.NamespacedName{Namespace: secretNS, Name: name}, secret)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretNotFound returns whether the error tells a secret wasn't found, rather than another
+// object read collecting the binding data.
+func secretNotFound(err error) bool {
+	statusErr, ok := err.(*errors.StatusError)
+	return ok && errors.IsNotFound(err) && statusErr.ErrStatus.Details != nil && statusErr.ErrStatus.Details.Kind == "secrets"
+}
+
+// configMapValue reads the value of a config map key.
+func (r *ReconcileServiceBindingRequest) configMapValue(ctx context.Context, ns, name, key string) (string, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, cm)
+	if err != nil {
+		return "", err
+	}
+	value, ok := cm.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found in config map '%s'", key, name)
+	}
+	return value, nil
+}
+
+// flattenedValues reads the value of a secret key, and returns its JSON fields, see flattenJSON.
+func (r *ReconcileServiceBindingRequest) flattenedValues(ctx context.Context, ns, name, key string) (map[string]string, error) {
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, secret)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key '%s' not found in secret '%s'", key, name)
+	}
+	values, err := flattenJSON(value)
+	if err != nil {
+		return nil, fmt.Errorf("key '%s' of secret '%s' is not a JSON object: %v", key, name, err)
+	}
+	return values, nil
+}
+
+// updateStatus records the conditions, recomputes the phase and writes the ServiceBindingRequest
+// status. Conditions not True are also recorded as warning events, with the same reason.
+func (r *ReconcileServiceBindingRequest) updateStatus(
+	ctx context.Context,
+	sbr *v1alpha1.ServiceBindingRequest,
+	conditions ...v1alpha1.Condition,
+) error {
+	for _, c := range conditions {
+		setCondition(&sbr.Status, c)
+		if c.Status != corev1.ConditionTrue {
+			r.recorder.Event(sbr, corev1.EventTypeWarning, c.Reason, c.Message)
+		}
+	}
+	return r.updatePhase(ctx, sbr)
+}
+
+// updatePhase writes the status with the phase computed from the conditions as they are, without
+// the events updateStatus records, for the phases of a binding step starting. The BindingStarted
+// event is recorded as the phase changes into Binding, once per binding rather than on every
+// reconcile.
+func (r *ReconcileServiceBindingRequest) updatePhase(ctx context.Context, sbr *v1alpha1.ServiceBindingRequest) error {
+	previous := sbr.Status.Phase
+	setPhase(&sbr.Status)
+	if err := r.client.Status().Update(ctx, sbr); err != nil {
+		return err
+	}
+	if previous != v1alpha1.PhaseBinding && sbr.Status.Phase == v1alpha1.PhaseBinding {
+		r.recorder.Event(sbr, corev1.EventTypeNormal, "BindingStarted", "Binding the applications")
+	}
+	return nil
+}
+
+// injectionFailed records the injection error in status and returns it to requeue the request.
+func (r *ReconcileServiceBindingRequest) injectionFailed(
+	ctx context.Context,
+	sbr *v1alpha1.ServiceBindingRequest,
+	err error,
+) (reconcile.Result, error) {
+	cond := newCondition(v1alpha1.InjectionReady, corev1.ConditionFalse, "InjectionFailed", err.Error())
+	if statusErr := r.updateStatus(ctx, sbr, cond); statusErr != nil {
+		return reconcile.Result{}, statusErr
+	}
+	return reconcile.Result{}, err
 }
@@ -1,19 +1,34 @@
 package servicebindingrequest
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	osappsv1 "github.com/openshift/api/apps/v1"
 	olmv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	"github.com/redhat-developer/service-binding-operator/pkg/testutils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -42,6 +57,13 @@ func TestServiceBindingRequestController(t *testing.T) {
 		},
 	}
 
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
 	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
@@ -62,6 +84,7 @@ func TestServiceBindingRequestController(t *testing.T) {
 						Name: "specialdb.example.org",
 						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
 							{
+								Path:         "db-credentials",
 								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password", "aaa:ccc:aa"},
 							},
 						},
@@ -111,12 +134,12 @@ func TestServiceBindingRequestController(t *testing.T) {
 		s.AddKnownTypes(appsv1.SchemeGroupVersion, dp)
 
 		// Objects to track in the fake client.
-		objs := []runtime.Object{sbr, csv, dp}
+		objs := []runtime.Object{sbr, csv, secret, dp}
 
 		cl := fake.NewFakeClient(objs...)
 
 		// Create a ReconcileServiceBindingRequest object with the scheme and fake client.
-		r := &ReconcileServiceBindingRequest{client: cl, scheme: s}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
 
 		// Mock request to simulate Reconcile() being called on an event for a
 		// watched resource .
@@ -154,6 +177,33 @@ func TestServiceBindingRequestController(t *testing.T) {
 		if n2 != "POSTGRES_USERNAME" {
 			t.Errorf("Environment name not matching: %s", n2)
 		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		err = r.client.Get(context.TODO(), req.NamespacedName, sbrOut)
+		if err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		if sbrOut.Status.Phase != v1alpha1.PhaseReady {
+			t.Errorf("Phase not matching: %s", sbrOut.Status.Phase)
+		}
+		if ready := getCondition(&sbrOut.Status, v1alpha1.Ready); ready == nil || ready.Status != corev1.ConditionTrue {
+			t.Errorf("Ready condition not matching: %+v", ready)
+		}
+		if !reflect.DeepEqual(sbrOut.Status.ApplicationObjects, []string{"default/my-app"}) {
+			t.Errorf("Application objects not matching: %v", sbrOut.Status.ApplicationObjects)
+		}
+		// read by the Ready, Secret and Applications printer columns
+		if !reflect.DeepEqual(sbrOut.Status.Secrets, []string{"db-credentials"}) {
+			t.Errorf("Secrets not matching: %v", sbrOut.Status.Secrets)
+		}
+		if sbrOut.Status.BoundApplications != 1 {
+			t.Errorf("Bound applications not matching: %d", sbrOut.Status.BoundApplications)
+		}
+		if injected := getCondition(&sbrOut.Status, v1alpha1.InjectionReady); injected == nil || injected.Status != corev1.ConditionTrue {
+			t.Errorf("InjectionReady condition not matching: %+v", injected)
+		}
+
+		testutils.AssertReconcileIdempotent(t, r, cl, req, sbrOut, dpOut)
 	})
 
 	t.Run("DeploymentConfig", func(t *testing.T) {
@@ -187,12 +237,12 @@ func TestServiceBindingRequestController(t *testing.T) {
 
 		sbr.Spec.ApplicationSelector.ResourceKind = "DeploymentConfig"
 		// Objects to track in the fake client.
-		objs := []runtime.Object{sbr, csv, dp}
+		objs := []runtime.Object{sbr, csv, secret, dp}
 
 		cl := fake.NewFakeClient(objs...)
 
 		// Create a ReconcileServiceBindingRequest object with the scheme and fake client.
-		r := &ReconcileServiceBindingRequest{client: cl, scheme: s}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
 
 		// Mock request to simulate Reconcile() being called on an event for a
 		// watched resource .
@@ -232,6 +282,151 @@ func TestServiceBindingRequestController(t *testing.T) {
 		}
 	})
 
+	t.Run("DeploymentConfig triggers", func(t *testing.T) {
+		dcWithTriggers := func(triggers ...osappsv1.DeploymentTriggerType) *osappsv1.DeploymentConfig {
+			dc := &osappsv1.DeploymentConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"connects-to": "postgres",
+						"environment": "production",
+					},
+				},
+				Spec: osappsv1.DeploymentConfigSpec{
+					Template: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "app"},
+							},
+						},
+					},
+				},
+			}
+			for _, trigger := range triggers {
+				dc.Spec.Triggers = append(dc.Spec.Triggers, osappsv1.DeploymentTriggerPolicy{Type: trigger})
+			}
+			return dc
+		}
+		sbr.Spec.ApplicationSelector.ResourceKind = "DeploymentConfig"
+
+		for _, test := range []struct {
+			name     string
+			dc       *osappsv1.DeploymentConfig
+			expected []string
+		}{
+			{
+				name:     "config change",
+				dc:       dcWithTriggers(osappsv1.DeploymentTriggerOnConfigChange, osappsv1.DeploymentTriggerOnImageChange),
+				expected: []string{"BindingStarted", "ApplicationBound"},
+			},
+			{
+				name:     "image change only",
+				dc:       dcWithTriggers(osappsv1.DeploymentTriggerOnImageChange),
+				expected: []string{"BindingStarted", "ApplicationBound", "RolloutNotTriggered"},
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				recorder := record.NewFakeRecorder(10)
+				cl := fake.NewFakeClient(sbr, csv, secret, test.dc)
+				r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: recorder}
+
+				req := reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      name,
+						Namespace: namespace,
+					},
+				}
+				if _, err := r.Reconcile(req); err != nil {
+					t.Fatalf("reconcile: (%v)", err)
+				}
+
+				dcOut := &osappsv1.DeploymentConfig{}
+				nn := types.NamespacedName{
+					Name:      deploymentName,
+					Namespace: namespace,
+				}
+				if err := r.client.Get(context.TODO(), nn, dcOut); err != nil {
+					t.Fatalf("get deployment config: (%v)", err)
+				}
+				// the environment lands in the pod template, the triggers are left alone
+				if len(dcOut.Spec.Template.Spec.Containers[0].Env) != 2 {
+					t.Errorf("DeploymentConfig not bound: %v", dcOut.Spec.Template.Spec.Containers[0].Env)
+				}
+				if !reflect.DeepEqual(dcOut.Spec.Triggers, test.dc.Spec.Triggers) {
+					t.Errorf("Triggers not matching: expected %v, got %v", test.dc.Spec.Triggers, dcOut.Spec.Triggers)
+				}
+
+				found := []string{}
+				for len(recorder.Events) > 0 {
+					found = append(found, strings.Split(<-recorder.Events, " ")[1])
+				}
+				if !reflect.DeepEqual(found, test.expected) {
+					t.Errorf("Events not matching: expected %v, got %v", test.expected, found)
+				}
+			})
+		}
+	})
+
+	t.Run("DeploymentConfig by label", func(t *testing.T) {
+		dcTemplate := func(name string, labels map[string]string) *osappsv1.DeploymentConfig {
+			return &osappsv1.DeploymentConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: osappsv1.DeploymentConfigSpec{
+					Template: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "app"},
+							},
+						},
+					},
+				},
+			}
+		}
+		matching := dcTemplate(deploymentName, map[string]string{
+			"connects-to": "postgres",
+			"environment": "production",
+		})
+		other := dcTemplate("other-app", map[string]string{
+			"connects-to": "postgres",
+			"environment": "staging",
+		})
+
+		sbr.Spec.ApplicationSelector.ResourceKind = "DeploymentConfig"
+		cl := &selectingClient{Client: fake.NewFakeClient(sbr, csv, secret, matching, other)}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		req := reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		for dcName, bound := range map[string]bool{deploymentName: true, "other-app": false} {
+			dcOut := &osappsv1.DeploymentConfig{}
+			nn := types.NamespacedName{Name: dcName, Namespace: namespace}
+			if err := r.client.Get(context.TODO(), nn, dcOut); err != nil {
+				t.Fatalf("get deployment config: (%v)", err)
+			}
+			env := dcOut.Spec.Template.Spec.Containers[0].Env
+			if bound && len(env) == 0 {
+				t.Errorf("DeploymentConfig '%s' should be bound", dcName)
+			}
+			if !bound && len(env) != 0 {
+				t.Errorf("DeploymentConfig '%s' should not be bound: %v", dcName, env)
+			}
+		}
+	})
+
 	t.Run("StatefulSet", func(t *testing.T) {
 
 		// Add StatefulSet scheme
@@ -263,12 +458,12 @@ func TestServiceBindingRequestController(t *testing.T) {
 
 		sbr.Spec.ApplicationSelector.ResourceKind = "StatefulSet"
 		// Objects to track in the fake client.
-		objs := []runtime.Object{sbr, csv, dp}
+		objs := []runtime.Object{sbr, csv, secret, dp}
 
 		cl := fake.NewFakeClient(objs...)
 
 		// Create a ReconcileServiceBindingRequest object with the scheme and fake client.
-		r := &ReconcileServiceBindingRequest{client: cl, scheme: s}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
 
 		// Mock request to simulate Reconcile() being called on an event for a
 		// watched resource .
@@ -339,12 +534,12 @@ func TestServiceBindingRequestController(t *testing.T) {
 
 		sbr.Spec.ApplicationSelector.ResourceKind = "DaemonSet"
 		// Objects to track in the fake client.
-		objs := []runtime.Object{sbr, csv, dp}
+		objs := []runtime.Object{sbr, csv, secret, dp}
 
 		cl := fake.NewFakeClient(objs...)
 
 		// Create a ReconcileServiceBindingRequest object with the scheme and fake client.
-		r := &ReconcileServiceBindingRequest{client: cl, scheme: s}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
 
 		// Mock request to simulate Reconcile() being called on an event for a
 		// watched resource .
@@ -412,6 +607,13 @@ func TestCornerCasesServiceBindingRequestController(t *testing.T) {
 		},
 	}
 
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
 	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
@@ -423,7 +625,7 @@ func TestCornerCasesServiceBindingRequestController(t *testing.T) {
 		cl := fake.NewFakeClient(objs...)
 
 		// Create a ReconcileServiceBindingRequest object with the scheme and fake client.
-		r := &ReconcileServiceBindingRequest{client: cl, scheme: s}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
 
 		// Mock request to simulate Reconcile() being called on an event for a
 		// watched resource .
@@ -462,6 +664,7 @@ func TestCornerCasesServiceBindingRequestController(t *testing.T) {
 							Name: "specialdb.example.org",
 							SpecDescriptors: []olmv1alpha1.SpecDescriptor{
 								{
+									Path:         "db-credentials",
 									XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password", "aaa:ccc:aa"},
 								},
 							},
@@ -510,12 +713,12 @@ func TestCornerCasesServiceBindingRequestController(t *testing.T) {
 			s.AddKnownTypes(appsv1.SchemeGroupVersion, dp)
 
 			// Objects to track in the fake client.
-			objs := []runtime.Object{sbr, csv, dp}
+			objs := []runtime.Object{sbr, csv, secret, dp}
 
 			cl := fake.NewFakeClient(objs...)
 
 			// Create a ReconcileServiceBindingRequest object with the scheme and fake client.
-			r := &ReconcileServiceBindingRequest{client: cl, scheme: s}
+			r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
 
 			// Mock request to simulate Reconcile() being called on an event for a
 			// watched resource .
@@ -535,3 +738,5867 @@ func TestCornerCasesServiceBindingRequestController(t *testing.T) {
 
 	})
 }
+
+func TestContainerIndexServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+					"environment": "production",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+				"environment": "production",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "sidecar"},
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	t.Run("bind container at index", func(t *testing.T) {
+		idx := 1
+		sbrIdx := sbr.DeepCopy()
+		sbrIdx.Spec.ContainerIndex = &idx
+
+		cl := fake.NewFakeClient(sbrIdx, csv, secret, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: namespace,
+		}
+		err = r.client.Get(context.TODO(), nn, dpOut)
+		if err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		containers := dpOut.Spec.Template.Spec.Containers
+		if len(containers[0].Env) != 0 {
+			t.Errorf("Container 'sidecar' should not be bound: %v", containers[0].Env)
+		}
+		if len(containers[1].Env) != 1 || containers[1].Env[0].Name != "POSTGRES_PASSWORD" {
+			t.Errorf("Container 'app' not bound as expected: %v", containers[1].Env)
+		}
+	})
+
+	t.Run("container index out of range", func(t *testing.T) {
+		idx := 2
+		sbrIdx := sbr.DeepCopy()
+		sbrIdx.Spec.ContainerIndex = &idx
+
+		cl := fake.NewFakeClient(sbrIdx, csv, secret, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err == nil {
+			t.Fatalf("reconcile worked with container index out of range")
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		err = r.client.Get(context.TODO(), req.NamespacedName, sbrOut)
+		if err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		if sbrOut.Status.Phase != v1alpha1.PhaseError {
+			t.Errorf("Phase not matching: %s", sbrOut.Status.Phase)
+		}
+	})
+
+	t.Run("bind container by name", func(t *testing.T) {
+		sbrNamed := sbr.DeepCopy()
+		sbrNamed.Spec.ApplicationSelector.Containers = []string{"app"}
+
+		cl := fake.NewFakeClient(sbrNamed, csv, secret, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: namespace,
+		}
+		err = r.client.Get(context.TODO(), nn, dpOut)
+		if err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		containers := dpOut.Spec.Template.Spec.Containers
+		if len(containers[0].Env) != 0 {
+			t.Errorf("Container 'sidecar' should not be bound: %v", containers[0].Env)
+		}
+		if len(containers[1].Env) != 1 || containers[1].Env[0].Name != "POSTGRES_PASSWORD" {
+			t.Errorf("Container 'app' not bound as expected: %v", containers[1].Env)
+		}
+	})
+
+	t.Run("container name not found", func(t *testing.T) {
+		sbrNamed := sbr.DeepCopy()
+		sbrNamed.Spec.ApplicationSelector.Containers = []string{"web"}
+
+		cl := fake.NewFakeClient(sbrNamed, csv, secret, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err == nil {
+			t.Fatalf("reconcile worked with container name not found")
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		err = r.client.Get(context.TODO(), req.NamespacedName, sbrOut)
+		if err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		if sbrOut.Status.Phase != v1alpha1.PhaseError {
+			t.Errorf("Phase not matching: %s", sbrOut.Status.Phase)
+		}
+	})
+}
+
+func TestConflictServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	t.Run("retry until no conflict", func(t *testing.T) {
+		cl := &conflictingClient{Client: fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp.DeepCopy()), conflicts: 2}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if cl.updates != 3 {
+			t.Errorf("Expected 3 update attempts, found %d", cl.updates)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: namespace,
+		}
+		err = r.client.Get(context.TODO(), nn, dpOut)
+		if err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		env := dpOut.Spec.Template.Spec.Containers[0].Env
+		if len(env) != 1 || env[0].Name != "POSTGRES_PASSWORD" {
+			t.Errorf("Environment not matching: %v", env)
+		}
+	})
+
+	t.Run("give up on persistent conflict", func(t *testing.T) {
+		cl := &conflictingClient{Client: fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp.DeepCopy()), conflicts: 100}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		bindErr, ok := err.(*BindError)
+		if !ok || len(bindErr.Failures) != 1 || !errors.IsConflict(bindErr.Failures[0].Err) {
+			t.Fatalf("Expected conflict error, found: (%v)", err)
+		}
+	})
+}
+
+func TestNamespaceSelectorServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+				NamespaceSelector: map[string]string{
+					"team": "payments",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	objs := []runtime.Object{sbr, csv, secret}
+	teams := map[string]string{"team-a": "payments", "team-b": "payments", "team-c": "shipping"}
+	for ns, team := range teams {
+		objs = append(objs, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   ns,
+				Labels: map[string]string{"team": team},
+			},
+		}, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      deploymentName,
+				Namespace: ns,
+				Labels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "app"},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	cl := &selectingClient{Client: fake.NewFakeClient(objs...)}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	for ns, team := range teams {
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: ns,
+		}
+		err = r.client.Get(context.TODO(), nn, dpOut)
+		if err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+
+		bound := len(dpOut.Spec.Template.Spec.Containers[0].Env) > 0
+		if team == "payments" && !bound {
+			t.Errorf("Deployment in namespace '%s' should be bound", ns)
+		}
+		if team != "payments" && bound {
+			t.Errorf("Deployment in namespace '%s' should not be bound", ns)
+		}
+	}
+}
+
+func TestInlineNonSensitiveServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			InlineNonSensitive: true,
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path:         "db-config",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:configmap:username"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"username": "postgres",
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	t.Run("inline config map values", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, cm, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: namespace,
+		}
+		err = r.client.Get(context.TODO(), nn, dpOut)
+		if err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		env := dpOut.Spec.Template.Spec.Containers[0].Env
+		if len(env) != 2 {
+			t.Fatalf("Expected two environment variables, found: %v", env)
+		}
+		if env[0].Name != "POSTGRES_PASSWORD" || env[0].ValueFrom == nil || env[0].ValueFrom.SecretKeyRef == nil {
+			t.Errorf("Sensitive value should use secretKeyRef: %v", env[0])
+		}
+		if env[1].Name != "POSTGRES_USERNAME" || env[1].ValueFrom != nil || env[1].Value != "postgres" {
+			t.Errorf("Non-sensitive value should be inlined: %v", env[1])
+		}
+	})
+
+	t.Run("io.servicebindingrequest config map descriptor", func(t *testing.T) {
+		ioCSV := csv.DeepCopy()
+		ioCSV.Spec.CustomResourceDefinitions.Owned[0].SpecDescriptors[1].XDescriptors = []string{
+			"urn:alm:descriptor:io.servicebindingrequest:configmap:username",
+		}
+		cl := fake.NewFakeClient(sbr.DeepCopy(), ioCSV, secret, cm, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: namespace,
+		}
+		err = r.client.Get(context.TODO(), nn, dpOut)
+		if err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		env := dpOut.Spec.Template.Spec.Containers[0].Env
+		if len(env) != 2 {
+			t.Fatalf("Expected two environment variables, found: %v", env)
+		}
+		if env[1].Name != "POSTGRES_USERNAME" || env[1].Value != "postgres" {
+			t.Errorf("Config map value should be read from the config map: %v", env[1])
+		}
+	})
+
+	t.Run("config map not found", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, err := r.Reconcile(req)
+		if err == nil {
+			t.Fatalf("reconcile worked without the config map to inline")
+		}
+	})
+}
+
+func TestForceRebindServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				forceRebindAnnotation: "2019-06-01T10:00:00Z",
+				"owner":               "team-a",
+			},
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// the deployment lost its binding, e.g. edited by hand
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	cl := fake.NewFakeClient(sbr, csv, secret, dp)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+	err = r.client.Get(context.TODO(), nn, dpOut)
+	if err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if len(dpOut.Spec.Template.Spec.Containers[0].Env) != 1 {
+		t.Errorf("Deployment not bound again: %v", dpOut.Spec.Template.Spec.Containers[0].Env)
+	}
+
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sbrOut)
+	if err != nil {
+		t.Fatalf("get service binding request: (%v)", err)
+	}
+	if _, ok := sbrOut.GetAnnotations()[forceRebindAnnotation]; ok {
+		t.Errorf("Annotation '%s' was not cleared", forceRebindAnnotation)
+	}
+	if sbrOut.GetAnnotations()["owner"] != "team-a" {
+		t.Errorf("Other annotations should be kept: %v", sbrOut.GetAnnotations())
+	}
+}
+
+func TestSourceSecretFoundServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+
+	t.Run("secret not found", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		res, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		// retried without waiting for the secret to be created
+		if res.RequeueAfter != notReadyMinDelay {
+			t.Errorf("Expected a requeue after %v, found %+v", notReadyMinDelay, res)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err = r.client.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		cond := getCondition(&sbrOut.Status, v1alpha1.SourceSecretFound)
+		if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "SecretNotFound" {
+			t.Errorf("SourceSecretFound condition not matching: %v", cond)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		if err = r.client.Get(context.TODO(), nn, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		if len(dpOut.Spec.Template.Spec.Containers[0].Env) != 0 {
+			t.Errorf("Deployment should not be bound: %v", dpOut.Spec.Template.Spec.Containers[0].Env)
+		}
+	})
+
+	t.Run("secret found", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := r.client.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		cond := getCondition(&sbrOut.Status, v1alpha1.SourceSecretFound)
+		if cond == nil || cond.Status != corev1.ConditionTrue {
+			t.Errorf("SourceSecretFound condition not matching: %v", cond)
+		}
+	})
+}
+
+func TestConsumedKeysServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dpTemplate := func(name string, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Annotations: annotations,
+				Labels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "app"},
+						},
+					},
+				},
+			},
+		}
+	}
+	reporting := dpTemplate("my-app", map[string]string{
+		consumedKeysAnnotation: " POSTGRES_PASSWORD, ,POSTGRES_USERNAME",
+	})
+	silent := dpTemplate("other-app", nil)
+
+	cl := fake.NewFakeClient(sbr, csv, secret, reporting, silent)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := r.client.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get service binding request: (%v)", err)
+	}
+
+	consumed := sbrOut.Status.ConsumedKeys
+	if len(consumed) != 1 || consumed[0].Application != "my-app" {
+		t.Fatalf("Consumed keys not matching: %v", consumed)
+	}
+	keys := consumed[0].Keys
+	if len(keys) != 2 || keys[0] != "POSTGRES_PASSWORD" || keys[1] != "POSTGRES_USERNAME" {
+		t.Errorf("Consumed keys not matching: %v", keys)
+	}
+}
+
+func TestStableEnvServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:username",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Env: []corev1.EnvVar{
+								{Name: "LOG_LEVEL", Value: "debug"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cl := &conflictingClient{Client: fake.NewFakeClient(sbr, csv, secret, dp)}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+
+	reconcileEnv := func() []byte {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		dpOut := &appsv1.Deployment{}
+		if err := r.client.Get(context.TODO(), nn, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		env, err := json.Marshal(dpOut.Spec.Template.Spec.Containers[0].Env)
+		if err != nil {
+			t.Fatalf("marshal environment: (%v)", err)
+		}
+		return env
+	}
+
+	first := reconcileEnv()
+	second := reconcileEnv()
+	if !bytes.Equal(first, second) {
+		t.Errorf("Environment changed between reconciles:\n%s\n%s", first, second)
+	}
+	if cl.updates != 1 {
+		t.Errorf("Expected a single deployment update, found %d", cl.updates)
+	}
+
+	env := []corev1.EnvVar{}
+	if err := json.Unmarshal(first, &env); err != nil {
+		t.Fatalf("unmarshal environment: (%v)", err)
+	}
+	if len(env) != 3 || env[0].Name != "LOG_LEVEL" || env[1].Name != "POSTGRES_PASSWORD" || env[2].Name != "POSTGRES_USERNAME" {
+		t.Errorf("Environment not matching: %v", env)
+	}
+}
+
+func TestRefsServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{},
+				Refs: []v1alpha1.NamespacedRef{
+					{Namespace: "payments", Name: "checkout"},
+					{Namespace: "shipping", Name: "tracker", Kind: "DeploymentConfig"},
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"password": []byte("secret"),
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+	// Add DeploymentConfig scheme
+	if err := osappsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add DeploymentConfig scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	podTemplate := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app"},
+			},
+		},
+	}
+	checkout := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "payments"},
+		Spec:       appsv1.DeploymentSpec{Template: *podTemplate.DeepCopy()},
+	}
+	unreferenced := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "refunds", Namespace: "payments"},
+		Spec:       appsv1.DeploymentSpec{Template: *podTemplate.DeepCopy()},
+	}
+	tracker := &osappsv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "tracker", Namespace: "shipping"},
+		Spec:       osappsv1.DeploymentConfigSpec{Template: podTemplate.DeepCopy()},
+	}
+
+	cl := fake.NewFakeClient(sbr, csv, secret, checkout, unreferenced, tracker)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: "payments", Name: "checkout"}, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if len(dpOut.Spec.Template.Spec.Containers[0].Env) != 1 {
+		t.Errorf("Deployment 'checkout' not bound: %v", dpOut.Spec.Template.Spec.Containers[0].Env)
+	}
+
+	dpOut = &appsv1.Deployment{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: "payments", Name: "refunds"}, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if len(dpOut.Spec.Template.Spec.Containers[0].Env) != 0 {
+		t.Errorf("Deployment 'refunds' should not be bound: %v", dpOut.Spec.Template.Spec.Containers[0].Env)
+	}
+
+	dcOut := &osappsv1.DeploymentConfig{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: "shipping", Name: "tracker"}, dcOut); err != nil {
+		t.Fatalf("get deployment config: (%v)", err)
+	}
+	if len(dcOut.Spec.Template.Spec.Containers[0].Env) != 1 {
+		t.Errorf("DeploymentConfig 'tracker' not bound: %v", dcOut.Spec.Template.Spec.Containers[0].Env)
+	}
+
+	for _, ns := range []string{"payments", "shipping"} {
+		mirrored := &corev1.Secret{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: "db-credentials"}, mirrored); err != nil {
+			t.Fatalf("get mirrored secret: (%v)", err)
+		}
+		if string(mirrored.Data["password"]) != "secret" {
+			t.Errorf("Mirrored secret data not matching in '%s': %v", ns, mirrored.Data)
+		}
+		if mirrored.GetLabels()[mirroredFromLabel] != namespace {
+			t.Errorf("Mirrored secret label not matching in '%s': %v", ns, mirrored.GetLabels())
+		}
+	}
+
+	// reconciling again leaves the mirrored copies alone
+	counting := &countingClient{Client: cl}
+	r.client = counting
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if counting.secretUpdates != 0 {
+		t.Errorf("Expected no secret update, found %d", counting.secretUpdates)
+	}
+
+	// the mirrored copies are updated with the source data
+	r.client = cl
+	secret.Data["password"] = []byte("rotated")
+	if err := r.client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("update secret: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	mirrored := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: "payments", Name: "db-credentials"}, mirrored); err != nil {
+		t.Fatalf("get mirrored secret: (%v)", err)
+	}
+	if string(mirrored.Data["password"]) != "rotated" {
+		t.Errorf("Mirrored secret not updated: %v", mirrored.Data)
+	}
+
+	// the mirrored copies, not garbage collected across namespaces, are deleted along with the
+	// ServiceBindingRequest
+	r.client = &selectingClient{Client: cl}
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	now := metav1.Now()
+	sbrOut.SetDeletionTimestamp(&now)
+	if err := cl.Update(context.TODO(), sbrOut); err != nil {
+		t.Fatalf("mark sbr deleted: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile deletion: (%v)", err)
+	}
+	for _, ns := range []string{"payments", "shipping"} {
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: "db-credentials"}, &corev1.Secret{})
+		if !errors.IsNotFound(err) {
+			t.Errorf("Expected the mirrored secret deleted in '%s', got (%v)", ns, err)
+		}
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "db-credentials"}, &corev1.Secret{}); err != nil {
+		t.Errorf("Expected the source secret kept, got (%v)", err)
+	}
+}
+
+func TestEnvMapServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:user",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+									"urn:alm:descriptor:servicebindingrequest:secret:host",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						envMapAnnotation: "user=DB_USERNAME,password=DB_PASSWORD",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	cl := fake.NewFakeClient(sbr, csv, secret, dp)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+	if err := r.client.Get(context.TODO(), nn, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+
+	keys := map[string]string{}
+	for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+		keys[env.Name] = env.ValueFrom.SecretKeyRef.Key
+	}
+	expected := map[string]string{
+		"DB_USERNAME":   "user",
+		"DB_PASSWORD":   "password",
+		"POSTGRES_HOST": "host",
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Environment not matching: %v", keys)
+	}
+}
+
+func TestAllowedKindsServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		daemonSetName       = "log-shipper"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+				ResourceKind: "DaemonSet",
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add DaemonSet scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add DaemonSet scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      daemonSetName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	nn := types.NamespacedName{
+		Name:      daemonSetName,
+		Namespace: namespace,
+	}
+
+	t.Run("disallowed kind", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, ds.DeepCopy())
+		r := &ReconcileServiceBindingRequest{
+			client:       cl,
+			scheme:       s,
+			recorder:     &record.FakeRecorder{},
+			allowedKinds: parseAllowedKinds(" Deployment, StatefulSet ,"),
+		}
+
+		res, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if res.Requeue {
+			t.Error("reconcile requeued a disallowed kind")
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err = r.client.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		cond := getCondition(&sbrOut.Status, v1alpha1.InjectionReady)
+		if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "KindNotAllowed" {
+			t.Errorf("InjectionReady condition not matching: %v", cond)
+		}
+
+		dsOut := &appsv1.DaemonSet{}
+		if err = r.client.Get(context.TODO(), nn, dsOut); err != nil {
+			t.Fatalf("get daemonset: (%v)", err)
+		}
+		if len(dsOut.Spec.Template.Spec.Containers[0].Env) != 0 {
+			t.Errorf("DaemonSet should not be bound: %v", dsOut.Spec.Template.Spec.Containers[0].Env)
+		}
+	})
+
+	t.Run("allowed kind", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, ds.DeepCopy())
+		r := &ReconcileServiceBindingRequest{
+			client:       cl,
+			scheme:       s,
+			recorder:     &record.FakeRecorder{},
+			allowedKinds: parseAllowedKinds("Deployment,DaemonSet"),
+		}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dsOut := &appsv1.DaemonSet{}
+		if err := r.client.Get(context.TODO(), nn, dsOut); err != nil {
+			t.Fatalf("get daemonset: (%v)", err)
+		}
+		if len(dsOut.Spec.Template.Spec.Containers[0].Env) != 1 {
+			t.Errorf("DaemonSet not bound: %v", dsOut.Spec.Template.Spec.Containers[0].Env)
+		}
+	})
+}
+
+func TestValidationFailedServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		selector v1alpha1.ApplicationSelector
+		message  string
+	}{
+		{
+			name: "unsupported kind",
+			// bound as a Deployment before, the default of the kinds not known
+			selector: v1alpha1.ApplicationSelector{
+				MatchLabels:  map[string]string{"connects-to": "postgres"},
+				ResourceKind: "Pod",
+			},
+			message: `spec.applicationSelector.resourceKind: Unsupported value: "Pod"`,
+		},
+		{
+			name:     "empty selector",
+			selector: v1alpha1.ApplicationSelector{},
+			message:  "spec.applicationSelector.matchLabels: Required value",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sbrIn := sbr.DeepCopy()
+			sbrIn.Spec.ApplicationSelector = test.selector
+			cl := fake.NewFakeClient(sbrIn, csv, secret, dp.DeepCopy())
+			r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+			res, err := r.Reconcile(req)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if res.Requeue || res.RequeueAfter != 0 {
+				t.Errorf("Expected no requeue of an invalid spec, found %+v", res)
+			}
+
+			sbrOut := &v1alpha1.ServiceBindingRequest{}
+			if err = cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+				t.Fatalf("get service binding request: (%v)", err)
+			}
+			cond := getCondition(&sbrOut.Status, v1alpha1.InjectionReady)
+			if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "ValidationFailed" ||
+				!strings.HasPrefix(cond.Message, test.message) {
+				t.Errorf("InjectionReady condition not matching: %+v", cond)
+			}
+			if sbrOut.Status.Phase != v1alpha1.PhaseError {
+				t.Errorf("Expected the Error phase, found '%s'", sbrOut.Status.Phase)
+			}
+
+			dpOut := &appsv1.Deployment{}
+			if err = cl.Get(context.TODO(), types.NamespacedName{Name: deploymentName, Namespace: namespace}, dpOut); err != nil {
+				t.Fatalf("get deployment: (%v)", err)
+			}
+			if env := dpOut.Spec.Template.Spec.Containers[0].Env; len(env) != 0 {
+				t.Errorf("Deployment should not be bound: %v", env)
+			}
+		})
+	}
+}
+
+func TestGenericWorkloadServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+				Group:          "example.org",
+				Version:        "v1",
+				ResourceKind:   "Workload",
+				ContainersPath: "spec.containers",
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// the fake client needs the list kind of the workload to be known
+	workloadGV := schema.GroupVersion{Group: "example.org", Version: "v1"}
+	s.AddKnownTypeWithName(workloadGV.WithKind("Workload"), &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(workloadGV.WithKind("WorkloadList"), &workloadList{})
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	workload := func(name string, labels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "example.org/v1",
+				"kind":       "Workload",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": namespace,
+					"labels":    labels,
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(2),
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "quay.io/example/app:latest",
+						},
+					},
+				},
+			},
+		}
+	}
+	matching := workload("my-app", map[string]interface{}{"connects-to": "postgres"})
+	other := workload("other-app", map[string]interface{}{"connects-to": "mysql"})
+
+	cl := &selectingClient{Client: fake.NewFakeClient(sbr, csv, secret, matching, other)}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	for workloadName, bound := range map[string]bool{"my-app": true, "other-app": false} {
+		out := &unstructured.Unstructured{}
+		out.SetGroupVersionKind(workloadGV.WithKind("Workload"))
+		nn := types.NamespacedName{Name: workloadName, Namespace: namespace}
+		if err := r.client.Get(context.TODO(), nn, out); err != nil {
+			t.Fatalf("get workload: (%v)", err)
+		}
+
+		containers, _, err := unstructured.NestedSlice(out.Object, "spec", "containers")
+		if err != nil || len(containers) != 1 {
+			t.Fatalf("Containers not matching: %v (%v)", containers, err)
+		}
+		container := &corev1.Container{}
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(containers[0].(map[string]interface{}), container)
+		if err != nil {
+			t.Fatalf("convert container: (%v)", err)
+		}
+
+		if !bound {
+			if len(container.Env) != 0 {
+				t.Errorf("Workload '%s' should not be bound: %v", workloadName, container.Env)
+			}
+			continue
+		}
+		if len(container.Env) != 1 || container.Env[0].Name != "POSTGRES_PASSWORD" {
+			t.Errorf("Workload '%s' not bound: %v", workloadName, container.Env)
+		}
+		if container.Image != "quay.io/example/app:latest" {
+			t.Errorf("Container image not kept: %s", container.Image)
+		}
+		if replicas, _, _ := unstructured.NestedInt64(out.Object, "spec", "replicas"); replicas != 2 {
+			t.Errorf("Workload spec not kept: %v", out.Object["spec"])
+		}
+	}
+}
+
+func TestBindAsFilesServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			BindAsFiles: true,
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						Kind: "SpecialDB",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:username",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+							{
+								Path: "db-config",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:configmap:host",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+						{Name: "sidecar"},
+					},
+				},
+			},
+		},
+	}
+
+	cl := &conflictingClient{Client: fake.NewFakeClient(sbr, csv, secret, dp)}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+	if cl.updates != 1 {
+		t.Errorf("Expected a single deployment update, found %d", cl.updates)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	nn := types.NamespacedName{Name: deploymentName, Namespace: namespace}
+	if err := r.client.Get(context.TODO(), nn, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	podSpec := dpOut.Spec.Template.Spec
+
+	if len(podSpec.Volumes) != 1 {
+		t.Fatalf("Expected a single volume, found %v", podSpec.Volumes)
+	}
+	volume := podSpec.Volumes[0]
+	if volume.Name != name || volume.Projected == nil || len(volume.Projected.Sources) != 3 {
+		t.Fatalf("Volume not matching: %v", volume)
+	}
+	sources := volume.Projected.Sources
+	if sources[0].Secret == nil || sources[0].Secret.Name != "db-credentials" || len(sources[0].Secret.Items) != 2 {
+		t.Errorf("Secret projection not matching: %v", sources[0])
+	}
+	if sources[1].ConfigMap == nil || sources[1].ConfigMap.Name != "db-config" || len(sources[1].ConfigMap.Items) != 1 {
+		t.Errorf("Config map projection not matching: %v", sources[1])
+	}
+	// the type and provider files, from the kind of the backing service
+	expectedItems := []corev1.KeyToPath{{Key: "type", Path: "type"}, {Key: "provider", Path: "provider"}}
+	if sources[2].Secret == nil || sources[2].Secret.Name != "postgres-flattened" || !reflect.DeepEqual(sources[2].Secret.Items, expectedItems) {
+		t.Errorf("Type and provider projection not matching: %v", sources[2])
+	}
+	typed := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}, typed); err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	expectedData := map[string][]byte{"type": []byte("specialdb"), "provider": []byte("example.org")}
+	if !reflect.DeepEqual(typed.Data, expectedData) {
+		t.Errorf("Type and provider not matching: %s", typed.Data)
+	}
+
+	for _, c := range podSpec.Containers {
+		expected := []corev1.EnvVar{{Name: "SERVICE_BINDING_ROOT", Value: "/bindings"}}
+		if !reflect.DeepEqual(c.Env, expected) {
+			t.Errorf("Environment of container '%s' not matching: %v", c.Name, c.Env)
+		}
+		if len(c.VolumeMounts) != 1 || c.VolumeMounts[0].Name != name || c.VolumeMounts[0].MountPath != "/bindings/postgres" {
+			t.Errorf("Volume mounts of container '%s' not matching: %v", c.Name, c.VolumeMounts)
+		}
+	}
+}
+
+func TestApplicationValuesServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			ApplicationValues: []v1alpha1.ApplicationValue{
+				{Key: "host", APIVersion: "route.openshift.io/v1", Kind: "Route", Name: deploymentName, Path: "ingress[0].host"},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+	routeGVK := schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+	s.AddKnownTypeWithName(routeGVK, &unstructured.Unstructured{})
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	// route returns the Route of the application, admitted with the host when set.
+	route := func(host string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"to": map[string]interface{}{"kind": "Service", "name": deploymentName}},
+		}}
+		u.SetGroupVersionKind(routeGVK)
+		u.SetNamespace(namespace)
+		u.SetName(deploymentName)
+		if host != "" {
+			u.Object["status"] = map[string]interface{}{
+				"ingress": []interface{}{map[string]interface{}{"host": host}},
+			}
+		}
+		return u
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	t.Run("admitted route", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp.DeepCopy(), route("my-app-default.apps.example.com"))
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}, sources: newSourceIndex()}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		values := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}, values); err != nil {
+			t.Fatalf("get secret: (%v)", err)
+		}
+		if host := string(values.Data["host"]); host != "my-app-default.apps.example.com" {
+			t.Errorf("Expected the host of the route in the secret, found '%s'", host)
+		}
+
+		// the value copied isn't bound into the application
+		dpOut := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: deploymentName, Namespace: namespace}, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		if env := dpOut.Spec.Template.Spec.Containers[0].Env; len(env) != 1 || env[0].Name != "POSTGRES_PASSWORD" {
+			t.Errorf("Environment not matching: %v", env)
+		}
+
+		// a change of the route, e.g. its host, requeues the ServiceBindingRequest
+		key := sourceKey{kind: routeGVK.GroupKind().String(), namespace: namespace, name: deploymentName}
+		if requests := r.sources.requests(key); len(requests) != 1 || requests[0] != req {
+			t.Errorf("Expected the route to requeue the ServiceBindingRequest, found %v", requests)
+		}
+	})
+
+	t.Run("route not admitted yet", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp.DeepCopy(), route(""))
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+		res, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if res.RequeueAfter != notReadyMinDelay {
+			t.Errorf("Expected a requeue after %v, found %+v", notReadyMinDelay, res)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err = cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		cond := getCondition(&sbrOut.Status, v1alpha1.CollectionReady)
+		if cond == nil || cond.Status != corev1.ConditionUnknown || cond.Reason != "CollectionNotReady" {
+			t.Errorf("CollectionReady condition not matching: %+v", cond)
+		}
+	})
+}
+
+func TestEventsServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// reasons returns the reasons of the events recorded so far.
+	reasons := func(recorder *record.FakeRecorder) []string {
+		found := []string{}
+		for len(recorder.Events) > 0 {
+			found = append(found, strings.Split(<-recorder.Events, " ")[1])
+		}
+		return found
+	}
+
+	t.Run("bound", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(10)
+		cl := &selectingClient{Client: fake.NewFakeClient(sbr, csv, secret, dp)}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: recorder}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected := []string{"BindingStarted", "ApplicationBound"}
+		if found := reasons(recorder); !reflect.DeepEqual(found, expected) {
+			t.Errorf("Events not matching: expected %v, got %v", expected, found)
+		}
+
+		// nothing happens once bound
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if found := reasons(recorder); len(found) != 0 {
+			t.Errorf("Expected no events, got %v", found)
+		}
+	})
+
+	t.Run("no matching application", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(10)
+		cl := &selectingClient{Client: fake.NewFakeClient(sbr, csv, secret)}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: recorder}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected := []string{"BindingStarted", "NoMatchingApplication"}
+		if found := reasons(recorder); !reflect.DeepEqual(found, expected) {
+			t.Errorf("Events not matching: expected %v, got %v", expected, found)
+		}
+
+		// the binding started once, the phase doesn't change into Binding again
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected = []string{"NoMatchingApplication"}
+		if found := reasons(recorder); !reflect.DeepEqual(found, expected) {
+			t.Errorf("Events not matching: expected %v, got %v", expected, found)
+		}
+	})
+
+	t.Run("kind not allowed", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(10)
+		cl := &selectingClient{Client: fake.NewFakeClient(sbr, csv, secret, dp)}
+		r := &ReconcileServiceBindingRequest{
+			client:       cl,
+			scheme:       s,
+			recorder:     recorder,
+			allowedKinds: parseAllowedKinds("StatefulSet"),
+		}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected := []string{"BindingStarted", "KindNotAllowed"}
+		if found := reasons(recorder); !reflect.DeepEqual(found, expected) {
+			t.Errorf("Events not matching: expected %v, got %v", expected, found)
+		}
+
+		// the binding started once, the phase doesn't change into Binding again
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected = []string{"KindNotAllowed"}
+		if found := reasons(recorder); !reflect.DeepEqual(found, expected) {
+			t.Errorf("Events not matching: expected %v, got %v", expected, found)
+		}
+	})
+}
+
+func TestUnbindServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:username",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							// the variables of the application may share the prefix of the binding
+							Env: []corev1.EnvVar{
+								{Name: "LOG_LEVEL", Value: "debug"},
+								{Name: "POSTGRES_HOME", Value: "/opt/postgres"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+
+	for _, bindAsFiles := range []bool{false, true} {
+		t.Run(fmt.Sprintf("bindAsFiles=%v", bindAsFiles), func(t *testing.T) {
+			sbrIn := sbr.DeepCopy()
+			sbrIn.Spec.BindAsFiles = bindAsFiles
+			cl := fake.NewFakeClient(sbrIn, csv, secret, dp.DeepCopy())
+			r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			sbrOut := &v1alpha1.ServiceBindingRequest{}
+			if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+				t.Fatalf("get sbr: (%v)", err)
+			}
+			if !hasFinalizer(sbrOut) {
+				t.Fatalf("Expected the finalizer to be set: %v", sbrOut.GetFinalizers())
+			}
+			dpOut := &appsv1.Deployment{}
+			if err := cl.Get(context.TODO(), nn, dpOut); err != nil {
+				t.Fatalf("get deployment: (%v)", err)
+			}
+			if reflect.DeepEqual(dpOut.Spec.Template.Spec, dp.Spec.Template.Spec) {
+				t.Fatal("Expected the deployment to be bound")
+			}
+
+			// the fake client deletes right away, the deletion is marked as the API server does
+			now := metav1.Now()
+			sbrOut.SetDeletionTimestamp(&now)
+			if err := cl.Update(context.TODO(), sbrOut); err != nil {
+				t.Fatalf("mark sbr deleted: (%v)", err)
+			}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile deletion: (%v)", err)
+			}
+
+			// reading into new objects, decoding keeps the fields absent from the stored ones
+			dpOut = &appsv1.Deployment{}
+			if err := cl.Get(context.TODO(), nn, dpOut); err != nil {
+				t.Fatalf("get deployment: (%v)", err)
+			}
+			if !reflect.DeepEqual(dpOut.Spec.Template.Spec, dp.Spec.Template.Spec) {
+				t.Errorf("Pod spec not matching: expected %+v, got %+v", dp.Spec.Template.Spec, dpOut.Spec.Template.Spec)
+			}
+			if annotations := dpOut.Spec.Template.GetAnnotations(); len(annotations) != 0 {
+				t.Errorf("Expected the bound envs annotation removed, got %v", annotations)
+			}
+			sbrOut = &v1alpha1.ServiceBindingRequest{}
+			if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+				t.Fatalf("get sbr: (%v)", err)
+			}
+			if hasFinalizer(sbrOut) {
+				t.Errorf("Expected the finalizer to be removed: %v", sbrOut.GetFinalizers())
+			}
+		})
+	}
+}
+
+func TestBackingServicesServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "backing-operator.v0.1.0"
+		name                = "backends"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "postgresql.example.org",
+			},
+			BackingServices: []v1alpha1.BackingSelector{
+				{ResourceName: "redis.example.org"},
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	dbSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+	redisSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "redis-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "postgresql.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:username",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+					{
+						Name: "redis.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "redis-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}},
+				},
+			},
+		},
+	}
+
+	cl := fake.NewFakeClient(sbr, csv, dbSecret, redisSecret, dp)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	nn := types.NamespacedName{Name: deploymentName, Namespace: namespace}
+	if err := r.client.Get(context.TODO(), nn, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+
+	// secrets by the environment variable names
+	expected := map[string]string{
+		"BACKENDS_USERNAME":       "db-credentials",
+		"BACKENDS_PASSWORD":       "db-credentials",
+		"BACKENDS_REDIS_PASSWORD": "redis-credentials",
+	}
+	env := dpOut.Spec.Template.Spec.Containers[0].Env
+	if len(env) != len(expected) {
+		t.Fatalf("Environment not matching: %v", env)
+	}
+	for _, ev := range env {
+		if ev.ValueFrom == nil || ev.ValueFrom.SecretKeyRef == nil || ev.ValueFrom.SecretKeyRef.Name != expected[ev.Name] {
+			t.Errorf("Environment variable '%s' not matching: %v", ev.Name, ev.ValueFrom)
+		}
+	}
+}
+
+func TestEnvVarPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		expected string
+	}{
+		{name: "my-db", expected: "MY_DB_"},
+		{name: "my-db", prefix: "DATABASE_", expected: "DATABASE_"},
+	}
+	for _, test := range tests {
+		sbr := &v1alpha1.ServiceBindingRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: test.name},
+			Spec:       v1alpha1.ServiceBindingRequestSpec{EnvVarPrefix: test.prefix},
+		}
+		if prefix := envVarPrefix(sbr); prefix != test.expected {
+			t.Errorf("Prefix not matching: expected '%s', got '%s'", test.expected, prefix)
+		}
+	}
+}
+
+func TestEnvVarPrefixServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:username",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Env: []corev1.EnvVar{
+								{Name: "LOG_LEVEL", Value: "debug"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "", expected: []string{"LOG_LEVEL", "POSTGRES_PASSWORD", "POSTGRES_USERNAME"}},
+		{prefix: "DATABASE_", expected: []string{"LOG_LEVEL", "DATABASE_PASSWORD", "DATABASE_USERNAME"}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("prefix=%s", test.prefix), func(t *testing.T) {
+			sbrIn := sbr.DeepCopy()
+			sbrIn.Spec.EnvVarPrefix = test.prefix
+			cl := fake.NewFakeClient(sbrIn, csv, secret, dp.DeepCopy())
+			r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			dpOut := &appsv1.Deployment{}
+			if err := cl.Get(context.TODO(), nn, dpOut); err != nil {
+				t.Fatalf("get deployment: (%v)", err)
+			}
+
+			names := []string{}
+			for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+				names = append(names, env.Name)
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef.Name != "db-credentials" {
+					t.Errorf("Environment variable '%s' not matching: %v", env.Name, env.ValueFrom)
+				}
+				// only the names are prefixed, the secret keys aren't renamed
+				if env.ValueFrom != nil && !strings.HasSuffix(env.Name, "_"+strings.ToUpper(env.ValueFrom.SecretKeyRef.Key)) {
+					t.Errorf("Environment variable '%s' references key '%s'", env.Name, env.ValueFrom.SecretKeyRef.Key)
+				}
+			}
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("Environment not matching: expected %v, got %v", test.expected, names)
+			}
+
+			// binding again matches the prefixed variables already injected, adding none
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			rebound := &appsv1.Deployment{}
+			if err := cl.Get(context.TODO(), nn, rebound); err != nil {
+				t.Fatalf("get deployment: (%v)", err)
+			}
+			if env := rebound.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(env, dpOut.Spec.Template.Spec.Containers[0].Env) {
+				t.Errorf("Expected binding again to leave the environment unchanged, found %v", env)
+			}
+		})
+	}
+}
+
+func TestMappingsServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:user",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Env: []corev1.EnvVar{
+								{Name: "LOG_LEVEL", Value: "debug"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+
+	sbr.Spec.Mappings = []v1alpha1.Mapping{{From: "user", To: "username"}}
+	cl := fake.NewFakeClient(sbr, csv, secret, dp)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	dpOut := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), nn, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+
+	// the renamed variable keeps referencing the key of the secret
+	keys := map[string]string{}
+	for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+		if env.ValueFrom != nil {
+			keys[env.Name] = env.ValueFrom.SecretKeyRef.Key
+		}
+	}
+	expected := map[string]string{"POSTGRES_USERNAME": "user", "POSTGRES_PASSWORD": "password"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Environment not matching: expected %v, got %v", expected, keys)
+	}
+}
+
+func TestComposedMappingsServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path: "db-config",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:configmap:host",
+									"urn:alm:descriptor:servicebindingrequest:configmap:port",
+									"urn:alm:descriptor:servicebindingrequest:configmap:database",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"host":     "db.example.org",
+			"port":     "5432",
+			"database": "orders",
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Env: []corev1.EnvVar{
+								{Name: "LOG_LEVEL", Value: "debug"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	nn := types.NamespacedName{
+		Name:      deploymentName,
+		Namespace: namespace,
+	}
+
+	reconcileMapping := func(t *testing.T, mapping v1alpha1.Mapping) (*appsv1.Deployment, *v1alpha1.ServiceBindingRequest, error) {
+		sbrIn := sbr.DeepCopy()
+		sbrIn.Spec.Mappings = []v1alpha1.Mapping{mapping}
+		cl := fake.NewFakeClient(sbrIn, csv, secret, cm, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		_, reconcileErr := r.Reconcile(req)
+		dpOut := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), nn, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		return dpOut, sbrOut, reconcileErr
+	}
+
+	t.Run("composed value", func(t *testing.T) {
+		dpOut, _, err := reconcileMapping(t, v1alpha1.Mapping{
+			To:    "jdbc-url",
+			Value: "jdbc:postgresql://{{ .host }}:{{ .port }}/{{ .database }}",
+		})
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		found := false
+		for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+			if env.Name == "POSTGRES_JDBC_URL" {
+				found = true
+				if env.Value != "jdbc:postgresql://db.example.org:5432/orders" {
+					t.Errorf("Composed value not matching: %s", env.Value)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Composed value not bound: %v", dpOut.Spec.Template.Spec.Containers[0].Env)
+		}
+	})
+
+	t.Run("composed value written into the secret", func(t *testing.T) {
+		sbrIn := sbr.DeepCopy()
+		sbrIn.Spec.Mappings = []v1alpha1.Mapping{{
+			To:     "dsn",
+			Value:  "postgres://{{ .host }}:{{ .port }}/{{ .database }}",
+			Secret: true,
+		}}
+		cl := fake.NewFakeClient(sbrIn, csv, secret, cm.DeepCopy(), dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+		flattenedKey := types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}
+		reconcileSecret := func(t *testing.T) map[string]string {
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			out := &corev1.Secret{}
+			if err := cl.Get(context.TODO(), flattenedKey, out); err != nil {
+				t.Fatalf("get flattened secret: (%v)", err)
+			}
+			data := map[string]string{}
+			for key, value := range out.Data {
+				data[key] = string(value)
+			}
+			return data
+		}
+
+		// both the composed value and its parts are found in the secret
+		expected := map[string]string{
+			"dsn":      "postgres://db.example.org:5432/orders",
+			"host":     "db.example.org",
+			"port":     "5432",
+			"database": "orders",
+		}
+		if data := reconcileSecret(t); !reflect.DeepEqual(data, expected) {
+			t.Errorf("Secret not matching: expected %v, got %v", expected, data)
+		}
+		dpOut := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), nn, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		found := false
+		for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+			if env.Name == "POSTGRES_DSN" {
+				found = true
+				if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil ||
+					env.ValueFrom.SecretKeyRef.Name != "postgres-flattened" || env.ValueFrom.SecretKeyRef.Key != "dsn" {
+					t.Errorf("Expected POSTGRES_DSN to reference postgres-flattened/dsn, found %+v", env)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Composed value not bound: %v", dpOut.Spec.Template.Spec.Containers[0].Env)
+		}
+
+		// a part changing composes the value again
+		cmOut := &corev1.ConfigMap{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: "db-config", Namespace: namespace}, cmOut); err != nil {
+			t.Fatalf("get config map: (%v)", err)
+		}
+		cmOut.Data["host"] = "db-1.example.org"
+		if err := cl.Update(context.TODO(), cmOut); err != nil {
+			t.Fatalf("update config map: (%v)", err)
+		}
+		data := reconcileSecret(t)
+		if data["dsn"] != "postgres://db-1.example.org:5432/orders" || data["host"] != "db-1.example.org" {
+			t.Errorf("Expected the composed value and its part updated, found %v", data)
+		}
+	})
+
+	t.Run("secret key", func(t *testing.T) {
+		dpOut, sbrOut, err := reconcileMapping(t, v1alpha1.Mapping{
+			To:    "url",
+			Value: "postgres://postgres:{{ .password }}@{{ .host }}",
+		})
+		if err == nil || !strings.Contains(err.Error(), `map has no entry for key "password"`) {
+			t.Fatalf("Expected the missing key to fail the reconcile, got (%v)", err)
+		}
+		c := getCondition(&sbrOut.Status, v1alpha1.CollectionReady)
+		if c == nil || c.Status != corev1.ConditionFalse || c.Reason != "MappingFailed" {
+			t.Errorf("CollectionReady condition not matching: %+v", c)
+		}
+		if len(dpOut.Spec.Template.Spec.Containers[0].Env) != 1 {
+			t.Errorf("Deployment should not be bound: %v", dpOut.Spec.Template.Spec.Containers[0].Env)
+		}
+	})
+}
+
+func TestMappedKey(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			Mappings: []v1alpha1.Mapping{{From: "user", To: "username"}, {From: "user", To: "login"}},
+		},
+	}
+	for key, expected := range map[string]string{"user": "username", "password": "password"} {
+		if got := mappedKey(sbr, key); got != expected {
+			t.Errorf("Key '%s' not matching: expected '%s', got '%s'", key, expected, got)
+		}
+	}
+}
+
+func TestSecretKeyIncluded(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector v1alpha1.BackingSelector
+		want     map[string]bool
+	}{
+		{"all", v1alpha1.BackingSelector{}, map[string]bool{"password": true, "token": true}},
+		{"include", v1alpha1.BackingSelector{Include: []string{"password"}}, map[string]bool{"password": true, "token": false}},
+		{"exclude", v1alpha1.BackingSelector{Exclude: []string{"token"}}, map[string]bool{"password": true, "token": false}},
+		{
+			"exclude over include",
+			v1alpha1.BackingSelector{Include: []string{"password", "token"}, Exclude: []string{"token"}},
+			map[string]bool{"password": true, "token": false, "user": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, want := range tt.want {
+				if got := secretKeyIncluded(tt.selector, key); got != want {
+					t.Errorf("Key '%s': expected %v, got %v", key, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretKeysServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:username",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+									"urn:alm:descriptor:servicebindingrequest:secret:admin-token",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: namespace,
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{name: "all", expected: []string{"POSTGRES_ADMIN_TOKEN", "POSTGRES_PASSWORD", "POSTGRES_USERNAME"}},
+		{name: "include only", include: []string{"username", "password"}, expected: []string{"POSTGRES_PASSWORD", "POSTGRES_USERNAME"}},
+		{name: "exclude", exclude: []string{"admin-token"}, expected: []string{"POSTGRES_PASSWORD", "POSTGRES_USERNAME"}},
+		{
+			name:     "exclude over include",
+			include:  []string{"password", "admin-token"},
+			exclude:  []string{"admin-token"},
+			expected: []string{"POSTGRES_PASSWORD"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: v1alpha1.ServiceBindingRequestSpec{
+					BackingSelector: v1alpha1.BackingSelector{
+						ResourceName: "specialdb.example.org",
+						Include:      tt.include,
+						Exclude:      tt.exclude,
+					},
+					ApplicationSelector: v1alpha1.ApplicationSelector{
+						MatchLabels: map[string]string{"connects-to": "postgres"},
+					},
+				},
+			}
+			cl := fake.NewFakeClient(sbr, csv, secret, dp)
+			r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			dpOut := &appsv1.Deployment{}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Name: "my-app", Namespace: namespace}, dpOut); err != nil {
+				t.Fatalf("get deployment: (%v)", err)
+			}
+			names := []string{}
+			for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+				names = append(names, env.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("Environment not matching: expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestFlattenedMappingServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+		Data: map[string][]byte{
+			"connection": []byte(`{"host": "orders-db", "port": 5432, "auth": {"user": "admin"}}`),
+			"password":   []byte("secret"),
+		},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path: "db-credentials",
+								XDescriptors: []string{
+									"urn:alm:descriptor:servicebindingrequest:secret:connection",
+									"urn:alm:descriptor:servicebindingrequest:secret:password",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{ResourceName: "specialdb.example.org"},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+			Mappings: []v1alpha1.Mapping{{From: "connection", To: "db", Type: v1alpha1.MappingTypeJSON}},
+		},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: namespace,
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	cl := fake.NewFakeClient(sbr, csv, secret.DeepCopy(), dp)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "my-app", Namespace: namespace}, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	refs := map[string]string{}
+	for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+			t.Fatalf("Expected the secret keys to be referenced, found %+v", env)
+		}
+		refs[env.Name] = env.ValueFrom.SecretKeyRef.Name + "/" + env.ValueFrom.SecretKeyRef.Key
+	}
+	expected := map[string]string{
+		"POSTGRES_DB_AUTH_USER": "postgres-flattened/db-auth-user",
+		"POSTGRES_DB_HOST":      "postgres-flattened/db-host",
+		"POSTGRES_DB_PORT":      "postgres-flattened/db-port",
+		"POSTGRES_PASSWORD":     "db-credentials/password",
+	}
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("Environment not matching: expected %v, got %v", expected, refs)
+	}
+
+	flattened := &corev1.Secret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}, flattened); err != nil {
+		t.Fatalf("get flattened secret: (%v)", err)
+	}
+	data := map[string]string{}
+	for key, value := range flattened.Data {
+		data[key] = string(value)
+	}
+	if expected := map[string]string{"db-auth-user": "admin", "db-host": "orders-db", "db-port": "5432"}; !reflect.DeepEqual(data, expected) {
+		t.Errorf("Flattened secret not matching: expected %v, got %v", expected, data)
+	}
+	if owners := flattened.GetOwnerReferences(); len(owners) != 1 || owners[0].Kind != "ServiceBindingRequest" || owners[0].Name != name {
+		t.Errorf("Expected the flattened secret to be owned by the ServiceBindingRequest, found %v", owners)
+	}
+
+	// a rotated value, double base64 encoded this time, is flattened again
+	rotated := &corev1.Secret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "db-credentials", Namespace: namespace}, rotated); err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	rotated.Data["connection"] = []byte(base64.StdEncoding.EncodeToString([]byte(`{"host": "orders-db-1", "port": 5433, "auth": {"user": "admin"}}`)))
+	if err := cl.Update(context.TODO(), rotated); err != nil {
+		t.Fatalf("update secret: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	flattened = &corev1.Secret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}, flattened); err != nil {
+		t.Fatalf("get flattened secret: (%v)", err)
+	}
+	if host := string(flattened.Data["db-host"]); host != "orders-db-1" {
+		t.Errorf("Expected the rotated host to be flattened, found '%s'", host)
+	}
+
+	// with the mapping removed, no key is flattened and the secret is deleted
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	sbrOut.Spec.Mappings = nil
+	if err := cl.Update(context.TODO(), sbrOut); err != nil {
+		t.Fatalf("update sbr: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected the flattened secret deleted, got (%v)", err)
+	}
+}
+
+func TestFlattenedSecretNameServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+		Data:       map[string][]byte{"connection": []byte(`{"host": "orders-db"}`)},
+	}
+	appConfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: namespace},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:connection"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{ResourceName: "specialdb.example.org"},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+			Mappings:   []v1alpha1.Mapping{{From: "connection", To: "db", Type: v1alpha1.MappingTypeJSON}},
+			SecretName: "orders-binding",
+		},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: namespace,
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	cl := fake.NewFakeClient(sbr, csv, secret, appConfig, dp)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// the variables reference the secret written under the custom name
+	dpOut := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "my-app", Namespace: namespace}, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	env := dpOut.Spec.Template.Spec.Containers[0].Env
+	if len(env) != 1 || env[0].ValueFrom == nil || env[0].ValueFrom.SecretKeyRef == nil || env[0].ValueFrom.SecretKeyRef.Name != "orders-binding" {
+		t.Fatalf("Expected POSTGRES_DB_HOST to reference 'orders-binding', found %+v", env)
+	}
+	flattened := &corev1.Secret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "orders-binding", Namespace: namespace}, flattened); err != nil {
+		t.Fatalf("get flattened secret: (%v)", err)
+	}
+	if host := string(flattened.Data[env[0].ValueFrom.SecretKeyRef.Key]); host != "orders-db" {
+		t.Errorf("Expected the referenced key to hold 'orders-db', found '%s'", host)
+	}
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	if !reflect.DeepEqual(sbrOut.Status.Secrets, []string{"orders-binding"}) {
+		t.Errorf("Expected the secrets to be 'orders-binding', found %v", sbrOut.Status.Secrets)
+	}
+
+	// a secret the ServiceBindingRequest doesn't own is left alone
+	sbrOut.Spec.SecretName = "app-config"
+	if err := cl.Update(context.TODO(), sbrOut); err != nil {
+		t.Fatalf("update sbr: (%v)", err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	cond := getCondition(&sbrOut.Status, v1alpha1.CollectionReady)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "SecretNameConflict" {
+		t.Errorf("Expected the CollectionReady condition to be False with reason SecretNameConflict, found %+v", cond)
+	}
+	out := &corev1.Secret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "app-config", Namespace: namespace}, out); err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if !reflect.DeepEqual(out.Data, appConfig.Data) || len(out.GetOwnerReferences()) != 0 {
+		t.Errorf("Expected 'app-config' untouched, found %+v", out)
+	}
+}
+
+func TestRepairServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+		Data:       map[string][]byte{"connection": []byte(`{"host": "orders-db"}`)},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:connection"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{ResourceName: "specialdb.example.org"},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels:       map[string]string{"connects-to": "postgres"},
+				NamespaceSelector: map[string]string{"team": "orders"},
+			},
+			Mappings: []v1alpha1.Mapping{{From: "connection", To: "db", Type: v1alpha1.MappingTypeJSON}},
+		},
+	}
+	jobs := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "jobs", Labels: map[string]string{"team": "orders"}}}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: "jobs",
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	cl := &selectingClient{Client: fake.NewFakeClient(sbr, csv, secret, jobs, dp)}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}, sources: newSourceIndex()}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	flattenedKey := types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}
+	mirroredKey := types.NamespacedName{Name: "postgres-flattened", Namespace: "jobs"}
+	dpKey := types.NamespacedName{Name: "my-app", Namespace: "jobs"}
+	bound := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), dpKey, bound); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	// deleting the secret or its copy requeues the ServiceBindingRequest
+	for _, key := range []types.NamespacedName{flattenedKey, mirroredKey} {
+		if !r.sources.has(sourceKey{kind: "Secret", namespace: key.Namespace, name: key.Name}) {
+			t.Errorf("Expected the secret '%s' indexed", key)
+		}
+	}
+
+	// the secret, its copy and the binding of the application are removed by hand, e.g. while
+	// the operator was down
+	for _, key := range []types.NamespacedName{flattenedKey, mirroredKey} {
+		if err := cl.Delete(context.TODO(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}); err != nil {
+			t.Fatalf("delete secret: (%v)", err)
+		}
+	}
+	drifted := bound.DeepCopy()
+	drifted.Spec.Template.Spec.Containers[0].Env = nil
+	if err := cl.Update(context.TODO(), drifted); err != nil {
+		t.Fatalf("update deployment: (%v)", err)
+	}
+
+	// the operator restarted reconciles the ServiceBindingRequest again, repairing the binding
+	r = &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}, sources: newSourceIndex()}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	for _, key := range []types.NamespacedName{flattenedKey, mirroredKey} {
+		out := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), key, out); err != nil {
+			t.Fatalf("Expected the secret '%s' recreated, got (%v)", key, err)
+		}
+		if host := string(out.Data["db-host"]); host != "orders-db" {
+			t.Errorf("Expected the secret '%s' to hold 'orders-db', found %v", key, out.Data)
+		}
+	}
+	repaired := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), dpKey, repaired); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if env := repaired.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(env, bound.Spec.Template.Spec.Containers[0].Env) {
+		t.Errorf("Expected the binding injected again as %v, found %v", bound.Spec.Template.Spec.Containers[0].Env, env)
+	}
+}
+
+func TestSecretTooLargeServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	// a certificate bundle, flattened into a secret over the limit
+	bundle, err := json.Marshal(map[string]string{
+		"ca":   strings.Repeat("a", corev1.MaxSecretSize/2),
+		"cert": strings.Repeat("b", corev1.MaxSecretSize/2+1),
+	})
+	if err != nil {
+		t.Fatalf("marshal bundle: (%v)", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+		Data:       map[string][]byte{"tls": bundle},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:tls"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{ResourceName: "specialdb.example.org"},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+			Mappings: []v1alpha1.Mapping{{From: "tls", To: "db", Type: v1alpha1.MappingTypeJSON}},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+
+	cl := fake.NewFakeClient(sbr, csv, secret)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	// the spec or the backing service needs changing, the request isn't retried
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	cond := getCondition(&sbrOut.Status, v1alpha1.CollectionReady)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "SecretTooLarge" {
+		t.Errorf("Expected the CollectionReady condition to be False with reason SecretTooLarge, found %+v", cond)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: "postgres-flattened", Namespace: namespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected no flattened secret written, got (%v)", err)
+	}
+}
+
+func TestPartialBindServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{ResourceName: "specialdb.example.org"},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+		},
+	}
+	deployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{"connects-to": "postgres"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+				},
+			},
+		}
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	cl := &rejectingClient{
+		Client: fake.NewFakeClient(sbr, csv, secret, deployment("my-app"), deployment("other-app")),
+		name:   "my-app",
+	}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	if _, err := r.Reconcile(req); err == nil {
+		t.Fatal("Expected the failure binding 'my-app' to be returned")
+	}
+
+	// the other application is bound still
+	for app, expected := range map[string]int{"my-app": 0, "other-app": 1} {
+		dpOut := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: app, Namespace: namespace}, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		if env := dpOut.Spec.Template.Spec.Containers[0].Env; len(env) != expected {
+			t.Errorf("Expected %d variables bound in '%s', found %v", expected, app, env)
+		}
+	}
+
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	if !reflect.DeepEqual(sbrOut.Status.ApplicationObjects, []string{"default/other-app"}) || sbrOut.Status.BoundApplications != 1 {
+		t.Errorf("Expected only 'default/other-app' bound, found %v (%d)", sbrOut.Status.ApplicationObjects, sbrOut.Status.BoundApplications)
+	}
+	failures := sbrOut.Status.FailedApplications
+	if len(failures) != 1 || failures[0].Application != "default/my-app" || !strings.Contains(failures[0].Message, "denied by policy") {
+		t.Errorf("Expected 'default/my-app' failed, found %+v", failures)
+	}
+	cond := getCondition(&sbrOut.Status, v1alpha1.InjectionReady)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "InjectionFailed" {
+		t.Errorf("Expected the InjectionReady condition to be False with reason InjectionFailed, found %+v", cond)
+	}
+
+	// the failures are cleared once every application is bound
+	r.client = cl.Client
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	sbrOut = &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	if sbrOut.Status.BoundApplications != 2 || len(sbrOut.Status.FailedApplications) != 0 {
+		t.Errorf("Expected both applications bound, found %v, failed %+v", sbrOut.Status.ApplicationObjects, sbrOut.Status.FailedApplications)
+	}
+}
+
+func TestBackingSourcesServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			InlineNonSensitive: true,
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path:         "db-config",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:configmap:username"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"username": "postgres",
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	t.Run("rotated backing service data", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, cm.DeepCopy(), dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{
+			client:   cl,
+			scheme:   s,
+			recorder: &record.FakeRecorder{},
+			sources:  newSourceIndex(),
+		}
+
+		_, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		// the watches feed the changes of the secrets and config maps to the handlers
+		queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer queue.ShutDown()
+		other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: namespace}}
+		if r.sources.predicate("Secret").Update(event.UpdateEvent{MetaOld: other, ObjectOld: other, MetaNew: other, ObjectNew: other}) {
+			t.Error("Expected the events of a secret not read to be dropped")
+		}
+		if !r.sources.predicate("Secret").Update(event.UpdateEvent{MetaOld: secret, ObjectOld: secret, MetaNew: secret, ObjectNew: secret}) {
+			t.Error("Expected the events of the secret read to be kept")
+		}
+		r.sources.eventHandler("Secret").Update(event.UpdateEvent{MetaOld: other, ObjectOld: other, MetaNew: other, ObjectNew: other}, queue)
+		if queue.Len() != 0 {
+			t.Fatalf("Expected no request for a secret not read, found %d", queue.Len())
+		}
+		r.sources.eventHandler("Secret").Update(event.UpdateEvent{MetaOld: secret, ObjectOld: secret, MetaNew: secret, ObjectNew: secret}, queue)
+		if queue.Len() != 1 {
+			t.Fatalf("Expected the ServiceBindingRequest to be requeued by its secret, found %d requests", queue.Len())
+		}
+		item, _ := queue.Get()
+		queue.Done(item)
+		if item.(reconcile.Request) != req {
+			t.Fatalf("Expected the request of the ServiceBindingRequest, found %v", item)
+		}
+
+		rotated := cm.DeepCopy()
+		rotated.Data["username"] = "admin"
+		if err = cl.Update(context.TODO(), rotated); err != nil {
+			t.Fatalf("update config map: (%v)", err)
+		}
+		r.sources.eventHandler("ConfigMap").Update(event.UpdateEvent{MetaOld: cm, ObjectOld: cm, MetaNew: rotated, ObjectNew: rotated}, queue)
+		if queue.Len() != 1 {
+			t.Fatalf("Expected the ServiceBindingRequest to be requeued by its config map, found %d requests", queue.Len())
+		}
+		item, _ = queue.Get()
+		queue.Done(item)
+		if _, err = r.Reconcile(item.(reconcile.Request)); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: namespace,
+		}
+		if err = r.client.Get(context.TODO(), nn, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		env := dpOut.Spec.Template.Spec.Containers[0].Env
+		if len(env) != 2 || env[1].Name != "POSTGRES_USERNAME" || env[1].Value != "admin" {
+			t.Errorf("Expected the rotated value to be injected, found: %v", env)
+		}
+	})
+
+	t.Run("changed CSV descriptors", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv.DeepCopy(), secret, cm.DeepCopy(), dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{
+			client:   cl,
+			scheme:   s,
+			recorder: &record.FakeRecorder{},
+			sources:  newSourceIndex(),
+		}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer queue.ShutDown()
+		other := &olmv1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis-operator.v0.1.0", Namespace: namespace},
+			Spec: olmv1alpha1.ClusterServiceVersionSpec{
+				CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+					Owned: []olmv1alpha1.CRDDescription{{Name: "redis.example.org", Kind: "Redis"}},
+				},
+			},
+		}
+		r.sources.csvHandler().Update(event.UpdateEvent{MetaOld: other, ObjectOld: other, MetaNew: other, ObjectNew: other}, queue)
+		if queue.Len() != 0 {
+			t.Fatalf("Expected no request for a CSV of another backing service, found %d", queue.Len())
+		}
+
+		// the upgraded operator describes one more key of the secret
+		upgraded := csv.DeepCopy()
+		descriptors := &upgraded.Spec.CustomResourceDefinitions.Owned[0].SpecDescriptors[0]
+		descriptors.XDescriptors = append(descriptors.XDescriptors, "urn:alm:descriptor:servicebindingrequest:secret:user")
+		if err := cl.Update(context.TODO(), upgraded); err != nil {
+			t.Fatalf("update csv: (%v)", err)
+		}
+		r.sources.csvHandler().Update(event.UpdateEvent{MetaOld: csv, ObjectOld: csv, MetaNew: upgraded, ObjectNew: upgraded}, queue)
+		if queue.Len() != 1 {
+			t.Fatalf("Expected the ServiceBindingRequest to be requeued by its CSV, found %d requests", queue.Len())
+		}
+		item, _ := queue.Get()
+		queue.Done(item)
+		if _, err := r.Reconcile(item.(reconcile.Request)); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: deploymentName, Namespace: namespace}, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		names := []string{}
+		for _, ev := range dpOut.Spec.Template.Spec.Containers[0].Env {
+			names = append(names, ev.Name)
+		}
+		if expected := []string{"POSTGRES_PASSWORD", "POSTGRES_USERNAME", "POSTGRES_USER"}; !reflect.DeepEqual(names, expected) {
+			t.Errorf("Expected the key described by the upgraded CSV to be bound, found %v", names)
+		}
+
+		// the next version describes the key no more, its variable is removed from the application
+		if err := cl.Update(context.TODO(), csv.DeepCopy()); err != nil {
+			t.Fatalf("update csv: (%v)", err)
+		}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		dpOut = &appsv1.Deployment{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: deploymentName, Namespace: namespace}, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		names = []string{}
+		for _, ev := range dpOut.Spec.Template.Spec.Containers[0].Env {
+			names = append(names, ev.Name)
+		}
+		if expected := []string{"POSTGRES_PASSWORD", "POSTGRES_USERNAME"}; !reflect.DeepEqual(names, expected) {
+			t.Errorf("Expected the key no longer described to be unbound, found %v", names)
+		}
+		bound, err := boundEnvs(dpOut.Spec.Template.GetAnnotations())
+		if err != nil {
+			t.Fatalf("bound envs: (%v)", err)
+		}
+		if expected := []string{"POSTGRES_PASSWORD", "POSTGRES_USERNAME"}; !reflect.DeepEqual(bound[bindingRef(sbr)], expected) {
+			t.Errorf("Expected %v recorded, found %v", expected, bound[bindingRef(sbr)])
+		}
+	})
+
+	t.Run("removed with the ServiceBindingRequest", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, cm.DeepCopy(), dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{
+			client:   cl,
+			scheme:   s,
+			recorder: &record.FakeRecorder{},
+			sources:  newSourceIndex(),
+		}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get sbr: (%v)", err)
+		}
+		now := metav1.Now()
+		sbrOut.SetDeletionTimestamp(&now)
+		if err := cl.Update(context.TODO(), sbrOut); err != nil {
+			t.Fatalf("update sbr: (%v)", err)
+		}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if requests := r.sources.requests(sourceKey{kind: "Secret", namespace: namespace, name: "db-credentials"}); len(requests) != 0 {
+			t.Errorf("Expected the unbound ServiceBindingRequest out of the index, found %v", requests)
+		}
+	})
+}
+
+func TestBackingResourceWatchServiceBindingRequestController(t *testing.T) {
+	var (
+		name           = "orders"
+		deploymentName = "my-app"
+		namespace      = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "databases.example.org",
+				ResourceRef:  "orders-db",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	crd := olmv1alpha1.CRDDescription{
+		Name:    "databases.example.org",
+		Version: "v1alpha1",
+		Kind:    "Database",
+		StatusDescriptors: []olmv1alpha1.StatusDescriptor{{
+			Path:         "dbCredentials",
+			XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+		}},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-operator.v0.1.0",
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{crd},
+			},
+		},
+	}
+
+	db := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.org/v1alpha1",
+			"kind":       "Database",
+			"metadata": map[string]interface{}{
+				"name":      "orders-db",
+				"namespace": namespace,
+			},
+			"status": map[string]interface{}{
+				"dbCredentials": "orders-db-credentials",
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "orders-db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+	s.AddKnownTypeWithName(crdGVK(crd), &unstructured.Unstructured{})
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// newReconciler returns a reconciler recording the kinds watched
+	newReconciler := func(objs ...runtime.Object) (*ReconcileServiceBindingRequest, *[]schema.GroupVersionKind) {
+		watched := []schema.GroupVersionKind{}
+		r := &ReconcileServiceBindingRequest{
+			client:   fake.NewFakeClient(objs...),
+			scheme:   s,
+			recorder: &record.FakeRecorder{},
+			sources:  newSourceIndex(),
+			backingKinds: newKindWatcher(func(gvk schema.GroupVersionKind) error {
+				watched = append(watched, gvk)
+				return nil
+			}),
+		}
+		return r, &watched
+	}
+
+	// enqueued returns the requests of a change to the database of the name
+	enqueued := func(r *ReconcileServiceBindingRequest, dbName string) []interface{} {
+		queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer queue.ShutDown()
+		changed := db.DeepCopy()
+		changed.SetName(dbName)
+		r.sources.eventHandler("Database.example.org").Update(event.UpdateEvent{
+			MetaOld: changed, ObjectOld: changed, MetaNew: changed, ObjectNew: changed,
+		}, queue)
+		items := []interface{}{}
+		for queue.Len() > 0 {
+			item, _ := queue.Get()
+			queue.Done(item)
+			items = append(items, item)
+		}
+		return items
+	}
+
+	t.Run("changed backing resource", func(t *testing.T) {
+		r, watched := newReconciler(sbr.DeepCopy(), csv, db, secret, dp.DeepCopy())
+		for i := 0; i < 2; i++ {
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+		}
+		if len(*watched) != 1 || (*watched)[0] != crdGVK(crd) {
+			t.Errorf("Expected the backing resource kind to be watched once, found %v", *watched)
+		}
+		if items := enqueued(r, "orders-db"); len(items) != 1 || items[0] != req {
+			t.Errorf("Expected the ServiceBindingRequest to be requeued, found %v", items)
+		}
+		if items := enqueued(r, "other-db"); len(items) != 0 {
+			t.Errorf("Expected no request for a backing resource not referenced, found %v", items)
+		}
+	})
+
+	t.Run("created backing resource", func(t *testing.T) {
+		r, _ := newReconciler(sbr.DeepCopy(), csv, secret, dp.DeepCopy())
+		if _, err := r.Reconcile(req); err == nil {
+			t.Fatalf("reconcile worked without the backing resource")
+		}
+		if items := enqueued(r, "orders-db"); len(items) != 1 || items[0] != req {
+			t.Errorf("Expected the ServiceBindingRequest to be requeued once the backing resource exists, found %v", items)
+		}
+	})
+}
+
+func TestBackingResourceStatusServiceBindingRequestController(t *testing.T) {
+	var (
+		name           = "orders"
+		deploymentName = "my-app"
+		namespace      = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "databases.example.org",
+				ResourceRef:  "orders-db",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+		},
+	}
+
+	crd := olmv1alpha1.CRDDescription{
+		Name:    "databases.example.org",
+		Version: "v1alpha1",
+		Kind:    "Database",
+		StatusDescriptors: []olmv1alpha1.StatusDescriptor{{
+			Path:         "dbCredentials",
+			XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+		}},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database-operator.v0.1.0",
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{crd},
+			},
+		},
+	}
+
+	// the database operator has yet to populate the status
+	db := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.org/v1alpha1",
+			"kind":       "Database",
+			"metadata": map[string]interface{}{
+				"name":      "orders-db",
+				"namespace": namespace,
+			},
+			"status": map[string]interface{}{},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "orders-db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+	s.AddKnownTypeWithName(crdGVK(crd), &unstructured.Unstructured{})
+
+	cl := fake.NewFakeClient(sbr, csv, db.DeepCopy(), secret, dp)
+	r := &ReconcileServiceBindingRequest{
+		client:   cl,
+		scheme:   s,
+		recorder: &record.FakeRecorder{},
+		sources:  newSourceIndex(),
+	}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter != notReadyMinDelay {
+		t.Errorf("Expected a requeue after %v while the backing resource status isn't populated, found %+v", notReadyMinDelay, res)
+	}
+
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err = cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	cond := getCondition(&sbrOut.Status, v1alpha1.CollectionReady)
+	if cond == nil || cond.Status != corev1.ConditionUnknown || cond.Reason != "CollectionNotReady" {
+		t.Fatalf("Expected the CollectionReady condition to be Unknown with reason CollectionNotReady, found %+v", cond)
+	}
+	if sbrOut.Status.Phase != v1alpha1.PhasePending {
+		t.Errorf("Expected phase %s, found %s", v1alpha1.PhasePending, sbrOut.Status.Phase)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	nn := types.NamespacedName{Name: deploymentName, Namespace: namespace}
+	if err = cl.Get(context.TODO(), nn, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	if env := dpOut.Spec.Template.Spec.Containers[0].Env; len(env) != 0 {
+		t.Fatalf("Expected nothing bound before the status is populated, found %v", env)
+	}
+
+	// the backing resource is still read through, its status change requeues the request
+	if requests := r.sources.requests(sourceKey{kind: "Database.example.org", namespace: namespace, name: "orders-db"}); len(requests) != 1 {
+		t.Errorf("Expected the ServiceBindingRequest indexed by its backing resource, found %v", requests)
+	}
+
+	populated := db.DeepCopy()
+	if err = unstructured.SetNestedField(populated.Object, "orders-db-credentials", "status", "dbCredentials"); err != nil {
+		t.Fatalf("set status: (%v)", err)
+	}
+	if err = cl.Update(context.TODO(), populated); err != nil {
+		t.Fatalf("update database: (%v)", err)
+	}
+	if _, err = r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dpOut = &appsv1.Deployment{}
+	if err = cl.Get(context.TODO(), nn, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	env := dpOut.Spec.Template.Spec.Containers[0].Env
+	if len(env) != 1 || env[0].Name != "ORDERS_PASSWORD" || env[0].ValueFrom == nil ||
+		env[0].ValueFrom.SecretKeyRef == nil || env[0].ValueFrom.SecretKeyRef.Name != "orders-db-credentials" {
+		t.Errorf("Expected the populated secret to be bound, found %v", env)
+	}
+
+	sbrOut = &v1alpha1.ServiceBindingRequest{}
+	if err = cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	if sbrOut.Status.Phase != v1alpha1.PhaseReady {
+		t.Errorf("Expected phase %s, found %s", v1alpha1.PhaseReady, sbrOut.Status.Phase)
+	}
+}
+
+func TestSecretListServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "orders"
+		namespace = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{ResourceName: "databases.example.org", ResourceRef: "orders-db"},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+		},
+	}
+	crd := olmv1alpha1.CRDDescription{
+		Name:    "databases.example.org",
+		Version: "v1alpha1",
+		Kind:    "Database",
+		StatusDescriptors: []olmv1alpha1.StatusDescriptor{{
+			Path: "secrets",
+			XDescriptors: []string{
+				"urn:alm:descriptor:servicebindingrequest:secret:username",
+				"urn:alm:descriptor:servicebindingrequest:secret:password",
+			},
+		}},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "database-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{Owned: []olmv1alpha1.CRDDescription{crd}},
+		},
+	}
+	// both secrets hold the same keys
+	db := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.org/v1alpha1",
+			"kind":       "Database",
+			"metadata":   map[string]interface{}{"name": "orders-db", "namespace": namespace},
+			"status":     map[string]interface{}{"secrets": []interface{}{"admin", "app"}},
+		},
+	}
+	secret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"username": []byte(name), "password": []byte("s3cr3t")},
+		}
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: namespace,
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+	s.AddKnownTypeWithName(crdGVK(crd), &unstructured.Unstructured{})
+
+	cl := fake.NewFakeClient(sbr, csv, db, secret("admin"), secret("app"), dp)
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	dpOut := &appsv1.Deployment{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "my-app", Namespace: namespace}, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	// the keys of both secrets are bound, qualified by the secret
+	bound := map[string]string{}
+	for _, env := range dpOut.Spec.Template.Spec.Containers[0].Env {
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+			t.Fatalf("Expected %s to reference a secret, found %+v", env.Name, env)
+		}
+		bound[env.Name] = env.ValueFrom.SecretKeyRef.Name + "/" + env.ValueFrom.SecretKeyRef.Key
+	}
+	expected := map[string]string{
+		"ORDERS_ADMIN_USERNAME": "admin/username",
+		"ORDERS_ADMIN_PASSWORD": "admin/password",
+		"ORDERS_APP_USERNAME":   "app/username",
+		"ORDERS_APP_PASSWORD":   "app/password",
+	}
+	if !reflect.DeepEqual(bound, expected) {
+		t.Errorf("Expected %v bound, found %v", expected, bound)
+	}
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	if !reflect.DeepEqual(sbrOut.Status.Secrets, []string{"admin", "app"}) {
+		t.Errorf("Expected the secrets to be 'admin' and 'app', found %v", sbrOut.Status.Secrets)
+	}
+}
+
+func TestSecretRefServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "orders"
+		namespace = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "databases.example.org",
+				ResourceRef:  "orders-db",
+				SecretRef:    "{{ .metadata.name }}-credentials",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+		},
+	}
+	// the CSV has no secret descriptor, the operator names the secret after the resource
+	crd := olmv1alpha1.CRDDescription{Name: "databases.example.org", Version: "v1alpha1", Kind: "Database"}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "database-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{Owned: []olmv1alpha1.CRDDescription{crd}},
+		},
+	}
+	db := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.org/v1alpha1",
+			"kind":       "Database",
+			"metadata":   map[string]interface{}{"name": "orders-db", "namespace": namespace},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders-db-credentials", Namespace: namespace},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: namespace,
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+	s.AddKnownTypeWithName(crdGVK(crd), &unstructured.Unstructured{})
+
+	// the secret is created by the database operator after the resource
+	cl := &selectingClient{Client: fake.NewFakeClient(sbr, csv, db, dp)}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter != notReadyMinDelay {
+		t.Errorf("Expected a requeue after %v while the secret is missing, found %+v", notReadyMinDelay, res)
+	}
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err = cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	cond := getCondition(&sbrOut.Status, v1alpha1.SourceSecretFound)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "SecretNotFound" {
+		t.Errorf("Expected the SourceSecretFound condition to be False with reason SecretNotFound, found %+v", cond)
+	}
+
+	if err = cl.Create(context.TODO(), secret); err != nil {
+		t.Fatalf("create secret: (%v)", err)
+	}
+	if _, err = r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	dpOut := &appsv1.Deployment{}
+	if err = cl.Get(context.TODO(), types.NamespacedName{Name: "my-app", Namespace: namespace}, dpOut); err != nil {
+		t.Fatalf("get deployment: (%v)", err)
+	}
+	env := dpOut.Spec.Template.Spec.Containers[0].Env
+	if len(env) != 1 || env[0].Name != "ORDERS_PASSWORD" || env[0].ValueFrom == nil || env[0].ValueFrom.SecretKeyRef == nil ||
+		env[0].ValueFrom.SecretKeyRef.Name != "orders-db-credentials" {
+		t.Errorf("Expected ORDERS_PASSWORD to reference 'orders-db-credentials', found %+v", env)
+	}
+
+	// the source collected from is recorded when the sources are listed
+	if err = cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	sbrOut.Spec.BackingSelector.Sources = []string{"descriptors", "secretRef"}
+	if err = cl.Update(context.TODO(), sbrOut); err != nil {
+		t.Fatalf("update sbr: (%v)", err)
+	}
+	if _, err = r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	sbrOut = &v1alpha1.ServiceBindingRequest{}
+	if err = cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get sbr: (%v)", err)
+	}
+	expected := []v1alpha1.CollectedSource{{ResourceName: "databases.example.org", Source: "secretRef"}}
+	if !reflect.DeepEqual(sbrOut.Status.CollectedSources, expected) {
+		t.Errorf("Expected the collected sources %+v, found %+v", expected, sbrOut.Status.CollectedSources)
+	}
+}
+
+func TestNotReadyResult(t *testing.T) {
+	waiting := func(since time.Duration) *v1alpha1.ServiceBindingRequest {
+		sbr := &v1alpha1.ServiceBindingRequest{}
+		cond := newCondition(v1alpha1.SourceSecretFound, corev1.ConditionFalse, "SecretNotFound", "")
+		cond.LastTransitionTime = metav1.NewTime(time.Now().Add(-since))
+		sbr.Status.Conditions = []v1alpha1.Condition{cond}
+		return sbr
+	}
+
+	tests := []struct {
+		name string
+		sbr  *v1alpha1.ServiceBindingRequest
+		min  time.Duration
+		max  time.Duration
+	}{
+		{name: "no condition", sbr: &v1alpha1.ServiceBindingRequest{}, min: notReadyMinDelay, max: notReadyMinDelay},
+		{name: "just waiting", sbr: waiting(time.Second), min: notReadyMinDelay, max: notReadyMinDelay},
+		{name: "waiting a while", sbr: waiting(time.Minute), min: time.Minute, max: time.Minute + time.Second},
+		{name: "waiting long", sbr: waiting(time.Hour), min: notReadyMaxDelay, max: notReadyMaxDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := notReadyResult(tt.sbr, v1alpha1.SourceSecretFound)
+			if res.Requeue || res.RequeueAfter < tt.min || res.RequeueAfter > tt.max {
+				t.Errorf("Expected a requeue after %v to %v, found %+v", tt.min, tt.max, res)
+			}
+		})
+	}
+}
+
+func TestRecreatedApplicationServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			InlineNonSensitive: true,
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr, &v1alpha1.ServiceBindingRequestList{})
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path:         "db-config",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:configmap:username"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"username": "postgres",
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// enqueued returns the requests of the application object created
+	enqueued := func(r *ReconcileServiceBindingRequest, obj *appsv1.Deployment) []interface{} {
+		queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer queue.ShutDown()
+		r.applicationHandler().Create(event.CreateEvent{Meta: obj, Object: obj}, queue)
+		items := []interface{}{}
+		for queue.Len() > 0 {
+			item, _ := queue.Get()
+			queue.Done(item)
+			items = append(items, item)
+		}
+		return items
+	}
+
+	t.Run("recreated deployment", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, cm, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		// a pipeline replaces the deployment with its original manifest
+		if err := cl.Delete(context.TODO(), dp.DeepCopy()); err != nil {
+			t.Fatalf("delete deployment: (%v)", err)
+		}
+		recreated := dp.DeepCopy()
+		if err := cl.Create(context.TODO(), recreated); err != nil {
+			t.Fatalf("create deployment: (%v)", err)
+		}
+
+		items := enqueued(r, recreated)
+		if len(items) != 1 || items[0] != req {
+			t.Fatalf("Expected the ServiceBindingRequest to be requeued, found %v", items)
+		}
+		if _, err := r.Reconcile(items[0].(reconcile.Request)); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		nn := types.NamespacedName{
+			Name:      deploymentName,
+			Namespace: namespace,
+		}
+		if err := cl.Get(context.TODO(), nn, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		if env := dpOut.Spec.Template.Spec.Containers[0].Env; len(env) != 2 {
+			t.Errorf("Expected the injection to be restored, found: %v", env)
+		}
+	})
+
+	t.Run("not selected", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv, secret, cm, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		unlabeled := dp.DeepCopy()
+		unlabeled.SetLabels(map[string]string{"connects-to": "mysql"})
+		if items := enqueued(r, unlabeled); len(items) != 0 {
+			t.Errorf("Expected no request for a deployment not matching the labels, found %v", items)
+		}
+		elsewhere := dp.DeepCopy()
+		elsewhere.SetNamespace("other")
+		if items := enqueued(r, elsewhere); len(items) != 0 {
+			t.Errorf("Expected no request for a deployment in another namespace, found %v", items)
+		}
+	})
+}
+
+func TestDryRunServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			InlineNonSensitive: true,
+			DryRun:             true,
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path:         "db-config",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:configmap:username"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"username": "postgres",
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		cl := &recordingClient{Client: fake.NewFakeClient(sbr.DeepCopy(), csv, secret, cm, dp.DeepCopy())}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if len(cl.mutations) != 0 {
+			t.Errorf("Expected no mutation, found %v", cl.mutations)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := r.client.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		expected := &v1alpha1.DryRunStatus{
+			EnvVars:            []string{"POSTGRES_PASSWORD", "POSTGRES_USERNAME"},
+			ApplicationObjects: []string{namespace + "/" + deploymentName},
+		}
+		if !reflect.DeepEqual(sbrOut.Status.DryRun, expected) {
+			t.Errorf("Dry run status not matching: expected %+v, got %+v", expected, sbrOut.Status.DryRun)
+		}
+		if c := getCondition(&sbrOut.Status, v1alpha1.InjectionReady); c == nil || c.Reason != "DryRun" {
+			t.Errorf("InjectionReady condition not matching: %v", c)
+		}
+		if len(sbrOut.GetFinalizers()) != 0 {
+			t.Errorf("Expected no finalizer, found %v", sbrOut.GetFinalizers())
+		}
+	})
+
+	t.Run("dry run of a bound application", func(t *testing.T) {
+		bound := sbr.DeepCopy()
+		bound.Spec.DryRun = false
+		cl := fake.NewFakeClient(bound, csv, secret, cm, dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		sbrOut.Spec.DryRun = true
+		if err := cl.Update(context.TODO(), sbrOut); err != nil {
+			t.Fatalf("update service binding request: (%v)", err)
+		}
+		recording := &recordingClient{Client: cl}
+		r.client = recording
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if len(recording.mutations) != 0 {
+			t.Errorf("Expected no mutation, found %v", recording.mutations)
+		}
+
+		sbrOut = &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		if sbrOut.Status.DryRun == nil || len(sbrOut.Status.DryRun.ApplicationObjects) != 0 {
+			t.Errorf("Expected no application to change, found %+v", sbrOut.Status.DryRun)
+		}
+	})
+}
+
+func TestApplicationsMatchedServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{
+					"connects-to": "postgres",
+				},
+			},
+			InlineNonSensitive: true,
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: namespace,
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: namespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path:         "db-config",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:configmap:username"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"username": "postgres",
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"connects-to": "postgres",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	matched := func(t *testing.T, objs ...runtime.Object) *v1alpha1.ServiceBindingRequest {
+		cl := &selectingClient{Client: fake.NewFakeClient(append(objs, sbr.DeepCopy(), csv, secret, cm)...)}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		return sbrOut
+	}
+
+	t.Run("no matching applications", func(t *testing.T) {
+		unlabeled := dp.DeepCopy()
+		unlabeled.SetLabels(map[string]string{"connects-to": "postgresql"})
+		sbrOut := matched(t, unlabeled)
+
+		c := getCondition(&sbrOut.Status, v1alpha1.ApplicationsMatched)
+		if c == nil || c.Status != corev1.ConditionFalse || c.Reason != "NoMatchingApplications" {
+			t.Fatalf("ApplicationsMatched condition not matching: %+v", c)
+		}
+		if !strings.Contains(c.Message, "matchLabels: connects-to=postgres}") {
+			t.Errorf("Selector not echoed: %s", c.Message)
+		}
+		if sbrOut.Status.Phase != v1alpha1.PhasePending {
+			t.Errorf("Phase not matching: %s", sbrOut.Status.Phase)
+		}
+	})
+
+	t.Run("matching applications", func(t *testing.T) {
+		other := dp.DeepCopy()
+		other.SetName("my-other-app")
+		sbrOut := matched(t, dp.DeepCopy(), other)
+
+		c := getCondition(&sbrOut.Status, v1alpha1.ApplicationsMatched)
+		if c == nil || c.Status != corev1.ConditionTrue || c.Message != "2 application(s) matched" {
+			t.Fatalf("ApplicationsMatched condition not matching: %+v", c)
+		}
+		if sbrOut.Status.Phase != v1alpha1.PhaseReady {
+			t.Errorf("Phase not matching: %s", sbrOut.Status.Phase)
+		}
+	})
+}
+
+func TestBackingNamespaceServiceBindingRequestController(t *testing.T) {
+	var (
+		backingOperatorName = "postgresql-operator.v0.1.0"
+		name                = "postgres"
+		deploymentName      = "my-app"
+		namespace           = "team-a"
+		backingNamespace    = "data"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+				Namespace:    backingNamespace,
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				ResourceKind: "Deployment",
+				ResourceRef:  deploymentName,
+			},
+		},
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-credentials",
+			Namespace: backingNamespace,
+		},
+		Data: map[string][]byte{
+			"password": []byte("secret"),
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-config",
+			Namespace: backingNamespace,
+		},
+		Data: map[string]string{
+			"host": "orders-db.data.svc",
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	// Add CSV scheme
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	// Add Deployment scheme
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	// the CSV is looked up in the backing namespace
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backingOperatorName,
+			Namespace: backingNamespace,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+							{
+								Path:         "db-config",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:configmap:host"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName,
+			Namespace: namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	t.Run("namespace not allowed", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv.DeepCopy(), secret.DeepCopy(), cm.DeepCopy(), dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{
+			client:                   cl,
+			scheme:                   s,
+			recorder:                 &record.FakeRecorder{},
+			allowedBackingNamespaces: []string{"shared"},
+		}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get sbr: (%v)", err)
+		}
+		cond := getCondition(&sbrOut.Status, v1alpha1.CollectionReady)
+		if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "NamespaceNotAllowed" {
+			t.Errorf("Expected a NamespaceNotAllowed condition, found %+v", cond)
+		}
+
+		copied := &corev1.Secret{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "db-credentials"}, copied)
+		if !errors.IsNotFound(err) {
+			t.Errorf("Expected the secret not to be copied, found %v", err)
+		}
+	})
+
+	t.Run("copy into the request namespace", func(t *testing.T) {
+		cl := fake.NewFakeClient(sbr.DeepCopy(), csv.DeepCopy(), secret.DeepCopy(), cm.DeepCopy(), dp.DeepCopy())
+		r := &ReconcileServiceBindingRequest{
+			client:                   cl,
+			scheme:                   s,
+			recorder:                 &record.FakeRecorder{},
+			allowedBackingNamespaces: []string{backingNamespace},
+			sources:                  newSourceIndex(),
+		}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+
+		copiedSecret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "db-credentials"}, copiedSecret); err != nil {
+			t.Fatalf("get copied secret: (%v)", err)
+		}
+		if string(copiedSecret.Data["password"]) != "secret" {
+			t.Errorf("Copied secret data not matching: %v", copiedSecret.Data)
+		}
+		if copiedSecret.GetLabels()[mirroredFromLabel] != backingNamespace {
+			t.Errorf("Copied secret label not matching: %v", copiedSecret.GetLabels())
+		}
+		copiedConfigMap := &corev1.ConfigMap{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "db-config"}, copiedConfigMap); err != nil {
+			t.Fatalf("get copied config map: (%v)", err)
+		}
+		if copiedConfigMap.Data["host"] != "orders-db.data.svc" {
+			t.Errorf("Copied config map data not matching: %v", copiedConfigMap.Data)
+		}
+
+		dpOut := &appsv1.Deployment{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: deploymentName}, dpOut); err != nil {
+			t.Fatalf("get deployment: (%v)", err)
+		}
+		env := dpOut.Spec.Template.Spec.Containers[0].Env
+		if len(env) != 2 {
+			t.Fatalf("Expected 2 environment variables, found %v", env)
+		}
+		for _, ev := range env {
+			switch ev.Name {
+			case "POSTGRES_PASSWORD":
+				if ev.ValueFrom.SecretKeyRef == nil || ev.ValueFrom.SecretKeyRef.Name != "db-credentials" {
+					t.Errorf("Environment variable not referencing the copied secret: %+v", ev)
+				}
+			case "POSTGRES_HOST":
+				if ev.ValueFrom.ConfigMapKeyRef == nil || ev.ValueFrom.ConfigMapKeyRef.Name != "db-config" {
+					t.Errorf("Environment variable not referencing the copied config map: %+v", ev)
+				}
+			default:
+				t.Errorf("Unexpected environment variable: %+v", ev)
+			}
+		}
+
+		// a change to the original requeues the request, which updates the copy
+		original := sourceKey{kind: "Secret", namespace: backingNamespace, name: "db-credentials"}
+		if requests := r.sources.requests(original); len(requests) != 1 {
+			t.Errorf("Expected the original secret to requeue the request, found %v", requests)
+		}
+		rotated := secret.DeepCopy()
+		rotated.Data["password"] = []byte("rotated")
+		if err := cl.Update(context.TODO(), rotated); err != nil {
+			t.Fatalf("update secret: (%v)", err)
+		}
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		copiedSecret = &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "db-credentials"}, copiedSecret); err != nil {
+			t.Fatalf("get copied secret: (%v)", err)
+		}
+		if string(copiedSecret.Data["password"]) != "rotated" {
+			t.Errorf("Copied secret not updated: %v", copiedSecret.Data)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		dryRun := sbr.DeepCopy()
+		dryRun.Spec.DryRun = true
+		// the type and provider files are written to the flattened secret
+		dryRun.Spec.BindAsFiles = true
+		// composed from the config map, read from the backing namespace
+		dryRun.Spec.Mappings = []v1alpha1.Mapping{{To: "url", Value: "postgres://{{ .host }}"}}
+		typed := csv.DeepCopy()
+		typed.Spec.CustomResourceDefinitions.Owned[0].Kind = "SpecialDB"
+		typed.Spec.CustomResourceDefinitions.Owned[0].Version = "v1alpha1"
+		cl := &recordingClient{Client: fake.NewFakeClient(dryRun, typed, secret.DeepCopy(), cm.DeepCopy(), dp.DeepCopy())}
+		r := &ReconcileServiceBindingRequest{
+			client:                   cl,
+			scheme:                   s,
+			recorder:                 &record.FakeRecorder{},
+			allowedBackingNamespaces: []string{backingNamespace},
+		}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if len(cl.mutations) != 0 {
+			t.Errorf("Expected no mutation, found %v", cl.mutations)
+		}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "db-credentials"}, &corev1.Secret{}); !errors.IsNotFound(err) {
+			t.Errorf("Expected the secret not to be copied, found %v", err)
+		}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "db-config"}, &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+			t.Errorf("Expected the config map not to be copied, found %v", err)
+		}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name + "-flattened"}, &corev1.Secret{}); !errors.IsNotFound(err) {
+			t.Errorf("Expected the flattened secret not to be written, found %v", err)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		status := sbrOut.Status.DryRun
+		if status == nil {
+			t.Fatalf("Expected a dry run status, found %+v", sbrOut.Status)
+		}
+		expectedSecrets := []string{namespace + "/db-credentials", namespace + "/" + name + "-flattened"}
+		if !reflect.DeepEqual(status.Secrets, expectedSecrets) {
+			t.Errorf("Secrets not matching: expected %v, got %v", expectedSecrets, status.Secrets)
+		}
+		if expected := []string{namespace + "/db-config"}; !reflect.DeepEqual(status.ConfigMaps, expected) {
+			t.Errorf("Config maps not matching: expected %v, got %v", expected, status.ConfigMaps)
+		}
+		expectedEnvs := []string{"POSTGRES_HOST", "POSTGRES_PASSWORD", "POSTGRES_PROVIDER", "POSTGRES_TYPE", "POSTGRES_URL"}
+		if !reflect.DeepEqual(status.EnvVars, expectedEnvs) {
+			t.Errorf("Environment variables not matching: expected %v, got %v", expectedEnvs, status.EnvVars)
+		}
+	})
+}
+
+func TestStatusSubresourceServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 1,
+			// removed once bound, an update of the metadata only
+			Annotations: map[string]string{forceRebindAnnotation: "2019-06-01T10:00:00Z"},
+		},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: namespace,
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+
+	cl := &generationClient{Client: fake.NewFakeClient(sbr, csv, secret, dp)}
+	r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	bound := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, bound); err != nil {
+		t.Fatalf("get service binding request: (%v)", err)
+	}
+	// the annotation is removed once the status is written, the update returning the stored status
+	if bound.Status.BoundApplications != 1 || !reflect.DeepEqual(bound.Status.ApplicationObjects, []string{"default/my-app"}) {
+		t.Errorf("Bound applications not in status: %d %v", bound.Status.BoundApplications, bound.Status.ApplicationObjects)
+	}
+	// reconciled again once bound
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	sbrOut := &v1alpha1.ServiceBindingRequest{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+		t.Fatalf("get service binding request: (%v)", err)
+	}
+	if sbrOut.Status.Phase != v1alpha1.PhaseReady {
+		t.Errorf("Phase not matching: %s", sbrOut.Status.Phase)
+	}
+	if _, ok := sbrOut.GetAnnotations()[forceRebindAnnotation]; ok {
+		t.Errorf("Annotation '%s' was not cleared", forceRebindAnnotation)
+	}
+	if sbrOut.GetGeneration() != 1 {
+		t.Errorf("Generation bumped writing the status: %d", sbrOut.GetGeneration())
+	}
+	if cl.specChanges != 0 {
+		t.Errorf("Expected status updates to leave the spec alone, %d changed it", cl.specChanges)
+	}
+	if !reflect.DeepEqual(sbrOut.Spec, sbr.Spec) {
+		t.Errorf("Spec changed: expected %+v, got %+v", sbr.Spec, sbrOut.Spec)
+	}
+}
+
+func TestPhasesServiceBindingRequestController(t *testing.T) {
+	var (
+		name      = "postgres"
+		namespace = "default"
+	)
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.ServiceBindingRequestSpec{
+			BackingSelector: v1alpha1.BackingSelector{
+				ResourceName: "specialdb.example.org",
+			},
+			ApplicationSelector: v1alpha1.ApplicationSelector{
+				MatchLabels: map[string]string{"connects-to": "postgres"},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: namespace},
+	}
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-operator.v0.1.0", Namespace: namespace},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: []olmv1alpha1.CRDDescription{
+					{
+						Name: "specialdb.example.org",
+						SpecDescriptors: []olmv1alpha1.SpecDescriptor{
+							{
+								Path:         "db-credentials",
+								XDescriptors: []string{"urn:alm:descriptor:servicebindingrequest:secret:password"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	dp := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app",
+			Namespace: namespace,
+			Labels:    map[string]string{"connects-to": "postgres"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, sbr)
+	if err := olmv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add CSV scheme: (%v)", err)
+	}
+	if err := appsv1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add Deployment scheme: (%v)", err)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+
+	t.Run("bound", func(t *testing.T) {
+		cl := &phaseRecordingClient{Client: fake.NewFakeClient(sbr.DeepCopy(), csv, secret, dp)}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected := []v1alpha1.ServiceBindingRequestPhase{v1alpha1.PhaseCollecting, v1alpha1.PhaseBinding, v1alpha1.PhaseReady}
+		if !reflect.DeepEqual(cl.phases, expected) {
+			t.Errorf("Phases not matching: expected %v, got %v", expected, cl.phases)
+		}
+
+		// once bound, the phase only changes when done
+		cl.phases = nil
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected = []v1alpha1.ServiceBindingRequestPhase{v1alpha1.PhaseReady}
+		if !reflect.DeepEqual(cl.phases, expected) {
+			t.Errorf("Phases not matching: expected %v, got %v", expected, cl.phases)
+		}
+	})
+
+	t.Run("secret missing", func(t *testing.T) {
+		cl := &phaseRecordingClient{Client: fake.NewFakeClient(sbr.DeepCopy(), csv, dp)}
+		r := &ReconcileServiceBindingRequest{client: cl, scheme: s, recorder: &record.FakeRecorder{}}
+
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		expected := []v1alpha1.ServiceBindingRequestPhase{v1alpha1.PhaseCollecting, v1alpha1.PhaseError}
+		if !reflect.DeepEqual(cl.phases, expected) {
+			t.Errorf("Phases not matching: expected %v, got %v", expected, cl.phases)
+		}
+
+		sbrOut := &v1alpha1.ServiceBindingRequest{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, sbrOut); err != nil {
+			t.Fatalf("get service binding request: (%v)", err)
+		}
+		ready := getCondition(&sbrOut.Status, v1alpha1.Ready)
+		if ready == nil || !strings.HasPrefix(ready.Message, "SourceSecretFound failed: ") {
+			t.Errorf("Expected the Ready condition to tell the failed step, found %+v", ready)
+		}
+	})
+}
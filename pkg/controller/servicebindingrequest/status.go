@@ -0,0 +1,120 @@
+package servicebindingrequest
+
+import (
+	"fmt"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newCondition returns a condition of the given type and status.
+func newCondition(
+	t v1alpha1.ConditionType,
+	status corev1.ConditionStatus,
+	reason string,
+	message string,
+) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:    t,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// getCondition returns the condition of the given type, or nil when not present.
+func getCondition(status *v1alpha1.ServiceBindingRequestStatus, t v1alpha1.ConditionType) *v1alpha1.Condition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == t {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setCondition adds or replaces the condition of the same type. The transition time is only
+// changed when the condition status changes.
+func setCondition(status *v1alpha1.ServiceBindingRequestStatus, condition v1alpha1.Condition) {
+	existing := getCondition(status, condition.Type)
+	if existing == nil {
+		condition.LastTransitionTime = metav1.Now()
+		status.Conditions = append(status.Conditions, condition)
+		return
+	}
+
+	if existing.Status != condition.Status {
+		existing.LastTransitionTime = metav1.Now()
+	}
+	existing.Status = condition.Status
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+}
+
+// collectingReason is the reason of the CollectionReady condition while the binding data is
+// collected for the first time.
+const collectingReason = "Collecting"
+
+// failedCondition returns the first failed condition, nil when none failed. The Ready condition is
+// derived from the others and no applications matching is no failure, neither is taken into account.
+func failedCondition(conditions []v1alpha1.Condition) *v1alpha1.Condition {
+	for i, c := range conditions {
+		if c.Type != v1alpha1.Ready && c.Type != v1alpha1.ApplicationsMatched && c.Status == corev1.ConditionFalse {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// computePhase summarizes the conditions. Any failed condition means Error, data being collected
+// means Collecting, collected data not yet injected means Binding, and collected plus injected
+// means Ready. Everything else is Pending, as are no applications matching, which is no failure
+// since they may be created later. The Ready condition is derived from the phase, and is not taken
+// into account.
+func computePhase(conditions []v1alpha1.Condition) v1alpha1.ServiceBindingRequestPhase {
+	if failedCondition(conditions) != nil {
+		return v1alpha1.PhaseError
+	}
+
+	status := &v1alpha1.ServiceBindingRequestStatus{Conditions: conditions}
+	collection := getCondition(status, v1alpha1.CollectionReady)
+	if collection != nil && collection.Status == corev1.ConditionUnknown && collection.Reason == collectingReason {
+		return v1alpha1.PhaseCollecting
+	}
+	if collection == nil || collection.Status != corev1.ConditionTrue {
+		return v1alpha1.PhasePending
+	}
+	matched := getCondition(status, v1alpha1.ApplicationsMatched)
+	if matched != nil && matched.Status == corev1.ConditionFalse {
+		return v1alpha1.PhasePending
+	}
+
+	injection := getCondition(status, v1alpha1.InjectionReady)
+	if injection == nil || injection.Status != corev1.ConditionTrue {
+		return v1alpha1.PhaseBinding
+	}
+	return v1alpha1.PhaseReady
+}
+
+// setPhase sets the phase computed from the conditions, and the Ready condition of the phase. On
+// Error, the Ready condition tells which step failed and why.
+func setPhase(status *v1alpha1.ServiceBindingRequestStatus) {
+	status.Phase = computePhase(status.Conditions)
+	ready := readyCondition(status.Phase)
+	if failed := failedCondition(status.Conditions); failed != nil {
+		ready.Message = fmt.Sprintf("%s failed: %s", failed.Type, failed.Message)
+	}
+	setCondition(status, ready)
+}
+
+// readyCondition returns the Ready condition of the phase: True when Ready, False on Error and
+// Unknown while pending, collecting or binding.
+func readyCondition(phase v1alpha1.ServiceBindingRequestPhase) v1alpha1.Condition {
+	switch phase {
+	case v1alpha1.PhaseReady:
+		return newCondition(v1alpha1.Ready, corev1.ConditionTrue, "", "")
+	case v1alpha1.PhaseError:
+		return newCondition(v1alpha1.Ready, corev1.ConditionFalse, "BindingFailed", "a binding step failed")
+	}
+	return newCondition(v1alpha1.Ready, corev1.ConditionUnknown, string(phase), "")
+}
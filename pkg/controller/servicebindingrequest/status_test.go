@@ -0,0 +1,109 @@
+package servicebindingrequest
+
+import (
+	"testing"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestComputePhase(t *testing.T) {
+	collected := newCondition(v1alpha1.CollectionReady, corev1.ConditionTrue, "", "")
+	notCollected := newCondition(v1alpha1.CollectionReady, corev1.ConditionUnknown, "BackingServiceNotFound", "")
+	collecting := newCondition(v1alpha1.CollectionReady, corev1.ConditionUnknown, collectingReason, "")
+	collectionFailed := newCondition(v1alpha1.CollectionReady, corev1.ConditionFalse, "VersionNotMatching", "")
+	injected := newCondition(v1alpha1.InjectionReady, corev1.ConditionTrue, "", "")
+	injectionFailed := newCondition(v1alpha1.InjectionReady, corev1.ConditionFalse, "InjectionFailed", "")
+	matched := newCondition(v1alpha1.ApplicationsMatched, corev1.ConditionTrue, "", "")
+	notMatched := newCondition(v1alpha1.ApplicationsMatched, corev1.ConditionFalse, "NoMatchingApplications", "")
+
+	tests := []struct {
+		name       string
+		conditions []v1alpha1.Condition
+		want       v1alpha1.ServiceBindingRequestPhase
+	}{
+		{name: "no conditions", conditions: nil, want: v1alpha1.PhasePending},
+		{name: "backing service not found", conditions: []v1alpha1.Condition{notCollected}, want: v1alpha1.PhasePending},
+		{name: "collecting", conditions: []v1alpha1.Condition{collecting}, want: v1alpha1.PhaseCollecting},
+		{name: "collected", conditions: []v1alpha1.Condition{collected}, want: v1alpha1.PhaseBinding},
+		{name: "collected and injected", conditions: []v1alpha1.Condition{collected, injected}, want: v1alpha1.PhaseReady},
+		{name: "collection failed", conditions: []v1alpha1.Condition{collectionFailed, injected}, want: v1alpha1.PhaseError},
+		{name: "injection failed", conditions: []v1alpha1.Condition{collected, injectionFailed}, want: v1alpha1.PhaseError},
+		{name: "matched", conditions: []v1alpha1.Condition{collected, matched, injected}, want: v1alpha1.PhaseReady},
+		{name: "no matching applications", conditions: []v1alpha1.Condition{collected, notMatched, injected}, want: v1alpha1.PhasePending},
+		{name: "recovered", conditions: []v1alpha1.Condition{collected, injected, readyCondition(v1alpha1.PhaseError)}, want: v1alpha1.PhaseReady},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computePhase(tt.conditions); got != tt.want {
+				t.Errorf("computePhase() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	status := &v1alpha1.ServiceBindingRequestStatus{}
+
+	setCondition(status, newCondition(v1alpha1.CollectionReady, corev1.ConditionUnknown, "BackingServiceNotFound", ""))
+	setCondition(status, newCondition(v1alpha1.CollectionReady, corev1.ConditionTrue, "", ""))
+
+	if len(status.Conditions) != 1 {
+		t.Fatalf("Expected a single condition, found %d", len(status.Conditions))
+	}
+	c := status.Conditions[0]
+	if c.Status != corev1.ConditionTrue || c.Reason != "" {
+		t.Errorf("Condition not replaced: %+v", c)
+	}
+	if c.LastTransitionTime.IsZero() {
+		t.Error("Condition transition time not set")
+	}
+}
+
+func TestReadyCondition(t *testing.T) {
+	tests := []struct {
+		phase v1alpha1.ServiceBindingRequestPhase
+		want  corev1.ConditionStatus
+	}{
+		{phase: v1alpha1.PhasePending, want: corev1.ConditionUnknown},
+		{phase: v1alpha1.PhaseCollecting, want: corev1.ConditionUnknown},
+		{phase: v1alpha1.PhaseBinding, want: corev1.ConditionUnknown},
+		{phase: v1alpha1.PhaseReady, want: corev1.ConditionTrue},
+		{phase: v1alpha1.PhaseError, want: corev1.ConditionFalse},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			c := readyCondition(tt.phase)
+			if c.Type != v1alpha1.Ready || c.Status != tt.want {
+				t.Errorf("readyCondition() = %+v, want status %s", c, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPhase(t *testing.T) {
+	status := &v1alpha1.ServiceBindingRequestStatus{}
+	setCondition(status, newCondition(v1alpha1.CollectionReady, corev1.ConditionTrue, "", ""))
+	setCondition(status, newCondition(v1alpha1.InjectionReady, corev1.ConditionFalse, "InjectionFailed", "deployment 'my-app' not found"))
+
+	setPhase(status)
+	if status.Phase != v1alpha1.PhaseError {
+		t.Errorf("Phase not matching: %s", status.Phase)
+	}
+	ready := getCondition(status, v1alpha1.Ready)
+	if ready == nil || ready.Status != corev1.ConditionFalse {
+		t.Fatalf("Ready condition not matching: %+v", ready)
+	}
+	if ready.Message != "InjectionReady failed: deployment 'my-app' not found" {
+		t.Errorf("Expected the message to tell the failed step, found '%s'", ready.Message)
+	}
+
+	// the failed Ready condition itself is not a failed step
+	setCondition(status, newCondition(v1alpha1.InjectionReady, corev1.ConditionTrue, "", ""))
+	setPhase(status)
+	if ready = getCondition(status, v1alpha1.Ready); status.Phase != v1alpha1.PhaseReady || ready.Status != corev1.ConditionTrue {
+		t.Errorf("Expected to recover, found phase %s and %+v", status.Phase, ready)
+	}
+}
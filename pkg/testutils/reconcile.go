@@ -0,0 +1,64 @@
+// Package testutils holds helpers shared by the controller tests.
+package testutils
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// AssertReconcileIdempotent reconciles the request twice, and fails the test when the second
+// reconcile changes any of the objects, status included. The objects are only used for their
+// type and key, they are read with the client after each reconcile.
+func AssertReconcileIdempotent(
+	t *testing.T,
+	r reconcile.Reconciler,
+	c client.Client,
+	req reconcile.Request,
+	objs ...runtime.Object,
+) {
+	t.Helper()
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("first reconcile: (%v)", err)
+	}
+	first := snapshot(t, c, objs)
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("second reconcile: (%v)", err)
+	}
+	second := snapshot(t, c, objs)
+
+	for i := range objs {
+		if first[i] != second[i] {
+			t.Errorf("Object changed by the second reconcile:\n%s\n%s", first[i], second[i])
+		}
+	}
+}
+
+// snapshot reads the objects and returns their serialized form.
+func snapshot(t *testing.T, c client.Client, objs []runtime.Object) []string {
+	t.Helper()
+
+	serialized := []string{}
+	for _, obj := range objs {
+		key, err := client.ObjectKeyFromObject(obj)
+		if err != nil {
+			t.Fatalf("object key: (%v)", err)
+		}
+		current := obj.DeepCopyObject()
+		if err = c.Get(context.TODO(), key, current); err != nil {
+			t.Fatalf("get '%s': (%v)", key, err)
+		}
+		data, err := json.Marshal(current)
+		if err != nil {
+			t.Fatalf("marshal '%s': (%v)", key, err)
+		}
+		serialized = append(serialized, string(data))
+	}
+	return serialized
+}
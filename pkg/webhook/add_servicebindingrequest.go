@@ -0,0 +1,11 @@
+package webhook
+
+import (
+	"github.com/redhat-developer/service-binding-operator/pkg/webhook/servicebindingrequest"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to build webhooks and register them in the server.
+	AddToManagerFuncs = append(AddToManagerFuncs, servicebindingrequest.ValidatingWebhook)
+	AddToManagerFuncs = append(AddToManagerFuncs, servicebindingrequest.MutatingWebhook)
+}
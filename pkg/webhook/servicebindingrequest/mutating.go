@@ -0,0 +1,65 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"net/http"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	controller "github.com/redhat-developer/service-binding-operator/pkg/controller/servicebindingrequest"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/builder"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+// MutatingWebhook returns the webhook setting, on creation and update, the defaults of the
+// ServiceBindingRequests.
+func MutatingWebhook(mgr manager.Manager) (*admission.Webhook, error) {
+	return builder.NewWebhookBuilder().
+		Name("mutating.servicebindingrequest.apps.openshift.io").
+		Path("/mutate-servicebindingrequest").
+		Mutating().
+		Operations(admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update).
+		WithManager(mgr).
+		ForType(&v1alpha1.ServiceBindingRequest{}).
+		Handlers(&defaulter{}).
+		Build()
+}
+
+// defaulter sets the defaults of the ServiceBindingRequests of the admission requests.
+type defaulter struct {
+	decoder atypes.Decoder
+}
+
+var _ admission.Handler = &defaulter{}
+
+// Handle patches the ServiceBindingRequest with its defaults, see setDefaults.
+func (d *defaulter) Handle(ctx context.Context, req atypes.Request) atypes.Response {
+	sbr := &v1alpha1.ServiceBindingRequest{}
+	if err := d.decoder.Decode(req, sbr); err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+	defaulted := sbr.DeepCopy()
+	setDefaults(defaulted)
+	return admission.PatchResponse(sbr, defaulted)
+}
+
+// InjectDecoder injects the decoder of the admission requests.
+func (d *defaulter) InjectDecoder(decoder atypes.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// setDefaults sets the fields left empty to the values the controller would use for them: the
+// Deployment resource kind, and the mount path of the bindings as files. Fields already set are
+// kept, so it can be applied again without changes. The mount path is left empty when the name is
+// still to be generated, the name being part of it.
+func setDefaults(sbr *v1alpha1.ServiceBindingRequest) {
+	if sbr.Spec.ApplicationSelector.ResourceKind == "" {
+		sbr.Spec.ApplicationSelector.ResourceKind = "Deployment"
+	}
+	if sbr.Spec.BindAsFiles && sbr.Spec.MountPath == "" && sbr.GetName() != "" {
+		sbr.Spec.MountPath = controller.MountPath(sbr)
+	}
+}
@@ -0,0 +1,104 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDefaulter(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("decoder: (%v)", err)
+	}
+	d := &defaulter{}
+	if err = d.InjectDecoder(decoder); err != nil {
+		t.Fatalf("inject decoder: (%v)", err)
+	}
+
+	tests := []struct {
+		name  string
+		sbr   metav1.ObjectMeta
+		spec  v1alpha1.ServiceBindingRequestSpec
+		paths []string
+	}{
+		{
+			name:  "resource kind",
+			sbr:   metav1.ObjectMeta{Name: "postgres"},
+			paths: []string{"/spec/applicationSelector/resourceKind"},
+		},
+		{
+			name:  "mount path",
+			sbr:   metav1.ObjectMeta{Name: "postgres"},
+			spec:  v1alpha1.ServiceBindingRequestSpec{BindAsFiles: true},
+			paths: []string{"/spec/applicationSelector/resourceKind", "/spec/mountPath"},
+		},
+		{
+			name: "mount path of a generated name",
+			sbr:  metav1.ObjectMeta{GenerateName: "postgres-"},
+			spec: v1alpha1.ServiceBindingRequestSpec{
+				ApplicationSelector: v1alpha1.ApplicationSelector{ResourceKind: "Deployment"},
+				BindAsFiles:         true,
+			},
+		},
+		{
+			name: "already set",
+			sbr:  metav1.ObjectMeta{Name: "postgres"},
+			spec: v1alpha1.ServiceBindingRequestSpec{
+				ApplicationSelector: v1alpha1.ApplicationSelector{ResourceKind: "StatefulSet"},
+				BindAsFiles:         true,
+				MountPath:           "/var/run/postgres",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "ServiceBindingRequest"},
+				ObjectMeta: tt.sbr,
+				Spec:       tt.spec,
+			}
+
+			resp := d.Handle(context.TODO(), admissionRequest(t, sbr))
+			if !resp.Response.Allowed {
+				t.Fatalf("Expected the request to be allowed: %v", resp.Response.Result)
+			}
+			paths := []string{}
+			for _, patch := range resp.Patches {
+				paths = append(paths, patch.Path)
+			}
+			sort.Strings(paths)
+			if len(paths) != len(tt.paths) || (len(paths) > 0 && !reflect.DeepEqual(paths, tt.paths)) {
+				t.Errorf("Expected the patches of %v, found %v", tt.paths, resp.Patches)
+			}
+
+			// the defaults applied again change nothing
+			defaulted := sbr.DeepCopy()
+			setDefaults(defaulted)
+			resp = d.Handle(context.TODO(), admissionRequest(t, defaulted))
+			if len(resp.Patches) != 0 {
+				t.Errorf("Expected no patch of the defaulted ServiceBindingRequest, found %v", resp.Patches)
+			}
+		})
+	}
+}
+
+func TestSetDefaultsMountPath(t *testing.T) {
+	sbr := &v1alpha1.ServiceBindingRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres"},
+		Spec:       v1alpha1.ServiceBindingRequestSpec{BindAsFiles: true},
+	}
+	setDefaults(sbr)
+	if sbr.Spec.MountPath != "/bindings/postgres" {
+		t.Errorf("Mount path not matching: %s", sbr.Spec.MountPath)
+	}
+}
@@ -0,0 +1,148 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"text/template"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	controller "github.com/redhat-developer/service-binding-operator/pkg/controller/servicebindingrequest"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/builder"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+// ValidatingWebhook returns the webhook rejecting, on creation and update, the
+// ServiceBindingRequests the controller can't bind.
+func ValidatingWebhook(mgr manager.Manager) (*admission.Webhook, error) {
+	return builder.NewWebhookBuilder().
+		Name("validating.servicebindingrequest.apps.openshift.io").
+		Path("/validate-servicebindingrequest").
+		Validating().
+		Operations(admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update).
+		WithManager(mgr).
+		ForType(&v1alpha1.ServiceBindingRequest{}).
+		Handlers(&validator{}).
+		Build()
+}
+
+// validator validates the ServiceBindingRequests of the admission requests.
+type validator struct {
+	decoder atypes.Decoder
+}
+
+var _ admission.Handler = &validator{}
+
+// Handle admits the ServiceBindingRequest unless invalid, see validate.
+func (v *validator) Handle(ctx context.Context, req atypes.Request) atypes.Response {
+	sbr := &v1alpha1.ServiceBindingRequest{}
+	if err := v.decoder.Decode(req, sbr); err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+	if errs := validate(sbr); len(errs) > 0 {
+		return admission.ValidationResponse(false, errs.ToAggregate().Error())
+	}
+	return admission.ValidationResponse(true, "")
+}
+
+// InjectDecoder injects the decoder of the admission requests.
+func (v *validator) InjectDecoder(d atypes.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// validate returns the errors of the spec fields the controller would fail to bind with: the
+// backing services without a resource name, the incomplete or invalid mappings and application values, the
+// applications selected by no labels nor references, or of kinds not supported, and the containers
+// selected both by index and name.
+func validate(sbr *v1alpha1.ServiceBindingRequest) field.ErrorList {
+	errs := field.ErrorList{}
+	spec := field.NewPath("spec")
+
+	if sbr.Spec.BackingSelector.ResourceName == "" {
+		errs = append(errs, field.Required(spec.Child("backingSelector", "resourceName"), ""))
+	}
+	for i, selector := range sbr.Spec.BackingServices {
+		if selector.ResourceName == "" {
+			errs = append(errs, field.Required(spec.Child("backingServices").Index(i).Child("resourceName"), ""))
+		}
+	}
+
+	for i, mapping := range sbr.Spec.Mappings {
+		path := spec.Child("mappings").Index(i)
+		switch {
+		case mapping.From == "" && mapping.Value == "":
+			errs = append(errs, field.Required(path.Child("from"), "either from or value is required"))
+		case mapping.From != "" && mapping.Value != "":
+			errs = append(errs, field.Forbidden(path.Child("value"), "may not be set along with from"))
+		case mapping.Value != "":
+			if _, err := template.New(mapping.To).Parse(mapping.Value); err != nil {
+				errs = append(errs, field.Invalid(path.Child("value"), mapping.Value, err.Error()))
+			}
+		}
+		switch {
+		case mapping.Type != "" && mapping.Type != v1alpha1.MappingTypeJSON:
+			errs = append(errs, field.NotSupported(path.Child("type"), mapping.Type, []string{string(v1alpha1.MappingTypeJSON)}))
+		case mapping.Type == v1alpha1.MappingTypeJSON && mapping.From == "" && mapping.Value != "":
+			errs = append(errs, field.Required(path.Child("from"), "the key flattened by type json is required"))
+		}
+		if mapping.Secret && mapping.Value == "" {
+			errs = append(errs, field.Forbidden(path.Child("secret"), "only a composed value can be written into the secret"))
+		}
+		if mapping.To == "" {
+			errs = append(errs, field.Required(path.Child("to"), ""))
+		} else if mapping.Secret {
+			for _, msg := range validation.IsConfigMapKey(mapping.To) {
+				errs = append(errs, field.Invalid(path.Child("to"), mapping.To, msg))
+			}
+		}
+	}
+
+	if name := sbr.Spec.SecretName; name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			errs = append(errs, field.Invalid(spec.Child("secretName"), name, msg))
+		}
+	}
+	// as the API server validates annotations
+	for key := range sbr.Spec.SecretAnnotations {
+		for _, msg := range validation.IsQualifiedName(strings.ToLower(key)) {
+			errs = append(errs, field.Invalid(spec.Child("secretAnnotations"), key, msg))
+		}
+	}
+
+	keys := map[string]bool{}
+	for i, value := range sbr.Spec.ApplicationValues {
+		path := spec.Child("applicationValues").Index(i)
+		if value.Key == "" {
+			errs = append(errs, field.Required(path.Child("key"), ""))
+		} else {
+			for _, msg := range validation.IsConfigMapKey(value.Key) {
+				errs = append(errs, field.Invalid(path.Child("key"), value.Key, msg))
+			}
+		}
+		if keys[value.Key] {
+			errs = append(errs, field.Duplicate(path.Child("key"), value.Key))
+		}
+		keys[value.Key] = true
+		if value.APIVersion == "" {
+			errs = append(errs, field.Required(path.Child("apiVersion"), ""))
+		}
+		if value.Kind == "" {
+			errs = append(errs, field.Required(path.Child("kind"), ""))
+		}
+		if value.Name == "" {
+			errs = append(errs, field.Required(path.Child("name"), ""))
+		}
+		if value.Path == "" {
+			errs = append(errs, field.Required(path.Child("path"), ""))
+		}
+	}
+
+	errs = append(errs, controller.ValidateApplicationSelector(sbr, spec.Child("applicationSelector"))...)
+	return errs
+}
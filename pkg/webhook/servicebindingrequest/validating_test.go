@@ -0,0 +1,305 @@
+package servicebindingrequest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	v1alpha1 "github.com/redhat-developer/service-binding-operator/pkg/apis/apps/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+// admissionRequest returns the admission request creating the ServiceBindingRequest.
+func admissionRequest(t *testing.T, sbr *v1alpha1.ServiceBindingRequest) atypes.Request {
+	raw, err := json.Marshal(sbr)
+	if err != nil {
+		t.Fatalf("marshal: (%v)", err)
+	}
+	return atypes.Request{AdmissionRequest: &admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func TestValidator(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.ServiceBindingRequest{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("decoder: (%v)", err)
+	}
+	v := &validator{}
+	if err = v.InjectDecoder(decoder); err != nil {
+		t.Fatalf("inject decoder: (%v)", err)
+	}
+
+	valid := v1alpha1.ServiceBindingRequestSpec{
+		BackingSelector: v1alpha1.BackingSelector{
+			ResourceName: "databases.example.org",
+		},
+		ApplicationSelector: v1alpha1.ApplicationSelector{
+			MatchLabels:  map[string]string{"connects-to": "postgres"},
+			ResourceKind: "Deployment",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		change  func(spec *v1alpha1.ServiceBindingRequestSpec)
+		allowed bool
+		field   string
+	}{
+		{
+			name:    "valid",
+			change:  func(spec *v1alpha1.ServiceBindingRequestSpec) {},
+			allowed: true,
+		},
+		{
+			name: "named application",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.MatchLabels = nil
+				spec.ApplicationSelector.ResourceRef = "my-app"
+			},
+			allowed: true,
+		},
+		{
+			name: "owned application",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.MatchLabels = nil
+				spec.ApplicationSelector.OwnedRef = &v1alpha1.OwnedRef{APIVersion: "v1", Kind: "Pod", Name: "my-app-6d4cf56db6-x7k2p"}
+			},
+			allowed: true,
+		},
+		{
+			name: "owned application along with refs",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.ResourceRef = "my-app"
+				spec.ApplicationSelector.OwnedRef = &v1alpha1.OwnedRef{APIVersion: "v1", Kind: "Pod", Name: "my-app-6d4cf56db6-x7k2p"}
+			},
+			field: "spec.applicationSelector.ownedRef",
+		},
+		{
+			name: "owned object without name",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.OwnedRef = &v1alpha1.OwnedRef{APIVersion: "v1", Kind: "Pod"}
+			},
+			field: "spec.applicationSelector.ownedRef.name",
+		},
+		{
+			name: "generic workload",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.Group = "example.org"
+				spec.ApplicationSelector.Version = "v1"
+				spec.ApplicationSelector.ResourceKind = "Workload"
+			},
+			allowed: true,
+		},
+		{
+			name: "group without version",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.Group = "argoproj.io"
+				spec.ApplicationSelector.ResourceKind = "Rollout"
+			},
+			field: "spec.applicationSelector.version",
+		},
+		{
+			name: "empty backing selector",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.BackingSelector = v1alpha1.BackingSelector{}
+			},
+			field: "spec.backingSelector.resourceName",
+		},
+		{
+			name: "empty backing service",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.BackingServices = []v1alpha1.BackingSelector{{}}
+			},
+			field: "spec.backingServices[0].resourceName",
+		},
+		{
+			name: "empty application selector",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.MatchLabels = nil
+			},
+			field: "spec.applicationSelector.matchLabels",
+		},
+		{
+			name: "unsupported kind",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.ResourceKind = "Pod"
+			},
+			field: "spec.applicationSelector.resourceKind",
+		},
+		{
+			name: "unsupported referenced kind",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.Refs = []v1alpha1.NamespacedRef{{Namespace: "default", Name: "backup", Kind: "Pod"}}
+			},
+			field: "spec.applicationSelector.refs[0].kind",
+		},
+		{
+			name: "pod",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.Version = "v1"
+				spec.ApplicationSelector.ResourceKind = "Pod"
+			},
+			field: "spec.applicationSelector.resourceKind",
+		},
+		{
+			name: "mapping without target",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.Mappings = []v1alpha1.Mapping{{From: "user"}}
+			},
+			field: "spec.mappings[0].to",
+		},
+		{
+			name: "composed mapping",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.Mappings = []v1alpha1.Mapping{{To: "jdbc-url", Value: "jdbc:postgresql://{{ .host }}"}}
+			},
+			allowed: true,
+		},
+		{
+			name: "invalid mapping template",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.Mappings = []v1alpha1.Mapping{{To: "jdbc-url", Value: "jdbc:postgresql://{{ .host }"}}
+			},
+			field: "spec.mappings[0].value",
+		},
+		{
+			name: "flattened mapping",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.Mappings = []v1alpha1.Mapping{{From: "connection", To: "db", Type: v1alpha1.MappingTypeJSON}}
+			},
+			allowed: true,
+		},
+		{
+			name: "unknown mapping type",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.Mappings = []v1alpha1.Mapping{{From: "connection", To: "db", Type: "yaml"}}
+			},
+			field: "spec.mappings[0].type",
+		},
+		{
+			name: "composed value written into the secret",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.Mappings = []v1alpha1.Mapping{{To: "dsn", Value: "postgres://{{ .host }}", Secret: true}}
+			},
+			allowed: true,
+		},
+		{
+			name: "renamed key written into the secret",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.Mappings = []v1alpha1.Mapping{{From: "user", To: "username", Secret: true}}
+			},
+			field: "spec.mappings[0].secret",
+		},
+		{
+			name: "secret name",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.SecretName = "orders-binding"
+			},
+			allowed: true,
+		},
+		{
+			name: "invalid secret name",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.SecretName = "Orders_Binding"
+			},
+			field: "spec.secretName",
+		},
+		{
+			name: "secret annotations",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.SecretAnnotations = map[string]string{"kms.example.org/encrypt": "true"}
+			},
+			allowed: true,
+		},
+		{
+			name: "invalid secret annotation",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.SecretAnnotations = map[string]string{"kms.example.org/encrypt?": "true"}
+			},
+			field: "spec.secretAnnotations",
+		},
+		{
+			name: "application value",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationValues = []v1alpha1.ApplicationValue{
+					{Key: "host", APIVersion: "route.openshift.io/v1", Kind: "Route", Name: "my-app", Path: "ingress[0].host"},
+				}
+			},
+			allowed: true,
+		},
+		{
+			name: "application value without path",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationValues = []v1alpha1.ApplicationValue{
+					{Key: "host", APIVersion: "route.openshift.io/v1", Kind: "Route", Name: "my-app"},
+				}
+			},
+			field: "spec.applicationValues[0].path",
+		},
+		{
+			name: "duplicate application value key",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				value := v1alpha1.ApplicationValue{Key: "host", APIVersion: "route.openshift.io/v1", Kind: "Route", Name: "my-app", Path: "ingress[0].host"}
+				spec.ApplicationValues = []v1alpha1.ApplicationValue{value, value}
+			},
+			field: "spec.applicationValues[1].key",
+		},
+		{
+			name: "containers along with container index",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				idx := 0
+				spec.ContainerIndex = &idx
+				spec.ApplicationSelector.Containers = []string{"app"}
+			},
+			field: "spec.applicationSelector.containers",
+		},
+		{
+			name: "label expressions",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.MatchLabels = nil
+				spec.ApplicationSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+					{Key: "connects-to", Operator: metav1.LabelSelectorOpExists},
+				}
+			},
+			allowed: true,
+		},
+		{
+			name: "invalid label expression",
+			change: func(spec *v1alpha1.ServiceBindingRequestSpec) {
+				spec.ApplicationSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: metav1.LabelSelectorOpIn},
+				}
+			},
+			field: "spec.applicationSelector.matchExpressions[0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sbr := &v1alpha1.ServiceBindingRequest{
+				TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "ServiceBindingRequest"},
+				ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "default"},
+				Spec:       *valid.DeepCopy(),
+			}
+			tt.change(&sbr.Spec)
+
+			resp := v.Handle(context.TODO(), admissionRequest(t, sbr)).Response
+			if resp.Allowed != tt.allowed {
+				t.Fatalf("Expected allowed to be %v, found %v: %v", tt.allowed, resp.Allowed, resp.Result)
+			}
+			if !tt.allowed && !strings.Contains(string(resp.Result.Reason), tt.field) {
+				t.Errorf("Expected the rejection of %s, found: %s", tt.field, resp.Result.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// AddToManagerFuncs is a list of functions to build the admission webhooks served by the operator
+var AddToManagerFuncs []func(manager.Manager) (*admission.Webhook, error)
+
+// AddToManager adds the admission webhook server, serving all webhooks, to the Manager. The
+// server creates the webhook configurations, the service fronting the operator pods and the
+// secret of its certificate on start.
+func AddToManager(m manager.Manager) error {
+	ns, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		return err
+	}
+
+	svr, err := webhook.NewServer("service-binding-operator-admission-server", m, webhook.ServerOptions{
+		Port:    9876,
+		CertDir: "/tmp/cert",
+		BootstrapOptions: &webhook.BootstrapOptions{
+			MutatingWebhookConfigName:   "service-binding-operator-mutating-webhook",
+			ValidatingWebhookConfigName: "service-binding-operator-validating-webhook",
+			Secret: &types.NamespacedName{
+				Namespace: ns,
+				Name:      "service-binding-operator-webhook-cert",
+			},
+			Service: &webhook.Service{
+				Namespace: ns,
+				Name:      "service-binding-operator-webhook",
+				Selectors: map[string]string{"name": "service-binding-operator"},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range AddToManagerFuncs {
+		wh, err := f(m)
+		if err != nil {
+			return err
+		}
+		if err = svr.Register(wh); err != nil {
+			return err
+		}
+	}
+	return nil
+}